@@ -5,6 +5,7 @@ go 1.17
 require (
 	cloud.google.com/go v0.46.3
 	github.com/AlecAivazis/survey/v2 v2.3.2
+	github.com/BurntSushi/toml v1.2.0
 	github.com/avast/retry-go v2.6.0+incompatible
 	github.com/aws/aws-sdk-go v1.25.43
 	github.com/beevik/ntp v0.3.0
@@ -14,6 +15,7 @@ require (
 	github.com/docker/go-connections v0.4.0
 	github.com/docker/go-units v0.4.0
 	github.com/fatih/color v1.7.0
+	github.com/fsnotify/fsnotify v1.5.1
 	github.com/google/uuid v1.1.1
 	github.com/hashicorp/go-multierror v1.1.0
 	github.com/lorenzosaino/go-sysctl v0.1.0
@@ -13,14 +13,29 @@
 package redpanda
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
 	"net"
+	"os"
+	"os/signal"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/cli"
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
 	vnet "github.com/redpanda-data/redpanda/src/go/rpk/pkg/net"
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 )
@@ -28,14 +43,80 @@ import (
 const (
 	configFileFlag     = "config"
 	configFileFlagDesc = "Redpanda config file, if not set the file will be searched for in the default location"
+	// configStdio is the --config value to read the configuration from
+	// stdin and write it to stdout, for the commands that support it.
+	configStdio         = "-"
+	configFileStdioDesc = configFileFlagDesc + `, or "-" to read it from stdin and write it to stdout`
+
+	backupFlag           = "backup"
+	backupFlagDesc       = "Copy the current config file before overwriting it"
+	backupSuffixFlag     = "backup-suffix"
+	backupSuffixFlagDesc = "Suffix appended to the config file path to name the --backup copy"
+	defaultBackupSuffix  = ".bak"
+
+	preferIPv4 = "ipv4"
+	preferIPv6 = "ipv6"
+	preferAny  = "any"
 )
 
 func NewConfigCommand(fs afero.Fs) *cobra.Command {
 	root := &cobra.Command{
 		Use:   "config <command>",
 		Short: "Edit configuration.",
+		Long: `Edit configuration.
+
+The set, rollback, generate, bootstrap, migrate and validate commands exit
+with the following codes on failure, for scripts to tell the failures apart:
+
+  2  invalid arguments, keys or values
+  3  the configuration cannot be read or written
+  4  the configuration is invalid (validate), a config file cannot be
+     decoded, has unknown keys (--strict), or the config files include each
+     other
+  5  the config file changed since it was read (set --if-match), or a test
+     operation of the patch does not hold (set --patch-type json)
+  1  any other failure, e.g. a network failure
+
+The commands that change or check the configuration report their outcome,
+e.g. the keys that set changed, as text, or as json with --output json, for
+scripts to parse it. --quiet silences it, leaving only the errors. The
+commands that print the configuration itself, such as view and get, print it
+as their own flags request.
+`,
 	}
+	root.PersistentFlags().BoolP(config.FlagVerbose, "v", false, "Print which config file is loaded and written, to stderr")
+	root.PersistentFlags().BoolP(quietFlag, "q", false, "Do not print the outcome of the commands, only their errors")
+	root.PersistentFlags().String(outputFlag, outputText, "Format of the outcome of the commands (text/json)")
+	root.PersistentFlags().String(config.FlagLogLevel, "", "Level of the logs printed to stderr (debug, info, warn, error); debug implies -v")
+	root.PersistentPreRun = func(cmd *cobra.Command, _ []string) {
+		l, err := configLogger(cmd)
+		maybeDieCode(err, exitInvalidInput, "invalid --%s: %v", config.FlagLogLevel, err)
+		config.SetLogger(l)
+	}
+	root.PersistentFlags().Bool(config.FlagStrict, false, "Fail if the config file has unknown keys, instead of warning about them")
+
 	root.AddCommand(set(fs))
+	root.AddCommand(get(fs))
+	root.AddCommand(unset(fs))
+	root.AddCommand(edit(fs))
+	root.AddCommand(rollback(fs))
+	root.AddCommand(view(fs))
+	root.AddCommand(showPath(fs))
+	root.AddCommand(diff(fs))
+	root.AddCommand(importFragment(fs))
+	root.AddCommand(export(fs))
+	root.AddCommand(validate(fs))
+	root.AddCommand(doctor(fs))
+	root.AddCommand(ensureDirs(fs))
+	root.AddCommand(migrate(fs))
+	root.AddCommand(normalizeSeeds(fs))
+	root.AddCommand(seeds(fs))
+	root.AddCommand(env(fs))
+	root.AddCommand(tls(fs))
+	root.AddCommand(listKeys())
+	root.AddCommand(describe())
+	root.AddCommand(keysSchema())
+	root.AddCommand(generate(fs))
 	root.AddCommand(bootstrap(fs))
 	root.AddCommand(initNode(fs))
 
@@ -43,12 +124,37 @@ func NewConfigCommand(fs afero.Fs) *cobra.Command {
 }
 
 func set(fs afero.Fs) *cobra.Command {
+	return newSetCommand(fs, configStore)
+}
+
+func newSetCommand(fs afero.Fs, storeFn storeFunc) *cobra.Command {
 	var (
-		format     string
-		configPath string
+		format       string
+		valueType    string
+		fromFile     string
+		readStdin    bool
+		lockTimeout  time.Duration
+		waitForFile  time.Duration
+		appendValue  bool
+		appendUnique bool
+		force        bool
+		ifMatch      string
+		separator    string
+		deleteEmpty  bool
+		noClobber    bool
+		allowExtra   bool
+		configPath   string
+		createDirs   bool
+		backup       bool
+		backupSuffix string
+		patchType    string
+
+		watch          bool
+		watchInterval  time.Duration
+		roundTripCheck bool
 	)
 	c := &cobra.Command{
-		Use:   "set <key> <value>",
+		Use:   "set <key> <value> | set <key=value>... | set <key> --from-file <path> | set <key> --stdin | set {--format env | --patch-type merge|json} {--from-file <path> | --stdin}",
 		Short: "Set configuration values, such as the node IDs or the list of seed servers",
 		Long: `Set configuration values, such as the node IDs or the list of seed servers
 
@@ -61,72 +167,996 @@ if --format is not used, rpk will use yaml as default, you can also pass
 partial json/yaml config objects:
 
   rpk redpanda config set redpanda.rpc_server '{"address":"0.0.0.0","port":33145}' --format json
+
+The keys rpk knows about are listed by rpk redpanda config list-keys. Each key
+whose value changed is printed with its old and new values, and "no change" is
+printed, without writing the file, if the values are already set.
+
+Examples:
+
+  # Set several keys at once, indexing into lists.
+  rpk redpanda config set redpanda.node_id=1 redpanda.seed_servers.0.host.address=10.0.0.2
+
+  # Read the value from a file, or from stdin.
+  rpk redpanda config set redpanda.rpc_server --from-file rpc.json --format json
+  generate-rpc-server | rpk redpanda config set redpanda.rpc_server --stdin
+
+  # Append a seed server.
+  rpk redpanda config set redpanda.seed_servers '{host: {address: 10.0.0.3, port: 33145}}' --append-unique
+
+  # Apply the KEY=VALUE lines of a dotenv file.
+  get-secrets --dotenv | rpk redpanda config set --format env --stdin
+
+  # Apply a JSON merge patch (RFC 7386) or a JSON patch (RFC 6902).
+  echo '{"redpanda": {"rack": null}}' | rpk redpanda config set --patch-type merge --stdin
+  rpk redpanda config set --patch-type json --from-file patch.json
+
+  # Only set the values if the config file did not change since it was read.
+  rpk redpanda config set redpanda.node_id 1 --if-match "$(rpk redpanda config view --hash)"
+
+  # Keep setting the values whenever the config file drifts from them.
+  rpk redpanda config set redpanda.developer_mode=false --watch
+
+  # Edit a configuration from stdin, writing the result to stdout.
+  cat base.yaml | rpk redpanda config set redpanda.node_id 1 --config - > redpanda.yaml
 `,
-		Args: cobra.ExactArgs(2),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if format == setFormatEnv || patchType != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
+		ValidArgsFunction: completeKeys(fs),
 		Run: func(cmd *cobra.Command, args []string) {
-			p := config.ParamsFromCommand(cmd)
-			cfg, err := p.Load(fs)
-			out.MaybeDie(err, "unable to load config: %v", err)
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			if configPath == configStdio {
+				// Stdout is the configuration itself.
+				pr.w = cmd.ErrOrStderr()
+			}
+			var stdin io.Reader
+			if readStdin {
+				if config.ParamsFromCommand(cmd).ConfigPath == configStdio {
+					out.DieCode(exitInvalidInput, "--stdin cannot be used with --config %s, which reads the configuration from stdin", configStdio)
+				}
+				stdin = cmd.InOrStdin()
+			}
+			if ifMatch != "" && config.ParamsFromCommand(cmd).ConfigPath == configStdio {
+				out.DieCode(exitInvalidInput, "--if-match cannot be used with --config %s, which has no config file", configStdio)
+			}
+			if waitForFile < 0 {
+				out.DieCode(exitInvalidInput, "--wait-for-file must not be negative, got %v", waitForFile)
+			}
+			if waitForFile > 0 && config.ParamsFromCommand(cmd).ConfigPath == configStdio {
+				out.DieCode(exitInvalidInput, "--wait-for-file cannot be used with --config %s, which has no config file", configStdio)
+			}
+			if patchType != "" && patchType != setPatchMerge && patchType != setPatchJSON {
+				out.DieCode(exitInvalidInput, "--patch-type must be %s or %s, got %q", setPatchMerge, setPatchJSON, patchType)
+			}
+			if patchType != "" {
+				for _, f := range []string{"format", "type", "append", "append-unique", "separator", "no-clobber", "delete-empty"} {
+					if cmd.Flags().Changed(f) {
+						out.DieCode(exitInvalidInput, "--patch-type cannot be used with --%s", f)
+					}
+				}
+			}
+			if watch {
+				switch {
+				case configPath == configStdio:
+					out.DieCode(exitInvalidInput, "--watch cannot be used with --config %s, which has no config file", configStdio)
+				case appendValue:
+					out.DieCode(exitInvalidInput, "--watch cannot be used with --append, which would append the value again on every change, use --append-unique")
+				case patchType == setPatchJSON:
+					out.DieCode(exitInvalidInput, "--watch cannot be used with --patch-type %s, which is not idempotent", setPatchJSON)
+				case noClobber:
+					out.DieCode(exitInvalidInput, "--watch cannot be used with --no-clobber, which would never correct an external change")
+				case ifMatch != "":
+					out.DieCode(exitInvalidInput, "--watch cannot be used with --if-match, as the hash changes on every correction")
+				case watchInterval <= 0:
+					out.DieCode(exitInvalidInput, "--watch-interval must be positive, got %v", watchInterval)
+				}
+			}
+			if appendValue && appendUnique {
+				out.DieCode(exitInvalidInput, "--append cannot be used with --append-unique")
+			}
+			if valueType != "" && (appendValue || appendUnique) {
+				out.DieCode(exitInvalidInput, "--type cannot be used with --append nor --append-unique")
+			}
+			splitList := cmd.Flags().Changed("separator")
+			if splitList && (format != "single" || valueType != "" || appendValue || appendUnique) {
+				out.DieCode(exitInvalidInput, "--separator can only be used with --format single, without --type nor --append")
+			}
+			if format == "single" && !splitList {
+				fmt.Fprintln(cmd.ErrOrStderr(), "'--format single' is deprecated, either remove it or use yaml/json")
+			}
+			var (
+				kvs   [][2]string
+				patch []byte
+			)
+			switch {
+			case patchType != "":
+				patch, err = readSetInput(fs, fromFile, stdin, "the patch", "--patch-type "+patchType)
+			case format == setFormatEnv:
+				kvs, err = readEnvSetArgs(fs, fromFile, stdin)
+				// Each value is then decoded as a yaml scalar.
+				format = config.FormatYAML
+			default:
+				kvs, err = readSetArgs(fs, args, fromFile, stdin)
+			}
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			// The keys are checked before waiting, creating the config
+			// directory or locking, as the flags are, for an invalid
+			// input not to leave a directory nor a lock file behind.
+			strict := config.ParamsFromCommand(cmd).Strict
+			for _, kv := range kvs {
+				if allowExtra || config.IsManaged(kv[0]) {
+					continue
+				}
+				if strict {
+					out.DieCode(exitInvalidInput, "%q is not a key rpk manages, use --allow-extra to set it anyway", kv[0])
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %q is not a key rpk manages, it is set as is for redpanda to read; use --allow-extra to silence this warning\n", kv[0])
+			}
 
-			if format == "single" {
-				fmt.Println("'--format single' is deprecated, either remove it or use yaml/json")
+			if waitForFile > 0 {
+				// Rather than writing a default config file over
+				// the one about to be written.
+				_, err = config.ParamsFromCommand(cmd).WaitForConfig(fs, waitForFile)
+				maybeDieCode(err, exitIO, "refusing to set: %v", err)
 			}
-			err = cfg.Set(args[0], args[1], format)
-			out.MaybeDie(err, "unable to set %q:%v", args[0], err)
+			if createDirs {
+				err = createResolvedConfigDir(fs, cmd)
+				maybeDieCode(err, exitIO, "%v", err)
+			}
+			unlock, err := lockConfig(fs, cmd, lockTimeout)
+			maybeDieCode(err, exitIO, "%v", err)
+			// Released before watching, for the other rpk processes
+			// not to wait on the watch.
+			var unlockOnce sync.Once
+			release := func() { unlockOnce.Do(unlock) }
+			defer release()
 
-			err = cfg.Write(fs)
-			out.MaybeDieErr(err)
+			store := storeFn(fs, cmd)
+			cfg, err := readStore(cmd, store)
+			maybeDieLoad(err, "unable to load config: %v", err)
+			if ifMatch != "" {
+				// Checked under the lock, for no other rpk to
+				// change the file before it is written.
+				err = cfg.CheckFileHash(fs, ifMatch)
+				maybeDieCode(err, exitIO, "refusing to set: %v", err)
+			}
+			// The configuration before the set, for the summary.
+			orig := cfg.Clone()
+
+			// apply sets the values onto cfg, once now and again on
+			// every correction with --watch.
+			apply := func(cfg *config.Config) error {
+				if patchType != "" {
+					if err := applySetPatch(cfg, patchType, patch); err != nil {
+						return fmt.Errorf("unable to apply the %s patch: %w", patchType, err)
+					}
+				}
+				for _, kv := range kvs {
+					if noClobber && cfg.IsPinned(kv[0]) {
+						fmt.Fprintf(cmd.ErrOrStderr(), "%s is already set in the config file, not overwriting it\n", kv[0])
+						continue
+					}
+					var err error
+					switch {
+					case appendValue:
+						err = cfg.Append(kv[0], kv[1], format)
+					case appendUnique:
+						err = cfg.AppendUnique(kv[0], kv[1], format)
+					case valueType != "":
+						err = cfg.SetTyped(kv[0], kv[1], valueType)
+					case splitList:
+						err = cfg.SetList(kv[0], kv[1], separator)
+					default:
+						err = cfg.Set(kv[0], kv[1], format)
+					}
+					if err != nil {
+						return fmt.Errorf("unable to set %q:%w", kv[0], err)
+					}
+					if deleteEmpty {
+						if err := cfg.PruneEmpty(kv[0]); err != nil {
+							return fmt.Errorf("unable to prune %q: %w", kv[0], err)
+						}
+					}
+				}
+				return nil
+			}
+			err = apply(cfg)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+
+			if !force && cfg.File() != nil && configPath != configStdio && config.Equal(orig, cfg) {
+				err = pr.Result(setResult{Changed: []setChange{}}, func(w io.Writer) { fmt.Fprintln(w, "no change") })
+				out.MaybeDieErr(err)
+			} else {
+				if roundTripCheck {
+					err = writeStoreChecked(cmd, store, orig, cfg, backup, backupSuffix)
+				} else {
+					err = writeStore(cmd, store, cfg, backup, backupSuffix)
+				}
+				maybeDieCode(err, exitIO, "%v", err)
+				err = printSetResult(pr, orig, cfg, "")
+				out.MaybeDieErr(err)
+			}
+			if !watch {
+				return
+			}
+
+			release()
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			w := &setWatcher{
+				cmd:         cmd,
+				fs:          fs,
+				store:       store,
+				path:        cfg.FileLocation(),
+				lockTimeout: lockTimeout,
+				apply:       apply,
+				write: func(cfg *config.Config) error {
+					return writeStore(cmd, store, cfg, backup, backupSuffix)
+				},
+				pr: pr,
+			}
+			w.run(ctx, watchInterval)
 		},
 	}
-	c.Flags().StringVar(&format, "format", "yaml", "Format of the value (yaml/json)")
+	c.Flags().StringVar(&format, "format", "yaml", "Format of the value (yaml/json, or single to split a list of strings on --separator), or env to read KEY=VALUE lines with --from-file or --stdin")
+	c.Flags().StringVar(&valueType, "type", "", "Parse the scalar value as this type (string/int/bool/float), instead of guessing it")
+	c.Flags().StringVar(&fromFile, "from-file", "", "Read the value from this file, instead of passing it as an argument")
+	c.Flags().BoolVar(&readStdin, "stdin", false, "Read the value from stdin, instead of passing it as an argument")
+	c.Flags().BoolVar(&appendValue, "append", false, "Append the value to the list at the key, instead of replacing the list")
+	c.Flags().BoolVar(&appendUnique, "append-unique", false, "Append the value to the list at the key only if the list holds no equal element")
+	c.Flags().BoolVar(&noClobber, "no-clobber", false, "Do not overwrite the keys that the config file already sets to a non default value")
+	c.Flags().BoolVar(&allowExtra, "allow-extra", false, "Set keys that rpk does not manage without a warning")
+	c.Flags().BoolVar(&force, "force", false, "Write the config file even if the values set are already the current ones")
+	c.Flags().StringVar(&separator, "separator", ",", "Separator of the elements of a list of strings set with --format single")
+	c.Flags().BoolVar(&deleteEmpty, "delete-empty", false, "Remove the unmanaged maps left empty by the set, along with their empty parents")
+	c.Flags().StringVar(&ifMatch, "if-match", "", "Only set the values if the hash of the config file is this one, as printed by view --hash")
+	c.Flags().DurationVar(&waitForFile, "wait-for-file", 0, "If there is no config file, wait up to this long for one to exist rather than writing a default one")
+	c.Flags().BoolVar(&watch, "watch", false, "Keep running once the values are set, setting them again whenever the config file is changed to other values")
+	c.Flags().DurationVar(&watchInterval, "watch-interval", time.Second, "How often --watch checks the config file for changes, if its directory cannot be watched")
+	c.Flags().BoolVar(&roundTripCheck, "round-trip-check", true, "Read the config file back once written, rolling the change back if the values set did not survive")
+	addLockTimeoutFlag(c, &lockTimeout)
+	addCreateDirsFlag(c, &createDirs)
 	c.Flags().StringVar(
 		&configPath,
 		configFileFlag,
 		"",
-		configFileFlagDesc,
+		configFileStdioDesc,
 	)
+	addConfigFormatFlag(c)
+	c.Flags().StringVar(&patchType, "patch-type", "", "Apply the patch read with --from-file or --stdin to the whole configuration, instead of setting keys (merge/json)")
+	addBackupFlags(c, &backup, &backupSuffix)
 	return c
 }
 
+// setResult is what set --output json prints once done.
+type setResult struct {
+	Changed []setChange `json:"changed"`
+}
+
+// setChange is a key that set changed, with its old and new values, null if
+// the key is absent.
+type setChange struct {
+	Key string      `json:"key"`
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// printSetSummary prints the old and new values of each key that set
+// changed, <unset> standing for an absent key.
+func printSetSummary(w io.Writer, diffs []config.FieldDiff) {
+	value := func(v interface{}) string {
+		switch v {
+		case nil:
+			return "<unset>"
+		case "":
+			return `""`
+		}
+		return diffValue(v)
+	}
+	for _, d := range diffs {
+		fmt.Fprintf(w, "%s: %s -> %s\n", d.Key, value(d.Old), value(d.New))
+	}
+}
+
+// printSetResult reports the keys whose value changed from orig to cfg, as
+// text after the header line, if any, or as a setResult.
+func printSetResult(pr *printer, orig, cfg *config.Config, header string) error {
+	diffs, err := config.Diff(orig, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to compare the configurations: %v", err)
+	}
+	res := setResult{Changed: make([]setChange, 0, len(diffs))}
+	for _, d := range diffs {
+		res.Changed = append(res.Changed, setChange(d))
+	}
+	return pr.Result(res, func(w io.Writer) {
+		if header != "" {
+			fmt.Fprintln(w, header)
+		}
+		printSetSummary(w, diffs)
+	})
+}
+
+// setFormatEnv is the set --format of dotenv KEY=VALUE lines.
+const setFormatEnv = "env"
+
+// The set --patch-type of RFC 7386 JSON merge patches and of RFC 6902 JSON
+// patches.
+const (
+	setPatchMerge = "merge"
+	setPatchJSON  = "json"
+)
+
+// applySetPatch applies the patch of the given set --patch-type to cfg.
+func applySetPatch(cfg *config.Config, patchType string, patch []byte) error {
+	switch patchType {
+	case setPatchMerge:
+		return cfg.ApplyMergePatch(patch)
+	case setPatchJSON:
+		return cfg.ApplyJSONPatch(patch)
+	default:
+		return fmt.Errorf("unknown patch type %q", patchType)
+	}
+}
+
+// readEnvSetArgs returns the key value pairs of the dotenv lines read from
+// fromFile or from stdin, see config.ParseEnvFile.
+func readEnvSetArgs(fs afero.Fs, fromFile string, stdin io.Reader) ([][2]string, error) {
+	b, err := readSetInput(fs, fromFile, stdin, "the env lines", "--format "+setFormatEnv)
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseEnvFile(b)
+}
+
+// readSetInput returns what is read from fromFile or from stdin, one of which
+// the flag requiredBy requires.
+func readSetInput(fs afero.Fs, fromFile string, stdin io.Reader, what, requiredBy string) ([]byte, error) {
+	switch {
+	case fromFile != "" && stdin != nil:
+		return nil, errors.New("--from-file and --stdin cannot be used together")
+	case fromFile != "":
+		b, err := afero.ReadFile(fs, fromFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read --from-file %q: %w", fromFile, err)
+		}
+		return b, nil
+	case stdin != nil:
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s from stdin: %w", what, err)
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("%s requires --from-file or --stdin", requiredBy)
+	}
+}
+
+// readSetArgs returns the key value pairs to set, reading the value of the
+// single key in args from fromFile or from stdin, if set.
+func readSetArgs(fs afero.Fs, args []string, fromFile string, stdin io.Reader) ([][2]string, error) {
+	if fromFile == "" && stdin == nil {
+		return parseSetArgs(args)
+	}
+	flag := "--stdin"
+	if fromFile != "" {
+		flag = "--from-file"
+	}
+	if len(args) != 1 || strings.Contains(args[0], "=") {
+		return nil, fmt.Errorf("%s requires a single key, and no value", flag)
+	}
+	b, err := readSetInput(fs, fromFile, stdin, "the value", flag)
+	if err != nil {
+		return nil, err
+	}
+	return [][2]string{{args[0], string(b)}}, nil
+}
+
+// The exit codes of the config commands, see NewConfigCommand.
+const (
+	exitInvalidInput  = 2
+	exitIO            = 3
+	exitInvalidConfig = 4
+	exitConflict      = 5
+)
+
+// configExitCode returns the exit code for the kind of the config error err,
+// or def if err is of no known kind.
+func configExitCode(err error, def int) int {
+	switch {
+	case errors.Is(err, config.ErrKeyNotFound),
+		errors.Is(err, config.ErrInvalidFormat):
+		return exitInvalidInput
+	case errors.Is(err, config.ErrUnknownKey),
+		errors.Is(err, config.ErrIncludeCycle):
+		return exitInvalidConfig
+	case errors.Is(err, config.ErrHashMismatch),
+		errors.Is(err, config.ErrPatchTestFailed):
+		return exitConflict
+	case errors.Is(err, config.ErrWritePermission),
+		errors.Is(err, config.ErrLockTimeout),
+		errors.Is(err, os.ErrPermission),
+		errors.Is(err, os.ErrNotExist):
+		return exitIO
+	default:
+		return def
+	}
+}
+
+// maybeDieCode is out.MaybeDie, but it exits with the exit code of err as
+// returned by configExitCode.
+func maybeDieCode(err error, def int, msg string, args ...interface{}) {
+	if err != nil {
+		out.DieCode(configExitCode(err, def), msg, args...)
+	}
+}
+
+// maybeDieLoad is maybeDieCode for the errors reading the config file, or a
+// backup of it: if the file cannot be decoded, it exits with
+// exitInvalidConfig, as it is the configuration rather than the arguments
+// that is invalid.
+func maybeDieLoad(err error, msg string, args ...interface{}) {
+	if errors.Is(err, config.ErrInvalidFormat) {
+		out.DieCode(exitInvalidConfig, msg, args...)
+	}
+	maybeDieCode(err, exitIO, msg, args...)
+}
+
+// addConfigFormatFlag adds the --config-format flag, which is read through
+// config.ParamsFromCommand.
+func addConfigFormatFlag(c *cobra.Command) {
+	c.Flags().String(config.FlagConfigFormat, "", "Format of the config file (yaml/toml), if not set it is detected from the file extension")
+}
+
+// addCreateDirsFlag adds the --create-dirs flag, see createConfigDir.
+func addCreateDirsFlag(c *cobra.Command, createDirs *bool) {
+	c.Flags().BoolVar(createDirs, "create-dirs", false, "Create the missing parent directories of the config file before writing it, instead of failing")
+}
+
+// createConfigDir creates the missing parent directories of the config file
+// path, for --create-dirs. Without it, writing to a directory that does not
+// exist fails, rather than a typo in --config creating stray directories.
+func createConfigDir(fs afero.Fs, path string) error {
+	if err := fs.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("unable to create the directory of %s: %w", path, err)
+	}
+	return nil
+}
+
+// createResolvedConfigDir is createConfigDir for the config file that cmd
+// loads and writes, if it does not write to stdout.
+func createResolvedConfigDir(fs afero.Fs, cmd *cobra.Command) error {
+	p := config.ParamsFromCommand(cmd)
+	if p.ConfigPath == configStdio {
+		return nil
+	}
+	path, exists, err := p.ResolvePath(fs)
+	if err != nil || exists {
+		return err
+	}
+	return createConfigDir(fs, path)
+}
+
+// addLockTimeoutFlag adds the --lock-timeout flag, see lockConfig.
+func addLockTimeoutFlag(c *cobra.Command, timeout *time.Duration) {
+	c.Flags().DurationVar(timeout, "lock-timeout", 30*time.Second, "How long to wait for other rpk processes to release the config file lock (0 waits forever)")
+}
+
+// lockConfig locks the config file for the load, modify and write cycle of
+// cmd, unless the config is read from stdin.
+func lockConfig(fs afero.Fs, cmd *cobra.Command, timeout time.Duration) (func(), error) {
+	p := config.ParamsFromCommand(cmd)
+	if p.ConfigPath == configStdio {
+		return func() {}, nil
+	}
+	return p.LockConfig(fs, timeout)
+}
+
+// completeKeys completes the key, the first argument of set, get and unset,
+// against the configuration keys, including the indexes of the elements of
+// the lists of the current configuration.
+func completeKeys(fs afero.Fs) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		keys := config.Keys(toComplete)
+		if p := config.ParamsFromCommand(cmd); p.ConfigPath != configStdio {
+			if cfg, err := p.Load(fs); err == nil {
+				keys = cfg.IndexedKeys(toComplete)
+			}
+		}
+		paths := make([]string, 0, len(keys))
+		for _, k := range keys {
+			paths = append(paths, k.Path)
+		}
+		return paths, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func addBackupFlags(c *cobra.Command, backup *bool, backupSuffix *string) {
+	c.Flags().BoolVar(backup, backupFlag, false, backupFlagDesc)
+	c.Flags().StringVar(backupSuffix, backupSuffixFlag, defaultBackupSuffix, backupSuffixFlagDesc)
+}
+
+// storeFunc returns the store of the configuration a command works on.
+type storeFunc func(fs afero.Fs, cmd *cobra.Command) config.ConfigStore
+
+// configStore is the default storeFunc: the configuration is read from stdin
+// and written to stdout if --config is "-", and is the config file otherwise.
+func configStore(fs afero.Fs, cmd *cobra.Command) config.ConfigStore {
+	p := config.ParamsFromCommand(cmd)
+	if p.ConfigPath == configStdio {
+		return config.NewStreamStore(p, cmd.InOrStdin(), cmd.OutOrStdout())
+	}
+	return config.NewFsStore(fs, p)
+}
+
+// loadConfig loads the config, from stdin if --config is "-".
+func loadConfig(fs afero.Fs, cmd *cobra.Command) (*config.Config, error) {
+	return readStore(cmd, configStore(fs, cmd))
+}
+
+// readStore reads the config from the store, reporting where it is read from
+// if --verbose is set.
+func readStore(cmd *cobra.Command, s config.ConfigStore) (*config.Config, error) {
+	if _, ok := s.(*config.StreamStore); ok {
+		logf(cmd, "Reading the configuration from stdin")
+	}
+	cfg, err := s.Read()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.(*config.FsStore); ok {
+		logLoaded(cmd, cfg)
+	}
+	return cfg, nil
+}
+
+// configLogger returns the logger of the config package for cmd, printing to
+// stderr at the --log-level of cmd, info by default. -v only prints the
+// summary of logf, --log-level debug prints the details of the config package
+// as well.
+func configLogger(cmd *cobra.Command) (*log.Logger, error) {
+	level := config.ParamsFromCommand(cmd).LogLevel
+	if level == "" {
+		level = "info"
+	}
+	l, err := config.NewLogger(cmd.ErrOrStderr(), level)
+	if err != nil {
+		return nil, err
+	}
+	l.SetFormatter(cli.NewRpkLogFormatter())
+	return l, nil
+}
+
+// logf prints the message to stderr if --verbose is set, keeping stdout clean
+// for the commands that print the configuration.
+func logf(cmd *cobra.Command, msg string, args ...interface{}) {
+	if config.ParamsFromCommand(cmd).Verbose {
+		fmt.Fprintf(cmd.ErrOrStderr(), msg+"\n", args...)
+	}
+}
+
+// logLoaded reports which config file cfg was loaded from, if --verbose is
+// set.
+func logLoaded(cmd *cobra.Command, cfg *config.Config) {
+	if path := cfg.LoadedPath(); path != "" {
+		logf(cmd, "Loaded config file %s", path)
+		return
+	}
+	logf(cmd, "No config file found, using the default configuration")
+}
+
+// logWrite reports where cfg is about to be written, if --verbose is set.
+func logWrite(cmd *cobra.Command, cfg *config.Config) {
+	if cfg.LoadedPath() != "" {
+		logf(cmd, "Writing config file %s", cfg.FileLocation())
+		return
+	}
+	logf(cmd, "Writing new config file %s", cfg.FileLocation())
+}
+
+// writeConfig writes the config, first backing up the current config file if
+// requested. If --config is "-", the config is written to stdout instead.
+func writeConfig(fs afero.Fs, cmd *cobra.Command, cfg *config.Config, backup bool, backupSuffix string) error {
+	return writeStore(cmd, configStore(fs, cmd), cfg, backup, backupSuffix)
+}
+
+// writeStore writes the config to the store, first backing up the current
+// config file if requested, which only config files support.
+func writeStore(cmd *cobra.Command, s config.ConfigStore, cfg *config.Config, backup bool, backupSuffix string) error {
+	switch fsStore := s.(type) {
+	case *config.StreamStore:
+		logf(cmd, "Writing the configuration to stdout")
+	case *config.FsStore:
+		logWrite(cmd, cfg)
+		if backup {
+			return cfg.WriteWithBackup(fsStore.Fs(), backupSuffix)
+		}
+	default:
+		if backup {
+			return errors.New("backups are only supported for config files")
+		}
+	}
+	return s.Write(cfg)
+}
+
+// fileStore is a store of a config file on a filesystem, such as
+// config.FsStore.
+type fileStore interface {
+	config.ConfigStore
+	Fs() afero.Fs
+}
+
+// writeStoreChecked is writeStore, but the config is then read back from the
+// store, to check that the keys that changed from orig hold their value in
+// cfg, for set --round-trip-check. If any does not, the store is rolled back
+// to what it held before the write, and an error naming the keys is
+// returned. Configurations written to stdout cannot be read back and are not
+// checked.
+func writeStoreChecked(cmd *cobra.Command, s config.ConfigStore, orig, cfg *config.Config, backup bool, backupSuffix string) error {
+	if _, ok := s.(*config.StreamStore); ok {
+		return writeStore(cmd, s, cfg, backup, backupSuffix)
+	}
+	changed, err := config.Diff(orig, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to compare the configurations: %v", err)
+	}
+	rollback, err := snapshotStore(s, orig, cfg.FileLocation())
+	if err != nil {
+		return err
+	}
+	if err := writeStore(cmd, s, cfg, backup, backupSuffix); err != nil {
+		return err
+	}
+
+	var mismatched []string
+	read, err := s.Read()
+	if err == nil {
+		var drift []config.FieldDiff
+		if drift, err = config.Diff(cfg, read); err == nil {
+			want := make(map[string]bool, len(changed))
+			for _, d := range changed {
+				want[d.Key] = true
+			}
+			for _, d := range drift {
+				if want[d.Key] {
+					mismatched = append(mismatched, d.Key)
+				}
+			}
+		}
+	}
+	if err == nil && len(mismatched) == 0 {
+		return nil
+	}
+	if err == nil {
+		err = fmt.Errorf("%s did not read back as set", strings.Join(mismatched, ", "))
+	}
+	if rerr := rollback(); rerr != nil {
+		return fmt.Errorf("round trip check failed: %v; unable to roll back: %v", err, rerr)
+	}
+	return fmt.Errorf("round trip check failed, the change was rolled back: %v", err)
+}
+
+// snapshotStore returns the function that restores the store to what it holds
+// now: the content of the config file at path for file stores, which is the
+// content a backup holds, or orig for the other stores.
+func snapshotStore(s config.ConfigStore, orig *config.Config, path string) (func() error, error) {
+	fsStore, ok := s.(fileStore)
+	if !ok {
+		return func() error { return s.Write(orig) }, nil
+	}
+	fs := fsStore.Fs()
+	info, err := fs.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return func() error { return fs.Remove(path) }, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat the config file: %v", err)
+	}
+	b, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the config file: %v", err)
+	}
+	return func() error { return afero.WriteFile(fs, path, b, info.Mode()) }, nil
+}
+
+// parseSetArgs returns the key value pairs to set, either from the legacy
+// "<key> <value>" form or from one or more "<key>=<value>" arguments.
+func parseSetArgs(args []string) ([][2]string, error) {
+	if len(args) == 2 && !strings.Contains(args[0], "=") {
+		return [][2]string{{args[0], args[1]}}, nil
+	}
+	kvs := make([][2]string, 0, len(args))
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("%q is not a key=value pair", arg)
+		}
+		kvs = append(kvs, [2]string{kv[0], kv[1]})
+	}
+	return kvs, nil
+}
+
+// interfaceAddrsFunc lists the addresses of the given network interface, or
+// of all the machine's network interfaces if the name is empty.
+type interfaceAddrsFunc func(iface string) ([]net.Addr, error)
+
+func interfaceAddrs(iface string) ([]net.Addr, error) {
+	if iface == "" {
+		return net.InterfaceAddrs()
+	}
+	i, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find interface %q: %v", iface, err)
+	}
+	return i.Addrs()
+}
+
+// dialFunc connects to the address on the named network, as net.DialTimeout.
+type dialFunc func(network, address string, timeout time.Duration) (net.Conn, error)
+
+// lookupHostFunc resolves the host to its addresses, as
+// net.Resolver.LookupHost.
+type lookupHostFunc func(ctx context.Context, host string) ([]string, error)
+
 func bootstrap(fs afero.Fs) *cobra.Command {
+	return newBootstrapCommand(fs, configStore, interfaceAddrs, net.DialTimeout, net.DefaultResolver.LookupHost)
+}
+
+func newBootstrapCommand(fs afero.Fs, storeFn storeFunc, addrsFn interfaceAddrsFunc, dialFn dialFunc, lookupFn lookupHostFunc) *cobra.Command {
 	var (
 		ips        []string
+		ipsFile    string
+		resolve    bool
+		join       bool
 		self       string
+		forceSelf  bool
+		iface      string
+		prefer     string
+		family     string
+		cidr       string
 		id         int
+		autoID     bool
+		rpcPort    int
+		kafkaPort  int
+		adminPort  int
 		configPath string
+		dryRun     bool
+		format     string
+		outPath    string
+		annotate   bool
+		skipChecks bool
+
+		timeout     time.Duration
+		lockTimeout time.Duration
+		createDirs  bool
+
+		backup       bool
+		backupSuffix string
 	)
 	c := &cobra.Command{
-		Use:   "bootstrap --id <id> [--self <ip>] [--ips <ip1,ip2,...>]",
+		Use:   "bootstrap {--id <id> | --auto-id} [--self <ip|host>] [--ips <host1,host2,...>]",
 		Short: "Initialize the configuration to bootstrap a cluster",
 		Long:  helpBootstrap,
 		Args:  cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
-			p := config.ParamsFromCommand(cmd)
-			cfg, err := p.Load(fs)
-			out.MaybeDie(err, "unable to load config: %v", err)
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			if pr.json() {
+				if configPath == configStdio {
+					out.DieCode(exitInvalidInput, "--output %s cannot be used with --config %s, which writes the config to stdout", pr.output, configStdio)
+				}
+				if dryRun {
+					out.DieCode(exitInvalidInput, "--output %s cannot be used with --dry-run, which prints the config", pr.output)
+				}
+			}
+			if cmd.Flags().Changed("format") && !dryRun {
+				out.DieCode(exitInvalidInput, "--format requires --dry-run")
+			}
+			switch strings.ToLower(format) {
+			case config.FormatYAML, config.FormatJSON, config.FormatTOML, config.FormatHCL:
+			default:
+				out.DieCode(exitInvalidInput, "invalid --format %q, must be %s, %s, %s or %s", format, config.FormatYAML, config.FormatJSON, config.FormatTOML, config.FormatHCL)
+			}
+			if outPath != "" {
+				switch {
+				case configPath == configStdio:
+					out.DieCode(exitInvalidInput, "--out cannot be used with --config %s, which writes the config to stdout", configStdio)
+				case dryRun:
+					out.DieCode(exitInvalidInput, "--out cannot be used with --dry-run, which prints the config")
+				case backup:
+					out.DieCode(exitInvalidInput, "--out cannot be used with --backup, as the config file is left untouched")
+				}
+			}
 
-			seeds, err := parseSeedIPs(ips)
-			out.MaybeDieErr(err)
+			switch idSet := cmd.Flags().Changed("id"); {
+			case idSet && autoID:
+				out.DieCode(exitInvalidInput, "--id and --auto-id cannot be used together")
+			case !idSet && !autoID:
+				out.DieCode(exitInvalidInput, "either --id or --auto-id must be set")
+			}
+			if self != "" && iface != "" {
+				out.DieCode(exitInvalidInput, "--self and --interface cannot be used together")
+			}
+			switch prefer {
+			case preferIPv4, preferIPv6, preferAny:
+			default:
+				out.DieCode(exitInvalidInput, "invalid --prefer %q, must be one of %s, %s or %s", prefer, preferIPv4, preferIPv6, preferAny)
+			}
+			switch family {
+			case "":
+			case preferIPv4, preferIPv6:
+				// --interface-family already picks among both
+				// families, which --prefer would contradict.
+				if cmd.Flags().Changed("prefer") {
+					out.DieCode(exitInvalidInput, "--prefer and --interface-family cannot be used together")
+				}
+				prefer = preferAny
+			default:
+				out.DieCode(exitInvalidInput, "invalid --interface-family %q, must be %s or %s", family, preferIPv4, preferIPv6)
+			}
 
-			ownIP, err := parseSelfIP(self)
-			out.MaybeDieErr(err)
+			var network *net.IPNet
+			if cidr != "" {
+				_, network, err = net.ParseCIDR(cidr)
+				maybeDieCode(err, exitInvalidInput, "invalid --cidr %q: %v", cidr, err)
+			}
+
+			for _, err := range []error{
+				checkPortFlag("rpc-port", rpcPort),
+				checkPortFlag("kafka-port", kafkaPort),
+				checkPortFlag("admin-port", adminPort),
+			} {
+				maybeDieCode(err, exitInvalidInput, "%v", err)
+			}
+
+			if timeout <= 0 {
+				out.DieCode(exitInvalidInput, "invalid --timeout %v, must be positive", timeout)
+			}
+
+			seeds, err := config.ParseSeedServers(ips, rpcPort)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			if ipsFile != "" {
+				fileIPs, err := readIPsFile(fs, ipsFile)
+				maybeDieCode(err, exitIO, "%v", err)
+				fileSeeds, err := config.ParseSeedServers(fileIPs, rpcPort)
+				maybeDieCode(err, exitInvalidInput, "invalid --ips-file %q: %v", ipsFile, err)
+				seeds = mergeSeedSources(seeds, fileSeeds)
+			}
+			if resolve {
+				seeds, err = resolveSeeds(cmd.ErrOrStderr(), seeds, lookupFn, timeout, skipChecks)
+				out.MaybeDieErr(err)
+				if self != "" {
+					resolved, err := resolveHost(self, lookupFn, timeout)
+					switch {
+					case err == nil:
+						self = resolved
+					case skipChecks:
+						fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %v, keeping --self %q as is\n", err, self)
+					default:
+						out.MaybeDieErr(err)
+					}
+				}
+			}
+			seeds, err = dedupeSeeds(cmd.ErrOrStderr(), seeds, config.ParamsFromCommand(cmd).Strict)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+
+			var (
+				ownAddr string
+				ownIP   net.IP
+			)
+			if self != "" && net.ParseIP(self) == nil {
+				// Hostnames are kept as is, for redpanda to
+				// resolve them at runtime.
+				err = checkSelfHost(self)
+				maybeDieCode(err, exitInvalidInput, "%v", err)
+				ownAddr = self
+			} else {
+				ownIP, err = parseSelfIP(self, iface, prefer, family, network, forceSelf, addrsFn)
+				out.MaybeDieErr(err)
+				ownAddr = ownIP.String()
+			}
+			if autoID {
+				if ownIP == nil {
+					out.DieCode(exitInvalidInput, "--auto-id requires this node's IP, use --resolve to resolve --self %q", self)
+				}
+				id = deriveNodeID(ownIP)
+				fmt.Fprintf(cmd.ErrOrStderr(), "Using node ID %d, derived from %s\n", id, ownIP)
+			}
+
+			// The flags are all checked by now, for an invalid one not to
+			// leave a config directory nor a lock file behind. Only
+			// writing the --config file takes the lock: a dry run or
+			// --out does not even create the lock file.
+			if !dryRun && outPath == "" {
+				if createDirs {
+					err := createResolvedConfigDir(fs, cmd)
+					maybeDieCode(err, exitIO, "%v", err)
+				}
+				unlock, err := lockConfig(fs, cmd, lockTimeout)
+				maybeDieCode(err, exitIO, "%v", err)
+				defer unlock()
+			}
+
+			store := storeFn(fs, cmd)
+			cfg, err := readStore(cmd, store)
+			maybeDieLoad(err, "unable to load config: %v", err)
+
+			orig := cfg.Clone()
 
 			cfg.Redpanda.ID = id
-			cfg.Redpanda.RPCServer.Address = ownIP.String()
+			cfg.Redpanda.RPCServer.Address = ownAddr
+			// The RPC port is only overridden if requested, to keep any
+			// custom port of the current config.
+			if cmd.Flags().Changed("rpc-port") {
+				cfg.Redpanda.RPCServer.Port = rpcPort
+			}
 			cfg.Redpanda.KafkaAPI = []config.NamedSocketAddress{{
-				Address: ownIP.String(),
-				Port:    config.DefaultKafkaPort,
+				Address: ownAddr,
+				Port:    kafkaPort,
 			}}
 
 			cfg.Redpanda.AdminAPI = []config.NamedSocketAddress{{
-				Address: ownIP.String(),
-				Port:    config.DefaultAdminPort,
+				Address: ownAddr,
+				Port:    adminPort,
 			}}
-			cfg.Redpanda.SeedServers = []config.SeedServer{}
-			cfg.Redpanda.SeedServers = seeds
+			if join {
+				cfg.Redpanda.SeedServers, err = joinSeeds(cmd.ErrOrStderr(), cfg.Redpanda.SeedServers, seeds, dialFn, timeout, skipChecks)
+				out.MaybeDieErr(err)
+			} else {
+				_, err = config.SetSeedServers(cfg, config.AssignSeedIDs(cfg.Redpanda.SeedServers, seeds))
+				maybeDieCode(err, exitInvalidInput, "%v", err)
+			}
 
-			err = cfg.Write(fs)
-			out.MaybeDie(err, "error writing config file: %v", err)
+			if dryRun {
+				b, err := config.Render(cfg, format)
+				maybeDieCode(err, exitInvalidInput, "%v", err)
+				fmt.Fprint(cmd.OutOrStdout(), string(b))
+				return
+			}
+
+			if annotate {
+				cfg.SetComment("redpanda.seed_servers", bootstrapAnnotation(time.Now(), ips))
+			}
+
+			writtenPath := cfg.FileLocation()
+			// Re-running bootstrap with the same inputs must not touch
+			// an existing file, so that configuration management tools
+			// do not see a change.
+			if outPath != "" {
+				writtenPath, err = filepath.Abs(outPath)
+				maybeDieCode(err, exitInvalidInput, "invalid --out %q: %v", outPath, err)
+				logf(cmd, "Writing config file %s", writtenPath)
+				if createDirs {
+					err = createConfigDir(fs, writtenPath)
+					maybeDieCode(err, exitIO, "%v", err)
+				}
+				err = cfg.WriteAs(fs, writtenPath)
+				maybeDieCode(err, exitIO, "error writing config file: %v", err)
+			} else if cfg.File() != nil && configPath != configStdio && config.Equal(orig, cfg) {
+				if !pr.json() {
+					pr.Messagef("config already up to date")
+				}
+			} else {
+				err = writeStore(cmd, store, cfg, backup, backupSuffix)
+				maybeDieCode(err, exitIO, "error writing config file: %v", err)
+			}
+
+			// With --config -, the config itself is on stdout.
+			summary := newBootstrapSummary(cfg, writtenPath)
+			var text func(io.Writer)
+			if configPath != configStdio {
+				text = summary.print
+			}
+			err = pr.Result(summary, text)
+			out.MaybeDieErr(err)
 		},
 	}
 	c.Flags().StringSliceVar(
@@ -135,28 +1165,170 @@ func bootstrap(fs afero.Fs) *cobra.Command {
 		[]string{},
 		"The list of known node addresses or hostnames",
 	)
+	c.Flags().StringVar(
+		&ipsFile,
+		"ips-file",
+		"",
+		"File listing known node addresses or hostnames, one per line, in addition to --ips",
+	)
+	c.Flags().BoolVar(
+		&resolve,
+		"resolve",
+		false,
+		"Resolve the hostnames in --ips and --self, instead of letting redpanda resolve them at runtime",
+	)
+	c.Flags().DurationVar(
+		&timeout,
+		"timeout",
+		5*time.Second,
+		"How long each network check, resolving a hostname with --resolve or dialing a cluster member with --join, may take",
+	)
+	c.Flags().BoolVar(
+		&skipChecks,
+		"skip-checks",
+		false,
+		"Warn about the hosts that cannot be resolved with --resolve or reached with --join, instead of failing",
+	)
 	c.Flags().StringVar(
 		&configPath,
 		configFileFlag,
 		"",
-		configFileFlagDesc,
+		configFileStdioDesc,
+	)
+	c.Flags().BoolVar(
+		&join,
+		"join",
+		false,
+		"Join an existing cluster: keep the current seed servers and add the --ips members to them",
 	)
 	c.Flags().StringVar(
 		&self,
 		"self",
 		"",
-		"Hint at this node's IP address from within the list passed in --ips",
+		"Hint at this node's IP address or hostname from within the list passed in --ips",
+	)
+	c.Flags().BoolVar(
+		&forceSelf,
+		"force-self",
+		false,
+		"Use --self even if it is not among this machine's addresses, e.g. behind NAT",
+	)
+	c.Flags().StringVar(
+		&iface,
+		"interface",
+		"",
+		"Use the address of this network interface as this node's IP, instead of --self",
+	)
+	c.Flags().StringVar(
+		&prefer,
+		"prefer",
+		preferIPv4,
+		"IP family to pick this node's address from if --self is not set (ipv4/ipv6/any)",
+	)
+	c.Flags().StringVar(
+		&family,
+		"interface-family",
+		"",
+		"IP family (ipv4/ipv6) to pick this node's address from if this node has addresses of both; cannot be used with --prefer",
+	)
+	c.Flags().StringVar(
+		&cidr,
+		"cidr",
+		"",
+		"Only pick this node's address among the ones within this network, e.g. 10.0.0.0/8",
 	)
 	c.Flags().IntVar(
 		&id,
 		"id",
 		-1,
-		"This node's ID (required).",
+		"This node's ID (required unless --auto-id is set).",
+	)
+	c.Flags().BoolVar(
+		&autoID,
+		"auto-id",
+		false,
+		"Derive this node's ID from its IP address instead of setting --id",
 	)
-	cobra.MarkFlagRequired(c.Flags(), "id")
+	c.Flags().IntVar(
+		&rpcPort,
+		"rpc-port",
+		config.Default().Redpanda.RPCServer.Port,
+		"This node's RPC port, also used for the --ips entries that have no port",
+	)
+	c.Flags().IntVar(
+		&kafkaPort,
+		"kafka-port",
+		config.DefaultKafkaPort,
+		"This node's Kafka API port",
+	)
+	c.Flags().IntVar(
+		&adminPort,
+		"admin-port",
+		config.DefaultAdminPort,
+		"This node's Admin API port",
+	)
+	c.Flags().BoolVar(&dryRun, "dry-run", false, "Print the resulting config to stdout instead of writing it")
+	c.Flags().BoolVar(&annotate, "annotate", false, "Write a comment above the seed servers recording when, by whom and with which --ips they were bootstrapped")
+	c.Flags().StringVar(&outPath, "out", "", "Write the resulting config to this path, leaving the --config file untouched")
+	c.Flags().StringVar(&format, "format", config.FormatYAML, "Format of the config printed by --dry-run (yaml/json/toml/hcl)")
+	addConfigFormatFlag(c)
+	addLockTimeoutFlag(c, &lockTimeout)
+	addCreateDirsFlag(c, &createDirs)
+	addBackupFlags(c, &backup, &backupSuffix)
 	return c
 }
 
+// bootstrapSummary is what bootstrap prints once done, as text or with
+// --output json.
+type bootstrapSummary struct {
+	ID          int                 `json:"id"`
+	SelfIP      string              `json:"self_ip"`
+	SeedServers []config.SeedServer `json:"seed_servers"`
+	ConfigPath  string              `json:"config_path"`
+}
+
+// bootstrapAnnotation returns the --annotate comment, for humans to trace
+// which bootstrap wrote the seed servers.
+func bootstrapAnnotation(now time.Time, ips []string) string {
+	who := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		who = u.Username
+	}
+	if who == "" {
+		who = "unknown user"
+	}
+	return fmt.Sprintf("Seed servers written by rpk redpanda config bootstrap\non %s by %s with --ips %q",
+		now.UTC().Format(time.RFC3339), who, strings.Join(ips, ","))
+}
+
+// print prints the summary as text, for the operator to check the node ID
+// and seed servers bootstrap settled on.
+func (s bootstrapSummary) print(w io.Writer) {
+	seeds := make([]string, 0, len(s.SeedServers))
+	for _, seed := range s.SeedServers {
+		seeds = append(seeds, net.JoinHostPort(seed.Host.Address, strconv.Itoa(seed.Host.Port)))
+	}
+	if len(seeds) == 0 {
+		seeds = append(seeds, "none")
+	}
+	fmt.Fprintf(w, "Config file:  %s\n", s.ConfigPath)
+	fmt.Fprintf(w, "Node ID:      %d\n", s.ID)
+	fmt.Fprintf(w, "Seed servers: %s\n", strings.Join(seeds, ", "))
+}
+
+func newBootstrapSummary(cfg *config.Config, path string) bootstrapSummary {
+	seeds := cfg.Redpanda.SeedServers
+	if seeds == nil {
+		seeds = []config.SeedServer{}
+	}
+	return bootstrapSummary{
+		ID:          cfg.Redpanda.ID,
+		SelfIP:      cfg.Redpanda.RPCServer.Address,
+		SeedServers: seeds,
+		ConfigPath:  path,
+	}
+}
+
 func initNode(fs afero.Fs) *cobra.Command {
 	var configPath string
 	c := &cobra.Command{
@@ -167,6 +1339,7 @@ func initNode(fs afero.Fs) *cobra.Command {
 			p := config.ParamsFromCommand(cmd)
 			cfg, err := p.Load(fs)
 			out.MaybeDie(err, "unable to load config: %v", err)
+			logLoaded(cmd, cfg)
 
 			// Don't reset the node's UUID if it has already been set.
 			if cfg.NodeUUID == "" {
@@ -175,6 +1348,7 @@ func initNode(fs afero.Fs) *cobra.Command {
 				cfg.NodeUUID = id.String()
 			}
 
+			logWrite(cmd, cfg)
 			err = cfg.Write(fs)
 			out.MaybeDie(err, "error writing config file: %v", err)
 		},
@@ -188,15 +1362,20 @@ func initNode(fs afero.Fs) *cobra.Command {
 	return c
 }
 
-func parseSelfIP(self string) (net.IP, error) {
+func parseSelfIP(self, iface, prefer, family string, cidr *net.IPNet, forceSelf bool, addrsFn interfaceAddrsFunc) (net.IP, error) {
 	if self != "" {
 		ownIP := net.ParseIP(self)
 		if ownIP == nil {
 			return nil, fmt.Errorf("%s is not a valid IP", self)
 		}
+		if !forceSelf {
+			if err := checkSelfIP(ownIP, addrsFn); err != nil {
+				return nil, err
+			}
+		}
 		return ownIP, nil
 	} else {
-		ownIP, err := getOwnIP()
+		ownIP, err := getOwnIP(iface, prefer, family, cidr, addrsFn)
 		if err != nil {
 			return nil, err
 		}
@@ -204,34 +1383,230 @@ func parseSelfIP(self string) (net.IP, error) {
 	}
 }
 
-func parseSeedIPs(ips []string) ([]config.SeedServer, error) {
-	defaultRPCPort := config.Default().Redpanda.RPCServer.Port
-	var seeds []config.SeedServer
+// checkSelfHost checks that host is a plain hostname, with no scheme nor port.
+func checkSelfHost(host string) error {
+	scheme, hostport, err := vnet.ParseHostMaybeScheme(host)
+	if err != nil {
+		return err
+	}
+	if scheme != "" || strings.Contains(hostport, ":") {
+		return fmt.Errorf("invalid --self %q, must be an IP or a hostname with no port", host)
+	}
+	return nil
+}
+
+// resolveHost returns the first address host resolves to, or host itself if
+// it is an IP already. The resolution fails if it takes longer than timeout.
+func resolveHost(host string, lookupFn lookupHostFunc, timeout time.Duration) (string, error) {
+	if net.ParseIP(host) != nil {
+		return host, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	addrs, err := lookupFn(ctx, host)
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("unable to resolve %q: timed out after %v", host, timeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve %q: %v", host, err)
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("unable to resolve %q: no addresses found", host)
+	}
+	return addrs[0], nil
+}
 
-	for _, i := range ips {
-		_, hostport, err := vnet.ParseHostMaybeScheme(i)
-		if err != nil {
+// resolveSeeds returns the seeds with their hostnames replaced by the address
+// they resolve to. With skipChecks, the hostnames that cannot be resolved are
+// warned about and kept as is, for redpanda to resolve them at runtime.
+func resolveSeeds(w io.Writer, seeds []config.SeedServer, lookupFn lookupHostFunc, timeout time.Duration, skipChecks bool) ([]config.SeedServer, error) {
+	resolved := make([]config.SeedServer, 0, len(seeds))
+	for _, s := range seeds {
+		addr, err := resolveHost(s.Host.Address, lookupFn, timeout)
+		switch {
+		case err == nil:
+			s.Host.Address = addr
+		case skipChecks:
+			fmt.Fprintf(w, "Warning: %v, keeping it as is\n", err)
+		default:
+			return nil, err
+		}
+		resolved = append(resolved, s)
+	}
+	return resolved, nil
+}
+
+// checkSelfIP checks that ip is one of the machine's non-loopback addresses.
+func checkSelfIP(ip net.IP, addrsFn interfaceAddrsFunc) error {
+	addrs, err := addrsFn("")
+	if err != nil {
+		return err
+	}
+	for _, a := range addrs {
+		if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.Equal(ip) && !ip.IsLoopback() {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not among this machine's non-loopback addresses, use --force-self to use it anyway", ip)
+}
+
+// deriveNodeID returns a node ID that only depends on the node's IP, so that
+// re-running bootstrap on the same node always results in the same ID.
+func deriveNodeID(ip net.IP) int {
+	h := fnv.New32a()
+	h.Write(ip.To16())
+	// Node IDs are non-negative 32 bit integers.
+	return int(h.Sum32() & math.MaxInt32)
+}
+
+func checkPortFlag(flag string, port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("invalid --%s %d, must be in the range [1, 65535]", flag, port)
+	}
+	return nil
+}
+
+// joinSeeds returns the current seeds followed by the members of the cluster
+// to join that are not among them yet, after checking that at least one of
+// the members accepts a connection within timeout. With skipChecks, no
+// member being reachable is only a warning.
+func joinSeeds(w io.Writer, current, members []config.SeedServer, dialFn dialFunc, timeout time.Duration, skipChecks bool) ([]config.SeedServer, error) {
+	if len(members) == 0 {
+		return nil, errors.New("--join requires --ips or --ips-file to be set to the existing cluster members")
+	}
+	if err := checkSeedsReachable(w, members, dialFn, timeout); err != nil {
+		if !skipChecks {
 			return nil, err
 		}
+		fmt.Fprintf(w, "Warning: %v, joining anyway\n", err)
+	}
+	return appendMissingSeeds(current, members), nil
+}
 
-		host, port := vnet.SplitHostPortDefault(hostport, defaultRPCPort)
-		seed := config.SeedServer{
-			Host: config.SocketAddress{
-				Address: host,
-				Port:    port,
-			},
+// checkSeedsReachable checks that at least one of the seeds accepts
+// connections on its RPC address, warning about the ones that do not.
+func checkSeedsReachable(w io.Writer, seeds []config.SeedServer, dialFn dialFunc, timeout time.Duration) error {
+	var reachable int
+	for _, s := range seeds {
+		addr := net.JoinHostPort(s.Host.Address, strconv.Itoa(s.Host.Port))
+		conn, err := dialFn("tcp", addr, timeout)
+		if err != nil {
+			fmt.Fprintf(w, "Warning: unable to reach cluster member %s: %v\n", addr, err)
+			continue
 		}
-		seeds = append(seeds, seed)
+		conn.Close()
+		reachable++
+	}
+	if reachable == 0 {
+		return errors.New("unable to reach any of the cluster members in --ips")
 	}
-	return seeds, nil
+	return nil
 }
 
-func getOwnIP() (net.IP, error) {
-	addrs, err := net.InterfaceAddrs()
+// readIPsFile returns the --ips-file entries, one per line, skipping the blank
+// lines and the comments, which start with a #.
+func readIPsFile(fs afero.Fs, path string) ([]string, error) {
+	b, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read --ips-file %q: %w", path, err)
+	}
+	var ips []string
+	for _, line := range strings.Split(string(b), "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			ips = append(ips, line)
+		}
+	}
+	return ips, nil
+}
+
+// mergeSeedSources returns the --ips seeds followed by the --ips-file ones,
+// without the file seeds that --ips already lists: listing a host in both is
+// not a duplicate to warn about, nor an error with --strict.
+func mergeSeedSources(inline, file []config.SeedServer) []config.SeedServer {
+	listed := make(map[config.SocketAddress]bool, len(inline))
+	for _, s := range inline {
+		listed[s.Host] = true
+	}
+	merged := append([]config.SeedServer{}, inline...)
+	for _, s := range file {
+		if !listed[s.Host] {
+			merged = append(merged, s)
+		}
+	}
+	return merged
+}
+
+// dedupeSeeds returns the seeds without the hosts that are listed more than
+// once, keeping their first occurrence. Duplicates are warned about, or are an
+// error if strict is set.
+func dedupeSeeds(w io.Writer, seeds []config.SeedServer, strict bool) ([]config.SeedServer, error) {
+	deduped := &config.Config{}
+	for _, s := range seeds {
+		_, err := config.AddSeedServer(deduped, s.Host)
+		switch {
+		case err == nil:
+		case strict:
+			return nil, fmt.Errorf("invalid --ips: %v", err)
+		default:
+			fmt.Fprintf(w, "Warning: ignoring %v in --ips\n", err)
+		}
+	}
+	return deduped.Redpanda.SeedServers, nil
+}
+
+// appendMissingSeeds returns the current seeds, followed by the seeds in add
+// that are not among them yet.
+func appendMissingSeeds(current, add []config.SeedServer) []config.SeedServer {
+	merged := &config.Config{}
+	merged.Redpanda.SeedServers = append([]config.SeedServer{}, current...)
+	for _, a := range add {
+		config.AddSeedServer(merged, a.Host) // only fails for the known seeds, which are skipped
+	}
+	return merged.Redpanda.SeedServers
+}
+
+func getOwnIP(iface, prefer, family string, cidr *net.IPNet, addrsFn interfaceAddrsFunc) (net.IP, error) {
+	addrs, err := addrsFn(iface)
 	if err != nil {
 		return nil, err
 	}
+	if cidr != nil {
+		var inNetwork []net.Addr
+		for _, a := range addrs {
+			if ipnet, ok := a.(*net.IPNet); ok && cidr.Contains(ipnet.IP) {
+				inNetwork = append(inNetwork, a)
+			}
+		}
+		addrs = inNetwork
+	}
+	if iface == "" && cidr == nil {
+		return pickOwnIP(addrs, prefer, family, true)
+	}
+	// The interface or network was explicitly chosen, the address doesn't
+	// need to be private.
+	ip, err := pickOwnIP(addrs, prefer, family, false)
+	switch {
+	case err == nil:
+		return ip, nil
+	case iface != "":
+		return nil, fmt.Errorf("interface %q has no usable address: %v", iface, err)
+	default:
+		return nil, fmt.Errorf("network %s: %v", cidr, err)
+	}
+}
 
+// pickOwnIP returns the only candidate IP of the preferred family in addrs,
+// failing if there is none or if there are many. Candidates are non-loopback
+// v4 IPs, which must also be private if privateOnly is set, and global
+// unicast v6 IPs; link-local v6 IPs are never picked and must be passed
+// explicitly with --self.
+//
+// If there are many candidates and family is set, e.g. on a dual-stack host,
+// the only candidate of that family is picked, failing if there are many.
+func pickOwnIP(addrs []net.Addr, prefer, family string, privateOnly bool) (net.IP, error) {
 	filtered := []net.IP{}
 	for _, a := range addrs {
 		ipnet, ok := a.(*net.IPNet)
@@ -244,14 +1619,43 @@ func getOwnIP() (net.IP, error) {
 			return nil, err
 		}
 
-		if isV4 && private && !ipnet.IP.IsLoopback() {
+		v4 := isV4 && (private || !privateOnly) && ipnet.IP.IsGlobalUnicast()
+		v6 := !isV4 && ipnet.IP.IsGlobalUnicast()
+		switch {
+		case v4 && prefer != preferIPv6,
+			v6 && prefer != preferIPv4:
 			filtered = append(filtered, ipnet.IP)
 		}
 	}
+	if len(filtered) > 1 && family != "" {
+		var inFamily []net.IP
+		for _, ip := range filtered {
+			if (ip.To4() != nil) == (family == preferIPv4) {
+				inFamily = append(inFamily, ip)
+			}
+		}
+		// With no candidate of the family, the others are still
+		// ambiguous.
+		if len(inFamily) > 0 {
+			filtered = inFamily
+			prefer = family
+		}
+	}
 	if len(filtered) > 1 {
-		return nil, errors.New(
-			"found multiple private non-loopback v4 IPs for the" +
+		desc := "private non-loopback v4"
+		if !privateOnly {
+			desc = "non-loopback v4"
+		}
+		switch prefer {
+		case preferIPv6:
+			desc = "non-loopback v6"
+		case preferAny:
+			desc = "non-loopback"
+		}
+		return nil, fmt.Errorf(
+			"found multiple %s IPs for the"+
 				" current node. Please set one with --self",
+			desc,
 		)
 	}
 	if len(filtered) == 0 {
@@ -283,16 +1687,102 @@ func isPrivate(ip net.IP) (bool, error) {
 
 const helpBootstrap = `Initialize the configuration to bootstrap a cluster.
 
---id is mandatory. bootstrap will expect the machine it's running on
-to have only one private non-loopback IP address associated to it,
-and use it in the configuration as the node's address.
+--id is mandatory, unless --auto-id is set: in that case the node ID is derived
+from a hash of this node's IP, so that it is stable across runs. The chosen ID
+is printed.
 
-If it has multiple IPs, --self must be specified.
-In that case, the given IP will be used without checking whether it's
-among the machine's addresses or not.
+bootstrap will expect the machine it's running on to have only one private
+non-loopback IP address associated to it, and use it in the configuration as
+the node's address.
 
-The elements in --ips must be separated by a comma, no spaces.
+Only IPv4 addresses are considered by default, use --prefer ipv6 to pick a
+global IPv6 address instead, or --prefer any to pick from both families.
+Link-local IPv6 addresses are never picked automatically. On dual-stack hosts,
+--interface-family ipv4 (or ipv6) picks the only address of that family among
+the addresses of both, and falls back to the only address of the other family
+if there is none; it cannot be used with --prefer.
+
+If it has multiple IPs, either --self or --interface must be specified.
+--interface uses the current address of the given network interface, which
+is stable across address reassignments. Alternatively, --cidr narrows the
+machine's addresses down to the ones within the given network, e.g. the data
+network of a multi-homed host.
+
+--self must be one of the machine's non-loopback addresses, unless
+--force-self is set, e.g. for NAT or overlay network setups where the node is
+reachable through an address it doesn't own.
+
+The elements in --ips must be separated by a comma, no spaces. Each element is
+an IP or hostname, optionally followed by a port, with IPv6 addresses in
+brackets if they have a port, e.g. 10.0.0.1,10.0.0.2:33146,[fd00::3]:33146.
+Elements with no port use the --rpc-port, which defaults to 33145. Elements
+that are listed more than once are only used once, with a warning, or are an
+error if --strict is set.
 
 If omitted, the node will be configured as a root node, that other
 ones can join later.
+
+For large clusters, the elements can also be listed in a file passed with
+--ips-file, one per line, in the same format as in --ips. Blank lines and
+comments, starting with a #, are ignored:
+
+  # rack 1
+  10.0.0.1
+  10.0.0.2:33146
+
+The elements of the file follow the ones of --ips, if both are set, and the
+elements listed in both are only used once, without a warning.
+
+Both --self and --ips accept hostnames, which are written as is to the
+configuration and resolved by redpanda at runtime, e.g. to use DNS names that
+resolve differently per environment. Use --resolve to resolve them
+beforehand and write their addresses instead. A --self hostname is not
+checked against the machine's addresses, and --auto-id requires it to be
+resolved.
+
+--rpc-port, --kafka-port and --admin-port set this node's listener ports.
+If --rpc-port is not set, the current RPC port is kept.
+
+By default, the seed servers are replaced with --ips. The --ips members that
+already are seed servers keep their ID, which is their index in the list, and
+the others take the free IDs, so that bootstrapping again with the same
+members in another order does not renumber them. To add a node to an
+already running cluster, use --join: the current seed servers are kept and the
+--ips members are added to them. --join requires --ips, and checks that at
+least one of them accepts connections on its RPC port.
+
+Each network check, resolving a hostname with --resolve or dialing a member
+with --join, fails if it takes longer than --timeout (5s by default), so that
+bootstrap does not hang on a partitioned network. A failed check names the
+host, and bootstrap exits with an error, unless --skip-checks is set: the
+failure is then only warned about, unresolved hostnames are written as is and
+the members are joined even if none of them is reachable.
+
+If the resulting configuration is the same as the current configuration file,
+the file is not written, so that bootstrap can safely be re-run.
+
+With --annotate, a comment recording when, by which user and with which --ips
+bootstrap ran is written above the seed servers of YAML config files, e.g. to
+trace the seed servers of configurations kept in git. The comment does not
+change the configuration, and is only updated when the file is written.
+
+With --out, the resulting configuration is written to the given path rather
+than to the file it was read from, which is left untouched, e.g. to stage it
+for review, or to render node configurations from a base one:
+
+  rpk redpanda config bootstrap --config base.yaml --out node.yaml --id 1 ...
+
+With --dry-run, the resulting configuration is printed to stdout rather than
+written, in the --format format (yaml/json/toml/hcl), so that it can be reviewed
+first. The configuration file is left untouched and not locked.
+
+With --output json, a summary of the node configuration is printed once done,
+for scripts to consume:
+
+  {
+    "id": 1,
+    "self_ip": "10.0.0.1",
+    "seed_servers": [{"host": {"address": "10.0.0.1", "port": 33145}}],
+    "config_path": "/etc/redpanda/redpanda.yaml"
+  }
 `
@@ -0,0 +1,86 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/cobra"
+)
+
+func describe() *cobra.Command {
+	return &cobra.Command{
+		Use:   "describe <key>",
+		Short: "Describe a configuration key: its type, default and meaning",
+		Long: `Describe a configuration key: its type, default and meaning.
+
+This complements list-keys, printing the type of the key, its default value as
+json, and what it means and accepts, e.g.:
+
+  $ rpk redpanda config describe redpanda.rpc_server.port
+  KEY      redpanda.rpc_server.port
+  TYPE     int
+  DEFAULT  33145
+  DOC      TCP port, 1-65535
+
+The key can index list elements, e.g. redpanda.seed_servers.1.host.address.
+If the key is not a key itself, every key starting with it is described, e.g.
+redpanda.rpc_server. describes the address and the port of the RPC server.
+
+Keys without a default value, such as the fields of list elements, have a
+DEFAULT of none.
+`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			keys := describedKeys(args[0])
+			if len(keys) == 0 {
+				out.DieCode(exitInvalidInput, "%q is not a configuration key that rpk manages, nor a prefix of one", args[0])
+			}
+			def := config.Default()
+			for i, k := range keys {
+				if i > 0 {
+					fmt.Fprintln(cmd.OutOrStdout())
+				}
+				printKeyDescription(cmd.OutOrStdout(), def, k)
+			}
+		},
+	}
+}
+
+// describedKeys returns the key at path, or the keys starting with it.
+func describedKeys(path string) []config.Key {
+	if k, ok := config.LookupKey(path); ok {
+		return []config.Key{k}
+	}
+	return config.Keys(path)
+}
+
+func printKeyDescription(w io.Writer, def *config.Config, k config.Key) {
+	dv, err := def.Get(k.Path, config.FormatJSON)
+	if err != nil {
+		dv = "none"
+	}
+	doc := k.Doc
+	if doc == "" {
+		doc = "-"
+	}
+	tw := out.NewTabWriterTo(w)
+	defer tw.Flush()
+	tw.Print("KEY", k.Path)
+	tw.Print("TYPE", k.Type)
+	tw.Print("DEFAULT", dv)
+	tw.Print("DOC", doc)
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribe(t *testing.T) {
+	run := func(key string) string {
+		var b bytes.Buffer
+		c := describe()
+		c.SetOut(&b)
+		c.SetArgs([]string{key})
+		require.NoError(t, c.Execute())
+		return b.String()
+	}
+
+	require.Regexp(t, `^KEY\s+redpanda.rpc_server.port
+TYPE\s+int
+DEFAULT\s+33145
+DOC\s+TCP port, 1-65535
+$`, run("redpanda.rpc_server.port"))
+
+	// Indexes are accepted, and keys without a default have none.
+	out := run("redpanda.seed_servers.1.host.address")
+	require.Regexp(t, `(?m)^KEY\s+redpanda.seed_servers.host.address$`, out)
+	require.Regexp(t, `(?m)^DEFAULT\s+none$`, out)
+	require.Regexp(t, `(?m)^DOC\s+Host name or IP address$`, out)
+
+	out = run("redpanda.developer_mode")
+	require.Regexp(t, `(?m)^DEFAULT\s+true$`, out)
+	require.Regexp(t, `(?m)^DOC\s+Skip the production checks and tuning, for development only$`, out)
+
+	// A prefix describes every key under it.
+	out = run("redpanda.rpc_server.")
+	require.Regexp(t, `(?m)^KEY\s+redpanda.rpc_server.address$`, out)
+	require.Regexp(t, `(?m)^DEFAULT\s+"0.0.0.0"$`, out)
+	require.Regexp(t, `(?m)^KEY\s+redpanda.rpc_server.port$`, out)
+}
@@ -0,0 +1,121 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func diff(fs afero.Fs) *cobra.Command {
+	var (
+		against    string
+		configPath string
+	)
+	c := &cobra.Command{
+		Use:   "diff",
+		Short: "Show how the configuration differs from the defaults or another file",
+		Long: `Show how the configuration differs from the defaults or another file.
+
+By default, the configuration is compared against the default configuration.
+Use --against to compare it against another configuration file instead, e.g.
+a golden baseline.
+
+Only differing keys are printed, one per line, using the dotted notation of
+'rpk redpanda config set'. Lines starting with '-' are values from the
+baseline, lines starting with '+' are values from the configuration:
+
+  - redpanda.node_id: 0
+  + redpanda.node_id: 3
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			p := config.ParamsFromCommand(cmd)
+			cfg, err := p.Load(fs)
+			out.MaybeDie(err, "unable to load config: %v", err)
+			logLoaded(cmd, cfg)
+
+			base := config.Default()
+			if against != "" {
+				exists, err := afero.Exists(fs, against)
+				out.MaybeDie(err, "unable to stat %q: %v", against, err)
+				if !exists {
+					out.Die("unable to find %q", against)
+				}
+				base, err = (&config.Params{ConfigPath: against}).Load(fs)
+				out.MaybeDie(err, "unable to load %q: %v", against, err)
+			}
+
+			diffs, err := config.Diff(base, cfg)
+			out.MaybeDieErr(err)
+			res := diffResult{Diffs: make([]setChange, 0, len(diffs))}
+			for _, d := range diffs {
+				res.Diffs = append(res.Diffs, setChange(d))
+			}
+			err = pr.Result(res, func(w io.Writer) {
+				if len(diffs) == 0 {
+					fmt.Fprintln(w, "No differences found.")
+					return
+				}
+				printConfigDiffs(w, diffs)
+			})
+			out.MaybeDieErr(err)
+		},
+	}
+	c.Flags().StringVar(&against, "against", "", "Config file to compare against, instead of the defaults")
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileFlagDesc,
+	)
+	return c
+}
+
+// printConfigDiffs prints the diffs, the old value of each key prefixed with
+// '-' and the new one with '+'.
+// diffResult is what diff --output json prints.
+type diffResult struct {
+	Diffs []setChange `json:"diffs"`
+}
+
+func printConfigDiffs(w io.Writer, diffs []config.FieldDiff) {
+	for _, d := range diffs {
+		if d.Old != nil {
+			fmt.Fprintf(w, "- %s: %s\n", d.Key, diffValue(d.Old))
+		}
+		if d.New != nil {
+			fmt.Fprintf(w, "+ %s: %s\n", d.Key, diffValue(d.New))
+		}
+	}
+}
+
+// diffValue returns the value v of a config.FieldDiff as it is written in
+// the config file.
+func diffValue(v interface{}) string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
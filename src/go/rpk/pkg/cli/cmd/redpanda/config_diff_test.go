@@ -0,0 +1,69 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  node_id: 3
+  data_directory: /data
+  rack: r1
+  seed_servers:
+    - host:
+        address: 10.0.0.1
+        port: 33145
+`), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/golden.yaml", []byte(`redpanda:
+  node_id: 1
+  data_directory: /data
+  seed_servers: []
+  developer_mode: true
+`), 0o644))
+
+	var b bytes.Buffer
+	c := diff(fs)
+	c.SetOut(&b)
+	c.SetArgs([]string{"--against", "/golden.yaml"})
+	require.NoError(t, c.Execute())
+
+	got := b.String()
+	for _, exp := range []string{
+		// changed
+		"- redpanda.node_id: 1\n+ redpanda.node_id: 3\n",
+		"- redpanda.developer_mode: true\n+ redpanda.developer_mode: false\n",
+		// added
+		"+ redpanda.rack: r1\n",
+		"+ redpanda.seed_servers.0.host.address: 10.0.0.1\n",
+		// removed
+		"- redpanda.seed_servers: []\n",
+	} {
+		require.Contains(t, got, exp)
+	}
+	require.NotContains(t, got, "data_directory")
+	require.NotContains(t, got, "config_file")
+
+	b.Reset()
+	c = diff(fs)
+	c.SetOut(&b)
+	c.SetArgs([]string{"--against", "/etc/redpanda/redpanda.yaml"})
+	require.NoError(t, c.Execute())
+	require.Equal(t, "No differences found.\n", b.String())
+}
@@ -0,0 +1,239 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// listenFunc listens on the address on the named network, as net.Listen.
+type listenFunc func(network, address string) (net.Listener, error)
+
+// doctorStatus is the outcome of a doctor check.
+type doctorStatus int
+
+const (
+	doctorPass doctorStatus = iota
+	doctorWarn
+	doctorFail
+)
+
+func (s doctorStatus) String() string {
+	switch s {
+	case doctorPass:
+		return "PASS"
+	case doctorWarn:
+		return "WARN"
+	default:
+		return "FAIL"
+	}
+}
+
+// doctorResult is the result of a single doctor check.
+type doctorResult struct {
+	check  string
+	status doctorStatus
+	detail string
+}
+
+// doctorReport is what doctor --output json prints.
+type doctorReport struct {
+	Checks   []doctorCheck `json:"checks"`
+	Passed   int           `json:"passed"`
+	Warnings int           `json:"warnings"`
+	Failed   int           `json:"failed"`
+}
+
+// doctorCheck is a doctorResult in a doctorReport.
+type doctorCheck struct {
+	Check  string `json:"check"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+func doctor(fs afero.Fs) *cobra.Command {
+	return newDoctorCommand(fs, net.Listen, net.DefaultResolver.LookupHost)
+}
+
+func newDoctorCommand(fs afero.Fs, listenFn listenFunc, lookupFn lookupHostFunc) *cobra.Command {
+	var (
+		configPath    string
+		createDataDir bool
+		timeout       time.Duration
+	)
+	c := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that the environment is ready for the configuration",
+		Long: `Check that the environment is ready for the configuration
+
+Unlike validate, which checks the configuration on its own, this checks it
+against the machine redpanda is about to start on:
+
+  * the data directory exists and is writable,
+  * the RPC, Kafka API and Admin API ports are free,
+  * the seed server hostnames resolve, within --timeout each.
+
+Each check is reported as PASS, WARN or FAIL, followed by a summary, or as
+json with --output json. The command fails if any check fails. As the ports are bound once redpanda runs,
+doctor is meant to be run before starting it.
+
+With --create-data-dir, a missing data directory is created rather than
+failing its check, as 'rpk redpanda config ensure-dirs --create-data-dir'
+does.
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			if timeout <= 0 {
+				out.DieCode(exitInvalidInput, "invalid --timeout %v, must be positive", timeout)
+			}
+			cfg, err := loadConfig(fs, cmd)
+			out.MaybeDie(err, "unable to load config: %v", err)
+
+			results := []doctorResult{checkDataDir(fs, cfg, createDataDir)}
+			results = append(results, checkPortsFree(cfg, listenFn)...)
+			results = append(results, checkSeedsResolve(cfg.Redpanda.SeedServers, lookupFn, timeout)...)
+
+			report := doctorReport{Checks: make([]doctorCheck, 0, len(results))}
+			var counts [doctorFail + 1]int
+			for _, r := range results {
+				counts[r.status]++
+				report.Checks = append(report.Checks, doctorCheck{r.check, r.status.String(), r.detail})
+			}
+			report.Passed, report.Warnings, report.Failed = counts[doctorPass], counts[doctorWarn], counts[doctorFail]
+			err = pr.Result(report, func(w io.Writer) {
+				tw := out.NewTableTo(w, "check", "status", "detail")
+				for _, r := range results {
+					tw.Print(r.check, r.status, r.detail)
+				}
+				tw.Flush()
+				fmt.Fprintf(w, "\n%d passed, %d warning(s), %d failed\n", report.Passed, report.Warnings, report.Failed)
+			})
+			out.MaybeDieErr(err)
+			if report.Failed > 0 {
+				out.Die("found %d failing check(s)", report.Failed)
+			}
+		},
+	}
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileFlagDesc,
+	)
+	addCreateDataDirFlag(c, &createDataDir)
+	c.Flags().DurationVar(&timeout, "timeout", 5*time.Second, "How long resolving each seed server hostname may take")
+	return c
+}
+
+// checkDataDir checks that the data directory exists, or creates it if
+// create is set, and that files can be created in it.
+func checkDataDir(fs afero.Fs, cfg *config.Config, create bool) doctorResult {
+	dir := cfg.Redpanda.Directory
+	r := doctorResult{check: "data directory " + dir, detail: "exists and is writable"}
+	check := config.CheckDataDir
+	if create {
+		if _, err := fs.Stat(dir); errors.Is(err, os.ErrNotExist) {
+			r.detail = "created"
+		}
+		check = config.EnsureDataDir
+	}
+	if err := check(fs, cfg); err != nil {
+		r.status, r.detail = doctorFail, err.Error()
+	}
+	return r
+}
+
+// checkPortsFree checks that the addresses redpanda listens on can be bound,
+// skipping the unset ones.
+func checkPortsFree(cfg *config.Config, listenFn listenFunc) []doctorResult {
+	rp := cfg.Redpanda
+	type listener struct {
+		name string
+		addr config.SocketAddress
+	}
+	listeners := []listener{{"rpc_server", rp.RPCServer}}
+	for i, a := range rp.KafkaAPI {
+		listeners = append(listeners, listener{fmt.Sprintf("kafka_api.%d", i), config.SocketAddress{Address: a.Address, Port: a.Port}})
+	}
+	for i, a := range rp.AdminAPI {
+		listeners = append(listeners, listener{fmt.Sprintf("admin.%d", i), config.SocketAddress{Address: a.Address, Port: a.Port}})
+	}
+
+	var results []doctorResult
+	for _, l := range listeners {
+		if l.addr.Port == 0 {
+			continue
+		}
+		addr := net.JoinHostPort(l.addr.Address, strconv.Itoa(l.addr.Port))
+		r := doctorResult{check: fmt.Sprintf("port %s (%s)", addr, l.name)}
+		ln, err := listenFn("tcp", addr)
+		if err != nil {
+			r.status, r.detail = doctorFail, fmt.Sprintf("unable to bind, is redpanda already running? %v", err)
+		} else {
+			ln.Close()
+			r.detail = "free"
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// checkSeedsResolve checks that the seed server hostnames resolve, each
+// within timeout. An empty list of seed servers is a warning, as the node then
+// starts a new cluster.
+func checkSeedsResolve(seeds []config.SeedServer, lookupFn lookupHostFunc, timeout time.Duration) []doctorResult {
+	if len(seeds) == 0 {
+		return []doctorResult{{
+			check:  "seed servers",
+			status: doctorWarn,
+			detail: "none configured, the node will start a new cluster",
+		}}
+	}
+	var results []doctorResult
+	for _, s := range seeds {
+		host := s.Host.Address
+		r := doctorResult{check: "seed server " + host}
+		if net.ParseIP(host) != nil {
+			r.detail = "is an IP address"
+		} else {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			addrs, err := lookupFn(ctx, host)
+			switch {
+			case ctx.Err() != nil:
+				r.status, r.detail = doctorFail, fmt.Sprintf("unable to resolve: timed out after %v", timeout)
+			case err != nil:
+				r.status, r.detail = doctorFail, fmt.Sprintf("unable to resolve: %v", err)
+			default:
+				r.detail = "resolves to " + strings.Join(addrs, ", ")
+			}
+			cancel()
+		}
+		results = append(results, r)
+	}
+	return results
+}
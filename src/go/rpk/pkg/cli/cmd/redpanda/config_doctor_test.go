@@ -0,0 +1,169 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeListener is a net.Listener that accepts no connection.
+type fakeListener struct{}
+
+func (fakeListener) Accept() (net.Conn, error) { return nil, errors.New("closed") }
+
+func (fakeListener) Close() error { return nil }
+
+func (fakeListener) Addr() net.Addr { return &net.TCPAddr{} }
+
+// testListen returns a listenFunc that fails on the bound addresses.
+func testListen(bound map[string]bool) listenFunc {
+	return func(_, addr string) (net.Listener, error) {
+		if bound[addr] {
+			return nil, errors.New("address already in use")
+		}
+		return fakeListener{}, nil
+	}
+}
+
+// testLookup returns a lookupHostFunc that only resolves the given hosts.
+func testLookup(hosts map[string][]string) lookupHostFunc {
+	return func(_ context.Context, host string) ([]string, error) {
+		if addrs, ok := hosts[host]; ok {
+			return addrs, nil
+		}
+		return nil, fmt.Errorf("lookup %s: no such host", host)
+	}
+}
+
+func TestCheckDataDir(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, fs.MkdirAll("/var/lib/redpanda/data", 0o755))
+	require.NoError(t, afero.WriteFile(fs, "/var/lib/redpanda/file", nil, 0o644))
+	withDir := func(dir string) *config.Config {
+		cfg := config.Default()
+		cfg.Redpanda.Directory = dir
+		return cfg
+	}
+
+	r := checkDataDir(fs, withDir("/var/lib/redpanda/data"), false)
+	require.Equal(t, doctorPass, r.status, r.detail)
+	files, err := afero.ReadDir(fs, "/var/lib/redpanda/data")
+	require.NoError(t, err)
+	require.Empty(t, files, "the write probe should be removed")
+
+	require.Equal(t, doctorFail, checkDataDir(fs, withDir("/var/lib/redpanda/missing"), false).status)
+	require.Equal(t, doctorFail, checkDataDir(fs, withDir("/var/lib/redpanda/file"), false).status)
+	require.Equal(t, doctorFail, checkDataDir(afero.NewReadOnlyFs(fs), withDir("/var/lib/redpanda/data"), false).status)
+
+	r = checkDataDir(fs, withDir("/var/lib/redpanda/missing"), true)
+	require.Equal(t, doctorResult{"data directory /var/lib/redpanda/missing", doctorPass, "created"}, r)
+	r = checkDataDir(fs, withDir("/var/lib/redpanda/missing"), true)
+	require.Equal(t, doctorResult{"data directory /var/lib/redpanda/missing", doctorPass, "exists and is writable"}, r)
+}
+
+func TestCheckPortsFree(t *testing.T) {
+	cfg := config.Default()
+	results := checkPortsFree(cfg, testListen(map[string]bool{"0.0.0.0:9092": true}))
+	require.Len(t, results, 3)
+	require.Equal(t, doctorPass, results[0].status)
+	require.Equal(t, "port 0.0.0.0:33145 (rpc_server)", results[0].check)
+	require.Equal(t, doctorFail, results[1].status)
+	require.Equal(t, "port 0.0.0.0:9092 (kafka_api.0)", results[1].check)
+	require.Equal(t, doctorPass, results[2].status)
+}
+
+func TestCheckSeedsResolve(t *testing.T) {
+	results := checkSeedsResolve(nil, testLookup(nil), time.Second)
+	require.Len(t, results, 1)
+	require.Equal(t, doctorWarn, results[0].status)
+
+	results = checkSeedsResolve([]config.SeedServer{
+		{Host: config.SocketAddress{Address: "10.0.0.1", Port: 33145}},
+		{Host: config.SocketAddress{Address: "rp-1.local", Port: 33145}},
+		{Host: config.SocketAddress{Address: "rp-2.local", Port: 33145}},
+	}, testLookup(map[string][]string{"rp-1.local": {"10.0.0.2"}}), time.Second)
+	require.Equal(t, []doctorResult{
+		{"seed server 10.0.0.1", doctorPass, "is an IP address"},
+		{"seed server rp-1.local", doctorPass, "resolves to 10.0.0.2"},
+		{"seed server rp-2.local", doctorFail, "unable to resolve: lookup rp-2.local: no such host"},
+	}, results)
+
+	// A lookup that hangs until it is canceled.
+	hang := func(ctx context.Context, _ string) ([]string, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	results = checkSeedsResolve([]config.SeedServer{
+		{Host: config.SocketAddress{Address: "rp-1.local", Port: 33145}},
+	}, hang, 10*time.Millisecond)
+	require.Equal(t, []doctorResult{
+		{"seed server rp-1.local", doctorFail, "unable to resolve: timed out after 10ms"},
+	}, results)
+}
+
+func TestDoctor(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, fs.MkdirAll("/var/lib/redpanda/data", 0o755))
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  data_directory: /var/lib/redpanda/data
+  rpc_server:
+    address: 0.0.0.0
+    port: 33145
+  kafka_api:
+    - address: 0.0.0.0
+      port: 9092
+  seed_servers:
+    - host:
+        address: rp-0.local
+        port: 33145
+`), 0o644))
+
+	var b bytes.Buffer
+	c := newDoctorCommand(fs, testListen(nil), testLookup(map[string][]string{"rp-0.local": {"10.0.0.1"}}))
+	c.SetOut(&b)
+	c.SetArgs(nil)
+	require.NoError(t, c.Execute())
+	require.Contains(t, b.String(), "seed server rp-0.local")
+	require.NotContains(t, b.String(), "FAIL")
+	require.True(t, strings.HasSuffix(b.String(), "\n4 passed, 0 warning(s), 0 failed\n"), b.String())
+
+	// As run by the config command, which has the --output flag.
+	b.Reset()
+	c = newDoctorCommand(fs, testListen(nil), testLookup(map[string][]string{"rp-0.local": {"10.0.0.1"}}))
+	c.Flags().String(outputFlag, outputText, "")
+	c.SetOut(&b)
+	c.SetArgs([]string{"--output", "json"})
+	require.NoError(t, c.Execute())
+	require.JSONEq(t, `{
+  "checks": [
+    {"check": "data directory /var/lib/redpanda/data", "status": "PASS", "detail": "exists and is writable"},
+    {"check": "port 0.0.0.0:33145 (rpc_server)", "status": "PASS", "detail": "free"},
+    {"check": "port 0.0.0.0:9092 (kafka_api.0)", "status": "PASS", "detail": "free"},
+    {"check": "seed server rp-0.local", "status": "PASS", "detail": "resolves to 10.0.0.1"}
+  ],
+  "passed": 4,
+  "warnings": 0,
+  "failed": 0
+}`, b.String())
+}
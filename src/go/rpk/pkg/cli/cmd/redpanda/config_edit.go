@@ -0,0 +1,183 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// editFunc opens the file at the given path for the user to edit, returning
+// once the user is done.
+type editFunc func(path string) error
+
+func edit(fs afero.Fs) *cobra.Command {
+	return newEditCommand(fs, runEditor)
+}
+
+func newEditCommand(fs afero.Fs, editFn editFunc) *cobra.Command {
+	var (
+		configPath  string
+		lockTimeout time.Duration
+	)
+	c := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the configuration file in your $EDITOR",
+		Long: `Edit the configuration file in your $EDITOR.
+
+This command opens the current configuration, or the default one if there is
+no configuration file yet, in $EDITOR (vi if unset). Once the editor exits, the
+edited configuration is validated and written back.
+
+If the edited configuration is invalid, the editor is opened again with the
+problems listed at the top of the file. To abort, exit the editor without
+making any change, or empty the file.
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			// The lock is held while the editor is open, for the edited
+			// configuration not to overwrite a concurrent change.
+			unlock, err := lockConfig(fs, cmd, lockTimeout)
+			out.MaybeDieErr(err)
+			defer unlock()
+
+			p := config.ParamsFromCommand(cmd)
+			cfg, err := p.Load(fs)
+			out.MaybeDie(err, "unable to load config: %v", err)
+			logLoaded(cmd, cfg)
+
+			edited, changed, err := editConfig(fs, cfg, editFn)
+			out.MaybeDieErr(err)
+			if !changed {
+				pr.Messagef("No changes made.")
+				return
+			}
+
+			logWrite(cmd, edited)
+			err = edited.Write(fs)
+			out.MaybeDie(err, "error writing config file: %v", err)
+		},
+	}
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileFlagDesc,
+	)
+	addLockTimeoutFlag(c, &lockTimeout)
+	return c
+}
+
+// editConfig lets the user edit cfg until the result is valid, returning the
+// edited configuration and whether anything changed.
+func editConfig(fs afero.Fs, cfg *config.Config, editFn editFunc) (*config.Config, bool, error) {
+	orig, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to marshal config: %v", err)
+	}
+
+	file, err := afero.TempFile(fs, "", "redpanda-*.yaml")
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to create temporary file: %v", err)
+	}
+	filename := file.Name()
+	file.Close()
+	defer func() {
+		if err := fs.Remove(filename); err != nil {
+			fmt.Fprintf(os.Stderr, "unable to remove temporary file %q\n", filename)
+		}
+	}()
+
+	var (
+		contents = orig
+		header   []byte
+	)
+	for {
+		if err := afero.WriteFile(fs, filename, append(header, contents...), 0o600); err != nil {
+			return nil, false, fmt.Errorf("unable to write temporary file %q: %v", filename, err)
+		}
+		if err := editFn(filename); err != nil {
+			return nil, false, fmt.Errorf("error running editor: %v", err)
+		}
+		b, err := afero.ReadFile(fs, filename)
+		if err != nil {
+			return nil, false, fmt.Errorf("unable to read temporary file %q: %v", filename, err)
+		}
+		b = bytes.TrimPrefix(b, header)
+
+		switch {
+		case len(bytes.TrimSpace(b)) == 0:
+			return nil, false, fmt.Errorf("edit aborted: the edited configuration is empty")
+		case bytes.Equal(b, orig):
+			return cfg, false, nil
+		case header != nil && bytes.Equal(b, contents):
+			return nil, false, fmt.Errorf("edit aborted: the configuration still has errors")
+		}
+
+		// The edited configuration is decoded on its own, for the keys
+		// deleted in the editor not to survive, and written to the file
+		// cfg was loaded from.
+		var errs []error
+		edited, err := config.ReadFromBytes(b, config.FormatYAML)
+		if err != nil {
+			errs = []error{fmt.Errorf("unable to parse configuration: %v", err)}
+		} else {
+			edited = cfg.Replace(edited)
+			errs = config.Validate(edited)
+		}
+		if len(errs) == 0 {
+			return edited, true, nil
+		}
+
+		contents = b
+		header = editErrorsHeader(errs)
+	}
+}
+
+// editErrorsHeader returns the comment we prepend to a configuration that
+// failed validation when reopening it in the editor.
+func editErrorsHeader(errs []error) []byte {
+	var sb strings.Builder
+	sb.WriteString("# The edited configuration was not saved because of the following problems:\n")
+	for _, err := range errs {
+		fmt.Fprintf(&sb, "#  - %s\n", strings.ReplaceAll(err.Error(), "\n", "\n#    "))
+	}
+	sb.WriteString("#\n")
+	return []byte(sb.String())
+}
+
+func runEditor(path string) error {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	// $EDITOR may contain arguments, e.g. "code --wait".
+	fields := strings.Fields(editor)
+	child := exec.Command(fields[0], append(fields[1:], path)...)
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	child.Stdin = os.Stdin
+	return child.Run()
+}
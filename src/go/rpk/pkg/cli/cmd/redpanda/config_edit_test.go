@@ -0,0 +1,166 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEdit(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		// edits are applied in order, one per editor invocation, by
+		// replacing the first string with the second.
+		edits     [][2]string
+		expHeader string
+		expErr    bool
+		expID     int
+		expWrite  bool
+	}{
+		{
+			name:     "valid edit",
+			edits:    [][2]string{{"node_id: 0", "node_id: 5"}},
+			expID:    5,
+			expWrite: true,
+		},
+		{
+			name:  "no changes",
+			edits: [][2]string{{"", ""}},
+		},
+		{
+			name: "invalid edit is reopened with the errors",
+			edits: [][2]string{
+				{"node_id: 0", "node_id: -2"},
+				{"node_id: -2", "node_id: 2"},
+			},
+			expHeader: "#  - redpanda.node_id can't be a negative integer",
+			expID:     2,
+			expWrite:  true,
+		},
+		{
+			name: "invalid edit left unchanged aborts",
+			edits: [][2]string{
+				{"node_id: 0", "node_id: -2"},
+				{"", ""},
+			},
+			expErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			var (
+				calls  int
+				header string
+			)
+			editFn := func(path string) error {
+				b, err := afero.ReadFile(fs, path)
+				require.NoError(t, err)
+				if calls > 0 {
+					header = string(b)
+				}
+				edit := test.edits[calls]
+				calls++
+				return afero.WriteFile(fs, path, []byte(strings.Replace(string(b), edit[0], edit[1], 1)), 0o600)
+			}
+
+			cfg, err := new(config.Params).Load(fs)
+			require.NoError(t, err)
+			edited, changed, err := editConfig(fs, cfg, editFn)
+			require.Equal(t, len(test.edits), calls)
+			if test.expHeader != "" {
+				require.Contains(t, header, test.expHeader)
+			}
+			if test.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expWrite, changed)
+			require.Equal(t, test.expID, edited.Redpanda.ID)
+
+			tmp, err := afero.ReadDir(fs, os.TempDir())
+			require.NoError(t, err)
+			require.Empty(t, tmp, "temporary file was not removed")
+		})
+	}
+}
+
+func TestEditDeletedKeys(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, path, []byte(`redpanda:
+  data_directory: /var/lib/redpanda/data
+  rpc_server:
+    address: 0.0.0.0
+    port: 33145
+  kafka_api:
+    - address: 0.0.0.0
+      port: 9092
+  rack: r1
+  auto_create_topics_enabled: true
+rpk:
+  not_a_key: 1
+`), 0o644))
+
+	// The edits delete the lines of both redpanda keys, and the unknown rpk
+	// key, which is not even in the edited configuration, should not fail
+	// the validation of the edit.
+	del := regexp.MustCompile(`(?m)^.*(rack|auto_create_topics_enabled):.*\n`)
+	editFn := func(path string) error {
+		b, err := afero.ReadFile(fs, path)
+		require.NoError(t, err)
+		return afero.WriteFile(fs, path, del.ReplaceAll(b, nil), 0o600)
+	}
+
+	var b bytes.Buffer
+	c := newEditCommand(fs, editFn)
+	c.SetOut(&b)
+	require.NoError(t, c.Execute())
+
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Empty(t, conf.Redpanda.Rack)
+	require.NotContains(t, conf.Redpanda.Other, "auto_create_topics_enabled")
+	require.Equal(t, path, conf.ConfigFile)
+}
+
+func TestEditLocks(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	editFn := func(path string) error {
+		// Another rpk process waits while the editor is open.
+		_, err := new(config.Params).LockConfig(fs, 10*time.Millisecond)
+		require.ErrorIs(t, err, config.ErrLockTimeout)
+
+		b, err := afero.ReadFile(fs, path)
+		require.NoError(t, err)
+		return afero.WriteFile(fs, path, bytes.Replace(b, []byte("node_id: 0"), []byte("node_id: 5"), 1), 0o600)
+	}
+
+	c := newEditCommand(fs, editFn)
+	c.SetOut(io.Discard)
+	require.NoError(t, c.Execute())
+
+	unlock, err := new(config.Params).LockConfig(fs, 10*time.Millisecond)
+	require.NoError(t, err, "the lock should be released once written")
+	unlock()
+}
@@ -0,0 +1,94 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func ensureDirs(fs afero.Fs) *cobra.Command {
+	var (
+		configPath    string
+		createDataDir bool
+	)
+	c := &cobra.Command{
+		Use:   "ensure-dirs",
+		Short: "Check that the data directory exists and is writable",
+		Long: `Check that the data directory exists and is writable.
+
+This checks that redpanda.data_directory is a directory that files can be
+created in, as redpanda needs it to start. With --create-data-dir, it is
+created along with its missing parents, with mode 0755, if it does not exist:
+
+  rpk redpanda config ensure-dirs --create-data-dir
+
+Without it, a missing directory fails the command, rather than a typo in the
+configuration creating a stray directory. The command is meant to be run as
+the user redpanda runs as, for the write check to be meaningful.
+
+With --output json, the directory is printed along with whether it was
+created:
+
+  {"data_directory":"/var/lib/redpanda/data","created":true}
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			cfg, err := loadConfig(fs, cmd)
+			maybeDieLoad(err, "unable to load config: %v", err)
+
+			dir := cfg.Redpanda.Directory
+			check, created := config.CheckDataDir, false
+			if createDataDir {
+				_, err := fs.Stat(dir)
+				created = errors.Is(err, os.ErrNotExist)
+				check = config.EnsureDataDir
+			}
+			err = check(fs, cfg)
+			maybeDieCode(err, exitIO, "%v", err)
+
+			err = pr.Result(struct {
+				Dir     string `json:"data_directory"`
+				Created bool   `json:"created"`
+			}{dir, created}, func(w io.Writer) {
+				if created {
+					fmt.Fprintf(w, "Created the data directory %s\n", dir)
+					return
+				}
+				fmt.Fprintf(w, "The data directory %s exists and is writable\n", dir)
+			})
+			out.MaybeDieErr(err)
+		},
+	}
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileFlagDesc,
+	)
+	addCreateDataDirFlag(c, &createDataDir)
+	return c
+}
+
+func addCreateDataDirFlag(c *cobra.Command, createDataDir *bool) {
+	c.Flags().BoolVar(createDataDir, "create-data-dir", false, "Create the data directory if it does not exist, instead of failing")
+}
@@ -0,0 +1,43 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureDirs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  data_directory: /data/redpanda
+`), 0o644))
+	run := func(args ...string) string {
+		var b bytes.Buffer
+		c := NewConfigCommand(fs)
+		c.SetOut(&b)
+		c.SetArgs(append([]string{"ensure-dirs"}, args...))
+		require.NoError(t, c.Execute())
+		return b.String()
+	}
+
+	require.Equal(t, "Created the data directory /data/redpanda\n", run("--create-data-dir"))
+	info, err := fs.Stat("/data/redpanda")
+	require.NoError(t, err)
+	require.True(t, info.IsDir())
+	require.Equal(t, "The data directory /data/redpanda exists and is writable\n", run())
+	require.JSONEq(t, `{"data_directory":"/data/redpanda","created":false}`, run("--create-data-dir", "--output", "json"))
+}
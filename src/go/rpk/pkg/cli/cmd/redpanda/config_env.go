@@ -0,0 +1,93 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func env(fs afero.Fs) *cobra.Command {
+	var (
+		prefix     string
+		noExport   bool
+		jsonLists  bool
+		configPath string
+	)
+	c := &cobra.Command{
+		Use:   "env",
+		Short: "Print the configuration as shell environment variables",
+		Long: `Print the configuration as shell environment variables.
+
+This prints an export line for each scalar value of the configuration, for
+the tools configured through environment variables, e.g.:
+
+  $ eval "$(rpk redpanda config env)"
+  $ echo $REDPANDA_RPC_SERVER_PORT
+  33145
+
+Each variable is named after the key of its value, uppercased and with
+underscores in place of the dots, so that redpanda.rpc_server.port is
+REDPANDA_RPC_SERVER_PORT. Use --prefix to prepend something to every name,
+e.g. --prefix RP_ for RP_REDPANDA_RPC_SERVER_PORT. The values are quoted for
+the shell if they need to be.
+
+Lists, such as the seed servers, are skipped, unless --json-lists is passed,
+in which case each list is a single variable holding the list as json.
+
+With --no-export, bare KEY=VALUE lines are printed instead, e.g. for a dotenv
+file. With --config -, the configuration is read from stdin.
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			cfg, err := loadConfig(fs, cmd)
+			out.MaybeDie(err, "unable to load config: %v", err)
+
+			vars, err := config.EnvVars(cfg, prefix, jsonLists)
+			out.MaybeDie(err, "unable to convert the configuration: %v", err)
+			for _, v := range vars {
+				if !noExport {
+					fmt.Fprint(cmd.OutOrStdout(), "export ")
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s=%s\n", v[0], shellQuote(v[1]))
+			}
+		},
+	}
+	c.Flags().StringVar(&prefix, "prefix", "", "Prefix of the variable names")
+	c.Flags().BoolVar(&noExport, "no-export", false, "Print KEY=VALUE lines, without export")
+	c.Flags().BoolVar(&jsonLists, "json-lists", false, "Print the lists as json, instead of skipping them")
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileFlagDesc+`, or "-" to read it from stdin`,
+	)
+	return c
+}
+
+// shellSafe matches the values that need no quoting for a POSIX shell.
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote returns s single quoted for a POSIX shell, unless it is safe.
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnv(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  node_id: 3
+  rack: rack 1
+  rpc_server:
+    address: 10.0.0.1
+    port: 33146
+  seed_servers:
+    - host:
+        address: 10.0.0.2
+        port: 33145
+rpk:
+  tune_network: true
+`), 0o644))
+	run := func(args ...string) []string {
+		var b bytes.Buffer
+		c := env(fs)
+		c.SetOut(&b)
+		c.SetArgs(args)
+		require.NoError(t, c.Execute())
+		return strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	}
+
+	lines := run()
+	for _, exp := range []string{
+		"export REDPANDA_NODE_ID=3",
+		"export REDPANDA_RACK='rack 1'",
+		"export REDPANDA_RPC_SERVER_ADDRESS=10.0.0.1",
+		"export REDPANDA_RPC_SERVER_PORT=33146",
+		"export RPK_TUNE_NETWORK=true",
+	} {
+		require.Contains(t, lines, exp)
+	}
+	for _, l := range lines {
+		require.NotContains(t, l, "SEED_SERVERS")
+		require.NotContains(t, l, "CONFIG_FILE")
+	}
+
+	lines = run("--prefix", "RP_", "--no-export", "--json-lists")
+	require.Contains(t, lines, "RP_REDPANDA_RPC_SERVER_PORT=33146")
+	require.Contains(t, lines, `RP_REDPANDA_SEED_SERVERS='[{"host":{"address":"10.0.0.2","port":33145}}]'`)
+	for _, l := range lines {
+		require.True(t, strings.HasPrefix(l, "RP_"), l)
+	}
+
+	require.Equal(t, "'it'\\''s'", shellQuote("it's"))
+	require.Equal(t, "''", shellQuote(""))
+}
@@ -0,0 +1,112 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func export(fs afero.Fs) *cobra.Command {
+	var (
+		format     string
+		outPath    string
+		sortKeys   bool
+		configPath string
+	)
+	c := &cobra.Command{
+		Use:   "export",
+		Short: "Print the configuration values that differ from the defaults",
+		Long: `Print the configuration values that differ from the defaults.
+
+This prints a minimal configuration, only holding the keys whose value differs
+from their default, e.g. to check a compact configuration into git. Keys that
+are absent from the configuration file are considered to have their default
+value. Lists are exported whole if any of their elements differ.
+
+The output can be printed as yaml (default), json or hcl, which rpk only
+writes and never reads back, and written to a file with --out instead of
+stdout. The keys of the maps, such as the properties
+rpk does not manage, are sorted bytewise for a stable output, unless
+--sort-keys=false is passed.
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			cfg, err := loadConfig(fs, cmd)
+			out.MaybeDie(err, "unable to load config: %v", err)
+
+			// Keys absent from the file are not overrides, even if
+			// they are loaded with a zero value.
+			cfg, err = cfg.WithDefaults(fs)
+			out.MaybeDie(err, "unable to fill defaults: %v", err)
+			// So that equivalent configurations export the same.
+			cfg = config.Canonicalize(cfg)
+
+			overrides, err := cfg.Overrides()
+			out.MaybeDie(err, "unable to compute the non default values: %v", err)
+
+			if sortKeys {
+				config.SortKeys(overrides)
+			}
+			b, err := marshalExport(overrides, format)
+			out.MaybeDieErr(err)
+
+			if outPath == "" {
+				fmt.Fprint(cmd.OutOrStdout(), string(b))
+				return
+			}
+			err = afero.WriteFile(fs, outPath, b, 0o644)
+			out.MaybeDie(err, "unable to write %q: %v", outPath, err)
+		},
+	}
+	c.Flags().StringVar(&format, "format", "yaml", "Output format (yaml/json/hcl)")
+	c.Flags().StringVar(&outPath, "out", "", "File to write the exported configuration to, instead of stdout")
+	c.Flags().BoolVar(&sortKeys, "sort-keys", true, "Sort the keys of the maps, for a stable output")
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileFlagDesc+`, or "-" to read it from stdin`,
+	)
+	return c
+}
+
+func marshalExport(n *yaml.Node, format string) ([]byte, error) {
+	switch format {
+	case "yaml":
+		return yaml.Marshal(n)
+	case "json":
+		var v map[string]interface{}
+		if err := n.Decode(&v); err != nil {
+			return nil, err
+		}
+		if v == nil {
+			v = map[string]interface{}{}
+		}
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	case "hcl":
+		return config.MarshalHCL(n)
+	default:
+		return nil, fmt.Errorf("unsupported format %q, must be yaml, json or hcl", format)
+	}
+}
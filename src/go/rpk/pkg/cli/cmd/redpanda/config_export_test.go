@@ -0,0 +1,116 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	var b bytes.Buffer
+	c := export(fs)
+	c.SetOut(&b)
+	c.SetArgs([]string{"--format", "json"})
+	require.NoError(t, c.Execute())
+	// Only the rpk addresses that are derived on load are exported.
+	require.JSONEq(t, `{"rpk": {
+  "kafka_api": {"brokers": ["0.0.0.0:9092"]},
+  "admin_api": {"addresses": ["127.0.0.1:9644"]}
+}}`, b.String())
+
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  node_id: 3
+  data_directory: /data
+  seed_servers:
+    - host:
+        address: 10.0.0.1
+        port: 33145
+  developer_mode: true
+rpk:
+  tune_cpu: true
+  kafka_api:
+    brokers:
+      - 0.0.0.0:9092
+  admin_api:
+    addresses:
+      - 127.0.0.1:9644
+`), 0o644))
+	c = export(fs)
+	c.SetArgs([]string{"--out", "/export.yaml"})
+	require.NoError(t, c.Execute())
+
+	exported, err := afero.ReadFile(fs, "/export.yaml")
+	require.NoError(t, err)
+	require.Equal(t, `redpanda:
+    data_directory: /data
+    node_id: 3
+    seed_servers:
+        - host:
+            address: 10.0.0.1
+            port: 33145
+rpk:
+    kafka_api:
+        brokers:
+            - 0.0.0.0:9092
+    admin_api:
+        addresses:
+            - 127.0.0.1:9644
+    tune_cpu: true
+`, string(exported))
+
+	// Loading the overrides with the defaults results in the original
+	// config.
+	orig, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	orig, err = orig.WithDefaults(fs)
+	require.NoError(t, err)
+	roundTrip, err := (&config.Params{ConfigPath: "/export.yaml"}).Load(fs)
+	require.NoError(t, err)
+	roundTrip, err = roundTrip.WithDefaults(fs)
+	require.NoError(t, err)
+	require.Equal(t, orig.Redpanda, roundTrip.Redpanda)
+	require.Equal(t, orig.Rpk, roundTrip.Rpk)
+
+	var b2 bytes.Buffer
+	c = export(fs)
+	c.SetOut(&b2)
+	c.SetArgs([]string{"--format", "hcl"})
+	require.NoError(t, c.Execute())
+	require.Equal(t, `redpanda {
+  data_directory = "/data"
+  node_id        = 3
+  seed_servers {
+    host {
+      address = "10.0.0.1"
+      port    = 33145
+    }
+  }
+}
+rpk {
+  kafka_api {
+    brokers = ["0.0.0.0:9092"]
+  }
+  admin_api {
+    addresses = ["127.0.0.1:9644"]
+  }
+  tune_cpu = true
+}
+`, b2.String())
+}
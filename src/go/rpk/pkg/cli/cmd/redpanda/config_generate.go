@@ -0,0 +1,94 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"fmt"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func generate(fs afero.Fs) *cobra.Command {
+	var (
+		force      bool
+		dataDir    string
+		configPath string
+		createDirs bool
+	)
+	c := &cobra.Command{
+		Use:   "generate",
+		Short: "Write a default configuration file, to start configuring a new node",
+		Long: `Write a default configuration file, to start configuring a new node.
+
+The configuration is written to /etc/redpanda/redpanda.yaml, or to the file
+passed with --config. An existing file is not overwritten unless --force is
+passed:
+
+  rpk redpanda config generate --config redpanda.yaml --data-dir /mnt/redpanda
+
+The directory of the file must exist, unless --create-dirs is passed to
+create it.
+
+The generated file can then be updated with the bootstrap and set commands.
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			cfg, err := config.ParamsFromCommand(cmd).DefaultConfig()
+			maybeDieCode(err, exitInvalidInput, "unable to generate config: %v", err)
+
+			logWrite(cmd, cfg)
+			if createDirs {
+				err = createConfigDir(fs, cfg.FileLocation())
+				maybeDieCode(err, exitIO, "%v", err)
+			}
+			err = writeDefaultConfig(fs, cfg, dataDir, force)
+			maybeDieCode(err, exitIO, "%v", err)
+			pr.Messagef("Wrote the default configuration to %q.", cfg.ConfigFile)
+		},
+	}
+	c.Flags().BoolVar(&force, "force", false, "Overwrite the configuration file if it already exists")
+	c.Flags().StringVar(&dataDir, "data-dir", "", "Data directory of the generated configuration, instead of the default one")
+	addCreateDirsFlag(c, &createDirs)
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileFlagDesc,
+	)
+	addConfigFormatFlag(c)
+	return c
+}
+
+// writeDefaultConfig writes cfg to its config file, refusing to overwrite an
+// existing file unless force is set.
+func writeDefaultConfig(fs afero.Fs, cfg *config.Config, dataDir string, force bool) error {
+	if dataDir != "" {
+		cfg.Redpanda.Directory = dataDir
+	}
+	path := cfg.FileLocation()
+	exists, err := afero.Exists(fs, path)
+	if err != nil {
+		return fmt.Errorf("unable to check if %q exists: %v", path, err)
+	}
+	if exists && !force {
+		return fmt.Errorf("%q already exists, use --force to overwrite it", path)
+	}
+	if err := cfg.Write(fs); err != nil {
+		return fmt.Errorf("error writing config file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"io"
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	existing := "redpanda:\n    node_id: 1\n"
+	for _, test := range []struct {
+		name     string
+		existing bool
+		dataDir  string
+		force    bool
+		expErr   bool
+		expDir   string
+	}{
+		{
+			name:   "no existing config",
+			expDir: "/var/lib/redpanda/data",
+		},
+		{
+			name:    "data directory override",
+			dataDir: "/mnt/redpanda",
+			expDir:  "/mnt/redpanda",
+		},
+		{
+			name:     "existing config is not overwritten",
+			existing: true,
+			expErr:   true,
+		},
+		{
+			name:     "existing config is overwritten with --force",
+			existing: true,
+			force:    true,
+			expDir:   "/var/lib/redpanda/data",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if test.existing {
+				require.NoError(t, afero.WriteFile(fs, path, []byte(existing), 0o644))
+			}
+			cfg, err := (&config.Params{ConfigPath: path}).DefaultConfig()
+			require.NoError(t, err)
+			err = writeDefaultConfig(fs, cfg, test.dataDir, test.force)
+			if test.expErr {
+				require.Error(t, err)
+				b, err := afero.ReadFile(fs, path)
+				require.NoError(t, err)
+				require.Equal(t, existing, string(b))
+				return
+			}
+			require.NoError(t, err)
+
+			conf, err := (&config.Params{ConfigPath: path}).Load(fs)
+			require.NoError(t, err)
+			exp := config.Default()
+			exp.Redpanda.Directory = test.expDir
+			require.Equal(t, exp.Redpanda, conf.Redpanda)
+		})
+	}
+
+	fs := afero.NewMemMapFs()
+	c := generate(fs)
+	c.SetOut(io.Discard)
+	c.SetArgs([]string{"--config", "/tmp/redpanda.toml", "--data-dir", "/mnt/redpanda"})
+	require.NoError(t, c.Execute())
+	b, err := afero.ReadFile(fs, "/tmp/redpanda.toml")
+	require.NoError(t, err)
+	require.Contains(t, string(b), `data_directory = "/mnt/redpanda"`)
+}
@@ -0,0 +1,196 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func get(fs afero.Fs) *cobra.Command {
+	var (
+		format      string
+		configPath  string
+		withDefault bool
+		raw         bool
+	)
+	c := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Get configuration values, such as the node ID or the list of seed servers",
+		Long: `Get configuration values, such as the node ID or the list of seed servers
+
+The key follows the same dotted notation used in 'rpk redpanda config set',
+e.g:
+
+  rpk redpanda config get redpanda.rpc_server.port
+
+Scalar values are printed as is. Object values, such as redpanda.kafka_api,
+are rendered according to --format (yaml/json).
+
+A "*" in the key matches any property at its level, and the other properties
+that apply to a list match each of its elements. Each matched key is printed
+along with its value, sorted by key, e.g. to get every listener port:
+
+  rpk redpanda config get 'redpanda.*.port'
+
+With --default, the default value of the key is printed along with its current
+value, to tell whether it is overridden:
+
+  $ rpk redpanda config get redpanda.rpc_server.port --default
+  current=33145 default=33145
+
+Both values are rendered according to --format, and printed in indented blocks
+if either spans multiple lines. Keys that have no default, such as the
+elements of a list past the default ones, have a default of (none).
+
+With --raw, scalar values are printed bare, with no YAML quoting and no
+trailing newline, so that command substitution gets the value as is:
+
+  PORT=$(rpk redpanda config get redpanda.rpc_server.port --raw)
+
+Object values are still rendered according to --format. --raw applies to a
+single key, so it cannot be used with "*" or --default.
+
+With --output json, the key is printed as {"key": ..., "value": ...}, along
+with its "default" with --default, and the keys matched by a "*" as a list of
+those. The values are then json whatever the --format.
+`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeKeys(fs),
+		Run: func(cmd *cobra.Command, args []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			if raw {
+				if withDefault {
+					out.DieCode(exitInvalidInput, "--raw cannot be used with --default")
+				}
+				if strings.Contains(args[0], "*") {
+					out.DieCode(exitInvalidInput, "--raw cannot be used with \"*\" keys")
+				}
+				if pr.json() {
+					out.DieCode(exitInvalidInput, "--raw cannot be used with --output %s", pr.output)
+				}
+			}
+			p := config.ParamsFromCommand(cmd)
+			cfg, err := p.Load(fs)
+			out.MaybeDie(err, "unable to load config: %v", err)
+			logLoaded(cmd, cfg)
+
+			getValue := func(key string) string {
+				v, err := cfg.Get(key, format)
+				out.MaybeDie(err, "unable to get %q: %v", key, err)
+				if !withDefault {
+					return v
+				}
+				def, err := config.Default().Get(key, format)
+				if errors.Is(err, config.ErrKeyNotFound) {
+					def = "(none)"
+				} else {
+					out.MaybeDie(err, "unable to get the default of %q: %v", key, err)
+				}
+				return withDefaultValue(v, def)
+			}
+			getResult := func(key string) getKeyResult {
+				v, err := cfg.Get(key, "json")
+				out.MaybeDie(err, "unable to get %q: %v", key, err)
+				res := getKeyResult{Key: key, Value: json.RawMessage(v)}
+				if withDefault {
+					def, err := config.Default().Get(key, "json")
+					if errors.Is(err, config.ErrKeyNotFound) {
+						def = "null"
+					} else {
+						out.MaybeDie(err, "unable to get the default of %q: %v", key, err)
+					}
+					res.Default = json.RawMessage(def)
+				}
+				return res
+			}
+
+			if raw {
+				v, err := cfg.GetRaw(args[0], format)
+				out.MaybeDie(err, "unable to get %q: %v", args[0], err)
+				err = pr.Result(nil, func(w io.Writer) { fmt.Fprint(w, v) })
+				out.MaybeDieErr(err)
+				return
+			}
+			if !strings.Contains(args[0], "*") {
+				var res interface{}
+				if pr.json() {
+					res = getResult(args[0])
+				}
+				err = pr.Result(res, func(w io.Writer) { fmt.Fprintln(w, getValue(args[0])) })
+				out.MaybeDieErr(err)
+				return
+			}
+			keys, err := cfg.Glob(args[0])
+			out.MaybeDie(err, "unable to get %q: %v", args[0], err)
+			res := make([]getKeyResult, 0, len(keys))
+			if pr.json() {
+				for _, key := range keys {
+					res = append(res, getResult(key))
+				}
+			}
+			err = pr.Result(res, func(w io.Writer) {
+				for _, key := range keys {
+					printKeyValue(w, key, getValue(key))
+				}
+			})
+			out.MaybeDieErr(err)
+		},
+	}
+	c.Flags().StringVar(&format, "format", "yaml", "Format of object values (single/yaml/json)")
+	c.Flags().BoolVar(&raw, "raw", false, "Print scalar values bare, without quoting or trailing newline")
+	c.Flags().BoolVar(&withDefault, "default", false, "Print the default value of the key along with its current value")
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileFlagDesc,
+	)
+	return c
+}
+
+// getKeyResult is what get --output json prints for each key.
+type getKeyResult struct {
+	Key     string          `json:"key"`
+	Value   json.RawMessage `json:"value"`
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+// printKeyValue prints the key and its value on one line, or the value
+// indented on the following lines if it spans multiple lines.
+func printKeyValue(w io.Writer, key, v string) {
+	if !strings.Contains(v, "\n") {
+		fmt.Fprintf(w, "%s: %s\n", key, v)
+		return
+	}
+	fmt.Fprintf(w, "%s:\n  %s\n", key, strings.ReplaceAll(v, "\n", "\n  "))
+}
+
+// withDefaultValue renders the current value of a key along with its default
+// value, on one line if both are single-line, or as two indented blocks.
+func withDefaultValue(current, def string) string {
+	if !strings.Contains(current, "\n") && !strings.Contains(def, "\n") {
+		return fmt.Sprintf("current=%s default=%s", current, def)
+	}
+	indent := func(v string) string { return "  " + strings.ReplaceAll(v, "\n", "\n  ") }
+	return fmt.Sprintf("current:\n%s\ndefault:\n%s", indent(current), indent(def))
+}
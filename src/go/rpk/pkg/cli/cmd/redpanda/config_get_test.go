@@ -0,0 +1,156 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetGlob(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  rpc_server:
+    address: 10.0.0.1
+    port: 33145
+  kafka_api:
+    - address: 10.0.0.1
+      port: 9092
+  admin:
+    - address: 127.0.0.1
+      port: 9644
+`), 0o644))
+
+	var b bytes.Buffer
+	c := get(fs)
+	c.SetOut(&b)
+	c.SetArgs([]string{"redpanda.*.address"})
+	require.NoError(t, c.Execute())
+	require.Equal(t, `redpanda.admin.0.address: 127.0.0.1
+redpanda.kafka_api.0.address: 10.0.0.1
+redpanda.rpc_server.address: 10.0.0.1
+`, b.String())
+
+	b.Reset()
+	c = get(fs)
+	c.SetOut(&b)
+	c.SetArgs([]string{"*.admin"})
+	require.NoError(t, c.Execute())
+	require.Equal(t, `redpanda.admin:
+  - address: 127.0.0.1
+    port: 9644
+`, b.String())
+}
+
+func TestGetRaw(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  rack: "row 1: rack 2"
+  developer_mode: true
+  rpc_server:
+    address: 10.0.0.1
+    port: 33145
+  cloud_label: "zone: a"
+  log_segment_size: 1024
+  extra:
+    a: 1
+`), 0o644))
+
+	for _, test := range []struct {
+		name   string
+		args   []string
+		expDef string
+		expRaw string
+	}{
+		{"int", []string{"redpanda.rpc_server.port"}, "33145\n", "33145"},
+		{"string with spaces", []string{"redpanda.rack"}, "'row 1: rack 2'\n", "row 1: rack 2"},
+		{"bool", []string{"redpanda.developer_mode"}, "true\n", "true"},
+		{"object", []string{"redpanda.rpc_server", "--format", "json"}, `{"address":"10.0.0.1","port":33145}` + "\n", `{"address":"10.0.0.1","port":33145}`},
+		{"unmanaged string", []string{"redpanda.cloud_label"}, "'zone: a'\n", "zone: a"},
+		{"unmanaged int", []string{"redpanda.log_segment_size"}, "1024\n", "1024"},
+		{"unmanaged object", []string{"redpanda.extra", "--format", "json"}, `{"a":1}` + "\n", `{"a":1}`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			for _, raw := range []bool{false, true} {
+				var b bytes.Buffer
+				c := get(fs)
+				c.SetOut(&b)
+				args, exp := test.args, test.expDef
+				if raw {
+					args, exp = append(args, "--raw"), test.expRaw
+				}
+				c.SetArgs(args)
+				require.NoError(t, c.Execute())
+				require.Equal(t, exp, b.String())
+			}
+		})
+	}
+}
+
+func TestGetDefault(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  rpc_server:
+    address: 10.0.0.1
+    port: 33145
+  kafka_api:
+    - address: 10.0.0.1
+      port: 9093
+    - address: 10.0.0.1
+      port: 9094
+`), 0o644))
+
+	for _, test := range []struct {
+		name string
+		args []string
+		exp  string
+	}{
+		{"same as default", []string{"redpanda.rpc_server.port"}, "current=33145 default=33145\n"},
+		{"overridden", []string{"redpanda.rpc_server.address"}, "current=10.0.0.1 default=0.0.0.0\n"},
+		{"no default", []string{"redpanda.kafka_api.1.port"}, "current=9094 default=(none)\n"},
+		{"json", []string{"redpanda.rpc_server", "--format", "json"}, `current={"address":"10.0.0.1","port":33145} default={"address":"0.0.0.0","port":33145}` + "\n"},
+		{
+			"object",
+			[]string{"redpanda.kafka_api"},
+			`current:
+  - address: 10.0.0.1
+    port: 9093
+  - address: 10.0.0.1
+    port: 9094
+default:
+  - address: 0.0.0.0
+    port: 9092
+`,
+		},
+		{
+			"glob",
+			[]string{"redpanda.*.port"},
+			`redpanda.kafka_api.0.port: current=9093 default=9092
+redpanda.kafka_api.1.port: current=9094 default=(none)
+redpanda.rpc_server.port: current=33145 default=33145
+`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var b bytes.Buffer
+			c := get(fs)
+			c.SetOut(&b)
+			c.SetArgs(append(test.args, "--default"))
+			require.NoError(t, c.Execute())
+			require.Equal(t, test.exp, b.String())
+		})
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// importFragment is the import command; import is a reserved word.
+func importFragment(fs afero.Fs) *cobra.Command {
+	var (
+		dryRun       bool
+		configPath   string
+		headers      []string
+		fetchTimeout time.Duration
+		lockTimeout  time.Duration
+		backup       bool
+		backupSuffix string
+	)
+	c := &cobra.Command{
+		Use:   "import <fragment|url>",
+		Short: "Merge a configuration fragment into the configuration",
+		Long: `Merge a configuration fragment into the configuration.
+
+The fragment is a YAML or JSON file holding part of a configuration, e.g. a
+reusable tuning block or listener definitions:
+
+  redpanda:
+    kafka_api:
+      - address: 0.0.0.0
+        port: 9092
+        name: internal
+
+The fragment is deep merged onto the current configuration: objects are
+merged recursively, while values and lists from the fragment replace the
+current ones.
+
+The fragment can also be fetched from a URL: file:// URLs are read from the
+local filesystem, while http:// and https:// URLs are downloaded, failing if
+the server does not respond with 200 OK within --fetch-timeout. Use --header
+to send request headers, e.g. for authentication:
+
+  rpk redpanda config import https://artifacts.example.com/tuning.yaml \
+    --header "Authorization: Bearer $TOKEN"
+
+Use --dry-run to print the merged configuration instead of writing it.
+Otherwise, the keys that the fragment changed are printed along with their old
+and new values, as set does.
+`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			if pr.json() && dryRun {
+				out.DieCode(exitInvalidInput, "--output %s cannot be used with --dry-run, which prints the config", pr.output)
+			}
+			if configPath == configStdio {
+				// Stdout is the configuration itself.
+				pr.w = cmd.ErrOrStderr()
+			}
+			unlock, err := lockConfig(fs, cmd, lockTimeout)
+			out.MaybeDieErr(err)
+			defer unlock()
+
+			cfg, err := loadConfig(fs, cmd)
+			out.MaybeDie(err, "unable to load config: %v", err)
+
+			fragment, err := readFragment(fs, args[0], headers, fetchTimeout)
+			out.MaybeDieErr(err)
+
+			merged, err := cfg.MergeFragment(fragment)
+			out.MaybeDie(err, "unable to merge %q: %v", args[0], err)
+
+			if dryRun {
+				err = merged.Encode(cmd.OutOrStdout())
+				out.MaybeDieErr(err)
+				return
+			}
+			err = writeConfig(fs, cmd, merged, backup, backupSuffix)
+			out.MaybeDie(err, "error writing config file: %v", err)
+			err = printSetResult(pr, cfg, merged, "")
+			out.MaybeDieErr(err)
+		},
+	}
+	c.Flags().BoolVar(&dryRun, "dry-run", false, "Print the merged configuration instead of writing it")
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileStdioDesc,
+	)
+	c.Flags().StringArrayVar(&headers, "header", nil, "HTTP header to send when fetching an http(s) fragment, as 'Name: value' (repeatable)")
+	c.Flags().DurationVar(&fetchTimeout, "fetch-timeout", 30*time.Second, "How long to wait for an http(s) fragment to be fetched")
+	addLockTimeoutFlag(c, &lockTimeout)
+	addBackupFlags(c, &backup, &backupSuffix)
+	return c
+}
+
+// readFragment returns the contents of the configuration fragment at src,
+// which is either a path or a file, http or https URL. headers and timeout
+// only apply to http(s) URLs.
+func readFragment(fs afero.Fs, src string, headers []string, timeout time.Duration) ([]byte, error) {
+	u, err := url.Parse(src)
+	if err != nil || u.Scheme == "" {
+		return readFragmentFile(fs, src)
+	}
+	switch u.Scheme {
+	case "file":
+		return readFragmentFile(fs, u.Path)
+	case "http", "https":
+		return fetchFragment(u, headers, timeout)
+	default:
+		return nil, fmt.Errorf("unsupported fragment URL scheme %q in %q, must be file, http or https", u.Scheme, src)
+	}
+}
+
+func readFragmentFile(fs afero.Fs, path string) ([]byte, error) {
+	b, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read fragment %q: %v", path, err)
+	}
+	return b, nil
+}
+
+// fetchFragment downloads the fragment at the http(s) URL u.
+func fetchFragment(u *url.URL, headers []string, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request for fragment %q: %v", u.Redacted(), err)
+	}
+	for _, h := range headers {
+		name, value, ok := cutHeader(h)
+		if !ok {
+			return nil, fmt.Errorf("invalid --header %q, must be 'Name: value'", h)
+		}
+		req.Header.Add(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch fragment %q: %v", u.Redacted(), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch fragment %q: unexpected status %s", u.Redacted(), resp.Status)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read fragment %q: %v", u.Redacted(), err)
+	}
+	return b, nil
+}
+
+// cutHeader splits a 'Name: value' header.
+func cutHeader(h string) (name, value string, ok bool) {
+	i := strings.IndexByte(h, ':')
+	if i <= 0 {
+		return "", "", false
+	}
+	name = strings.TrimSpace(h[:i])
+	return name, strings.TrimSpace(h[i+1:]), name != ""
+}
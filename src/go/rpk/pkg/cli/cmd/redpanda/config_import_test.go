@@ -0,0 +1,115 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+	const file = `redpanda:
+  node_id: 3
+  rack: r1
+`
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(file), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/fragment.yaml", []byte(`redpanda:
+  rack: r2
+  kafka_api:
+    - address: 10.0.0.3
+      port: 9093
+      name: internal
+`), 0o644))
+
+	var b bytes.Buffer
+	c := importFragment(fs)
+	c.SetOut(&b)
+	c.SetArgs([]string{"/fragment.yaml", "--dry-run"})
+	require.NoError(t, c.Execute())
+	require.Contains(t, b.String(), "rack: r2")
+	require.Contains(t, b.String(), "name: internal")
+	got, err := afero.ReadFile(fs, "/etc/redpanda/redpanda.yaml")
+	require.NoError(t, err)
+	require.Equal(t, file, string(got), "--dry-run should not write the config")
+
+	c = importFragment(fs)
+	c.SetArgs([]string{"/fragment.yaml"})
+	require.NoError(t, c.Execute())
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 3, conf.Redpanda.ID)
+	require.Equal(t, "r2", conf.Redpanda.Rack)
+	require.Equal(t, []config.NamedSocketAddress{{Address: "10.0.0.3", Port: 9093, Name: "internal"}}, conf.Redpanda.KafkaAPI)
+}
+
+func TestImportURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/rack.yaml":
+			fmt.Fprint(w, "redpanda:\n  rack: r2\n")
+		case "/slow.yaml":
+			time.Sleep(time.Second)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte("redpanda:\n  node_id: 3\n"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/fragments/id.yaml", []byte("redpanda:\n  node_id: 4\n"), 0o644))
+
+	c := importFragment(fs)
+	c.SetArgs([]string{srv.URL + "/rack.yaml", "--header", "Authorization: Bearer secret"})
+	require.NoError(t, c.Execute())
+	c = importFragment(fs)
+	c.SetArgs([]string{"file:///fragments/id.yaml"})
+	require.NoError(t, c.Execute())
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 4, conf.Redpanda.ID)
+	require.Equal(t, "r2", conf.Redpanda.Rack)
+
+	for _, test := range []struct {
+		name    string
+		src     string
+		headers []string
+		exp     string
+	}{
+		{"unauthorized", srv.URL + "/rack.yaml", nil, "unexpected status 401 Unauthorized"},
+		{"not found", srv.URL + "/missing.yaml", []string{"Authorization: Bearer secret"}, "unexpected status 404 Not Found"},
+		{"timeout", srv.URL + "/slow.yaml", []string{"Authorization: Bearer secret"}, "unable to fetch fragment"},
+		{"invalid header", srv.URL + "/rack.yaml", []string{"Authorization"}, "invalid --header"},
+		{"missing file", "file:///fragments/missing.yaml", nil, "unable to read fragment"},
+		{"unsupported scheme", "ftp://example.com/rack.yaml", nil, "unsupported fragment URL scheme"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := readFragment(fs, test.src, test.headers, 100*time.Millisecond)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.exp)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/cobra"
+)
+
+func keysSchema() *cobra.Command {
+	return &cobra.Command{
+		Use:   "keys-schema",
+		Short: "Print a JSON Schema of the configuration",
+		Long: `Print a JSON Schema of the configuration.
+
+This prints a JSON Schema (draft-07) document describing the keys that rpk
+manages and the type of their value, as listed by list-keys, e.g. to validate
+config files in CI with a standard JSON Schema validator rather than rpk:
+
+  rpk redpanda config keys-schema > redpanda.schema.json
+
+Socket addresses require both an address and a port, and SASL mechanisms must
+be one of the supported ones. Sections with unmanaged properties, such as
+redpanda, accept any other key; the other sections, such as rpk, reject the
+keys rpk does not know about.
+
+The schema is generated from the configuration rpk reads, so it matches the
+version of rpk that prints it. It describes the values as rpk writes them:
+rpk itself is more lenient when reading, e.g. it accepts "1" for an integer.
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			b, err := json.MarshalIndent(config.JSONSchema(), "", "  ")
+			out.MaybeDie(err, "unable to encode the schema: %v", err)
+			fmt.Fprintln(cmd.OutOrStdout(), string(b))
+		},
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeysSchema(t *testing.T) {
+	var b bytes.Buffer
+	c := keysSchema()
+	c.SetOut(&b)
+	require.NoError(t, c.Execute())
+
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(b.Bytes(), &schema))
+	require.Equal(t, config.JSONSchemaDraft, schema["$schema"])
+
+	// Every managed key is in the schema.
+	for _, k := range config.Keys("") {
+		s := schema
+		for _, prop := range strings.Split(k.Path, ".") {
+			for s["type"] == "array" {
+				s = s["items"].(map[string]interface{})
+			}
+			props, ok := s["properties"].(map[string]interface{})
+			require.True(t, ok, "%s: no properties above %s", k.Path, prop)
+			s, ok = props[prop].(map[string]interface{})
+			require.True(t, ok, "%s is not in the schema", k.Path)
+		}
+	}
+	rpcServer := schema["properties"].(map[string]interface{})["redpanda"].(map[string]interface{})["properties"].(map[string]interface{})["rpc_server"]
+	require.Equal(t, map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"address": map[string]interface{}{"type": "string"},
+			"port":    map[string]interface{}{"type": "integer"},
+		},
+		"additionalProperties": false,
+		"required":             []interface{}{"address", "port"},
+	}, rpcServer)
+}
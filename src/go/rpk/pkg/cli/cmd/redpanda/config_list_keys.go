@@ -0,0 +1,56 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/cobra"
+)
+
+func listKeys() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list-keys [prefix]",
+		Short: "List the configuration keys that can be set, and their type",
+		Long: `List the configuration keys that can be set, and their type
+
+This lists every key that rpk knows about, along with the type of its value.
+If a prefix is passed, only the keys starting with it are listed, e.g.:
+
+  rpk redpanda config list-keys redpanda.
+
+The fields of list elements are listed under the list key, and refer to the
+first element: an index can be inserted after the list key to refer to another
+element, e.g. redpanda.seed_servers.1.host.address.
+
+Sections with unmanaged properties, such as redpanda, also accept any key that
+is not listed, which is written as is for redpanda to read.
+`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var prefix string
+			if len(args) == 1 {
+				prefix = args[0]
+			}
+			keys := config.Keys(prefix)
+			if len(keys) == 0 {
+				out.Die("no configuration key starts with %q", prefix)
+			}
+			tw := out.NewTableTo(cmd.OutOrStdout(), "key", "type")
+			defer tw.Flush()
+			for _, k := range keys {
+				tw.Print(k.Path, k.Type)
+			}
+		},
+	}
+}
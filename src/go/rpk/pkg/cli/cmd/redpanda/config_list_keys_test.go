@@ -0,0 +1,53 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListKeys(t *testing.T) {
+	var b bytes.Buffer
+	c := listKeys()
+	c.SetOut(&b)
+	require.NoError(t, c.Execute())
+	lines := strings.Split(b.String(), "\n")
+	require.Regexp(t, `^KEY\s+TYPE$`, lines[0])
+	for _, exp := range []string{
+		`redpanda.rpc_server.port\s+int`,
+		`redpanda.seed_servers\s+\[\]config.SeedServer`,
+		`redpanda.kafka_api.address\s+string`,
+		`rpk.tls.key_file\s+string`,
+		`rpk.smp\s+\*int`,
+		`schema_registry.schema_registry_api\s+\[\]config.NamedSocketAddress`,
+	} {
+		require.Regexp(t, "(?m)^"+exp+"$", b.String())
+	}
+	require.NotContains(t, b.String(), "Other")
+
+	b.Reset()
+	c = listKeys()
+	c.SetOut(&b)
+	c.SetArgs([]string{"redpanda.rpc_server"})
+	require.NoError(t, c.Execute())
+	require.Regexp(t, `^KEY\s+TYPE
+redpanda.rpc_server\s+config.SocketAddress
+redpanda.rpc_server.address\s+string
+redpanda.rpc_server.port\s+int
+redpanda.rpc_server_tls\s+\[\]config.ServerTLS
+`, b.String())
+}
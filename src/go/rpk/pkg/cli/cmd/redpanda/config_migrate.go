@@ -0,0 +1,98 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func migrate(fs afero.Fs) *cobra.Command {
+	var (
+		dryRun     bool
+		configPath string
+	)
+	c := &cobra.Command{
+		Use:   "migrate",
+		Short: "Rewrite the config file from legacy layouts to the current schema",
+		Long: `Rewrite the config file from legacy layouts to the current schema
+
+Older config files may use keys that have since been renamed or relocated,
+such as rpk.tls, which is now rpk.kafka_api.tls and rpk.admin_api.tls, or seed
+servers with their address and port outside of host. This rewrites them to the
+current layout, keeping their values and the comments of the file, and lists
+the applied migrations.
+
+Once migrated, the file is stamped with its schema version in config_version,
+and only the migrations to later versions apply to it. Files without it are
+version 0.
+
+With --dry-run, the migrated configuration is printed rather than written, and
+the applied migrations are listed on stderr:
+
+  rpk redpanda config migrate --dry-run | diff /etc/redpanda/redpanda.yaml -
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			p := config.ParamsFromCommand(cmd)
+			m, err := p.Migrate(fs, dryRun)
+			maybeDieLoad(err, "unable to migrate config: %v", err)
+			logf(cmd, "Loaded config file %s", m.Path)
+
+			if dryRun {
+				for _, applied := range m.Applied {
+					fmt.Fprintln(cmd.ErrOrStderr(), applied)
+				}
+				fmt.Fprint(cmd.OutOrStdout(), string(m.After))
+				return
+			}
+			applied := m.Applied
+			if applied == nil {
+				applied = []string{}
+			}
+			err = pr.Result(migrateResult{m.Path, applied}, func(w io.Writer) {
+				if len(applied) == 0 {
+					fmt.Fprintln(w, "config already up to date")
+					return
+				}
+				fmt.Fprintf(w, "Migrated %s:\n", m.Path)
+				for _, a := range applied {
+					fmt.Fprintf(w, "  %s\n", a)
+				}
+			})
+			out.MaybeDieErr(err)
+		},
+	}
+	c.Flags().BoolVar(&dryRun, "dry-run", false, "Print the migrated configuration instead of writing it")
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileFlagDesc,
+	)
+	addConfigFormatFlag(c)
+	return c
+}
+
+// migrateResult is what migrate --output json prints once done.
+type migrateResult struct {
+	Path    string   `json:"path"`
+	Applied []string `json:"applied"`
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, path, []byte(`rpk:
+    sasl:
+        user: admin
+`), 0o644))
+	const exp = `config_version: 2
+rpk:
+    kafka_api:
+        sasl:
+            user: admin
+`
+	run := func(args ...string) (string, string) {
+		var stdout, stderr bytes.Buffer
+		c := migrate(fs)
+		c.SetOut(&stdout)
+		c.SetErr(&stderr)
+		c.SetArgs(args)
+		require.NoError(t, c.Execute())
+		return stdout.String(), stderr.String()
+	}
+
+	stdout, stderr := run("--dry-run")
+	require.Equal(t, exp, stdout)
+	require.Equal(t, "moved rpk.tls and rpk.sasl to rpk.kafka_api and rpk.admin_api\n", stderr)
+
+	stdout, _ = run()
+	require.Equal(t, "Migrated "+path+":\n  moved rpk.tls and rpk.sasl to rpk.kafka_api and rpk.admin_api\n", stdout)
+	b, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	require.Equal(t, exp, string(b))
+
+	stdout, _ = run()
+	require.Equal(t, "config already up to date\n", stdout)
+}
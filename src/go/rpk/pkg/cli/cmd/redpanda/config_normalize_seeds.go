@@ -0,0 +1,94 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func normalizeSeeds(fs afero.Fs) *cobra.Command {
+	var (
+		configPath   string
+		lockTimeout  time.Duration
+		backup       bool
+		backupSuffix string
+		keepIDs      bool
+	)
+	c := &cobra.Command{
+		Use:   "normalize-seeds",
+		Short: "Remove the duplicate seed servers and sort them",
+		Long: `Remove the duplicate seed servers and sort them
+
+Over the life of a cluster, set --append and manual edits can leave the same
+seed server listed more than once, and nodes with their seed servers in a
+different order. This removes the duplicate hosts and sorts the seed servers,
+IP addresses first, so that every node lists them the same way, with the
+contiguous IDs that their indexes are.
+
+With --keep-ids, the duplicates are removed without sorting the seed servers:
+the remaining ones keep their ID, unless it is past the end of the shortened
+list, in which case they take the IDs the duplicates freed.
+
+If the seed servers already are normalized, the file is left untouched.
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			unlock, err := lockConfig(fs, cmd, lockTimeout)
+			maybeDieCode(err, exitIO, "%v", err)
+			defer unlock()
+
+			cfg, err := loadConfig(fs, cmd)
+			maybeDieLoad(err, "unable to load config: %v", err)
+
+			before, err := config.Render(cfg, config.FormatYAML)
+			out.MaybeDie(err, "unable to marshal config: %v", err)
+			seeds := cfg.Redpanda.SeedServers
+			normalized := config.NormalizeSeeds(seeds)
+			if keepIDs {
+				normalized = config.AssignSeedIDs(seeds, seeds)
+			}
+			cfg.Redpanda.SeedServers = normalized
+			after, err := config.Render(cfg, config.FormatYAML)
+			out.MaybeDie(err, "unable to marshal config: %v", err)
+			if cfg.File() != nil && bytes.Equal(before, after) {
+				pr.Messagef("seed servers already normalized")
+				return
+			}
+			err = writeConfig(fs, cmd, cfg, backup, backupSuffix)
+			maybeDieCode(err, exitIO, "%v", err)
+			if removed := len(seeds) - len(normalized); removed > 0 && !pr.quiet {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Removed %d duplicate seed server(s)\n", removed)
+			}
+		},
+	}
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileStdioDesc,
+	)
+	c.Flags().BoolVar(&keepIDs, "keep-ids", false, "Only remove the duplicates, keeping the IDs of the remaining seed servers")
+	addConfigFormatFlag(c)
+	addLockTimeoutFlag(c, &lockTimeout)
+	addBackupFlags(c, &backup, &backupSuffix)
+	return c
+}
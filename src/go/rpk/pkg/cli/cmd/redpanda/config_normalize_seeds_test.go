@@ -0,0 +1,101 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeSeeds(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, path, []byte(`redpanda:
+  node_id: 1
+  seed_servers:
+    - host:
+        address: 10.0.0.2
+        port: 33145
+    - host:
+        address: 10.0.0.1
+        port: 33145
+    - host:
+        address: 10.0.0.2
+        port: 33145
+`), 0o644))
+
+	var stdout, stderr bytes.Buffer
+	c := normalizeSeeds(fs)
+	c.SetOut(&stdout)
+	c.SetErr(&stderr)
+	c.SetArgs(nil)
+	require.NoError(t, c.Execute())
+	require.Empty(t, stdout.String())
+	require.Equal(t, "Removed 1 duplicate seed server(s)\n", stderr.String())
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 1, conf.Redpanda.ID)
+	require.Equal(t, []config.SeedServer{
+		{Host: config.SocketAddress{Address: "10.0.0.1", Port: 33145}},
+		{Host: config.SocketAddress{Address: "10.0.0.2", Port: 33145}},
+	}, conf.Redpanda.SeedServers)
+
+	written, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	c = normalizeSeeds(fs)
+	c.SetOut(&stdout)
+	c.SetArgs(nil)
+	require.NoError(t, c.Execute())
+	require.Equal(t, "seed servers already normalized\n", stdout.String())
+	b, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	require.Equal(t, string(written), string(b))
+}
+
+func TestNormalizeSeedsKeepIDs(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, path, []byte(`redpanda:
+  seed_servers:
+    - host:
+        address: 10.0.0.3
+        port: 33145
+    - host:
+        address: 10.0.0.3
+        port: 33145
+    - host:
+        address: 10.0.0.2
+        port: 33145
+    - host:
+        address: 10.0.0.1
+        port: 33145
+`), 0o644))
+
+	c := normalizeSeeds(fs)
+	c.SetErr(io.Discard)
+	c.SetArgs([]string{"--keep-ids"})
+	require.NoError(t, c.Execute())
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	// 10.0.0.1 is past the end once the duplicate is removed.
+	require.Equal(t, []config.SeedServer{
+		{Host: config.SocketAddress{Address: "10.0.0.3", Port: 33145}},
+		{Host: config.SocketAddress{Address: "10.0.0.1", Port: 33145}},
+		{Host: config.SocketAddress{Address: "10.0.0.2", Port: 33145}},
+	}, conf.Redpanda.SeedServers)
+}
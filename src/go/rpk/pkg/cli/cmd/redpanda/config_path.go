@@ -0,0 +1,77 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func showPath(fs afero.Fs) *cobra.Command {
+	var (
+		format     string
+		configPath string
+	)
+	c := &cobra.Command{
+		Use:   "path",
+		Short: "Print the location of the config file",
+		Long: `Print the location of the config file.
+
+This prints the absolute path of the config file that the other config
+commands read and write, resolved the same way they do: the --config flag or
+the REDPANDA_CONFIG environment variable if set, otherwise the first config
+file found in the default locations, otherwise /etc/redpanda/redpanda.yaml.
+
+If the file does not exist yet, it is the file that will be created on the
+next write, and a note is printed to stderr. With --format json, the path is
+printed along with whether it exists:
+
+  {"path":"/etc/redpanda/redpanda.yaml","exists":true}
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			if format != "text" && format != "json" {
+				out.Die("unsupported format %q, use text or json", format)
+			}
+			p, exists, err := config.ParamsFromCommand(cmd).ResolvePath(fs)
+			out.MaybeDie(err, "unable to resolve the config file path: %v", err)
+
+			if format == "json" {
+				b, err := json.Marshal(struct {
+					Path   string `json:"path"`
+					Exists bool   `json:"exists"`
+				}{p, exists})
+				out.MaybeDie(err, "unable to encode the config file path: %v", err)
+				fmt.Fprintln(cmd.OutOrStdout(), string(b))
+				return
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), p)
+			if !exists {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Note: %s does not exist yet, it will be created on write\n", p)
+			}
+		},
+	}
+	c.Flags().StringVar(&format, "format", "text", "Output format (text/json)")
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileFlagDesc,
+	)
+	return c
+}
@@ -0,0 +1,120 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPath(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	for _, test := range []struct {
+		name   string
+		files  []string
+		args   []string
+		env    string
+		exp    string
+		exists bool
+	}{
+		{
+			name:   "default location",
+			files:  []string{"/etc/redpanda/redpanda.yaml"},
+			exp:    "/etc/redpanda/redpanda.yaml",
+			exists: true,
+		},
+		{
+			name: "nothing found",
+			exp:  "/etc/redpanda/redpanda.yaml",
+		},
+		{
+			name:   "found in the current directory",
+			files:  []string{filepath.Join(cwd, "redpanda.yaml")},
+			exp:    filepath.Join(cwd, "redpanda.yaml"),
+			exists: true,
+		},
+		{
+			name:   "--config",
+			files:  []string{"/etc/redpanda/redpanda.yaml", "/tmp/rp.yaml"},
+			args:   []string{"--config", "/tmp/rp.yaml"},
+			exp:    "/tmp/rp.yaml",
+			exists: true,
+		},
+		{
+			name:  "relative --config not found",
+			files: []string{"/etc/redpanda/redpanda.yaml"},
+			args:  []string{"--config", "conf/rp.yaml"},
+			exp:   filepath.Join(cwd, "conf/rp.yaml"),
+		},
+		{
+			name:   "REDPANDA_CONFIG",
+			files:  []string{"/etc/redpanda/redpanda.yaml", "/tmp/env.yaml"},
+			env:    "/tmp/env.yaml",
+			exp:    "/tmp/env.yaml",
+			exists: true,
+		},
+		{
+			name:   "--config over REDPANDA_CONFIG",
+			files:  []string{"/tmp/env.yaml", "/tmp/rp.yaml"},
+			args:   []string{"--config", "/tmp/rp.yaml"},
+			env:    "/tmp/env.yaml",
+			exp:    "/tmp/rp.yaml",
+			exists: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if test.env != "" {
+				t.Setenv(config.EnvConfig, test.env)
+			}
+			fs := afero.NewMemMapFs()
+			for _, f := range test.files {
+				require.NoError(t, afero.WriteFile(fs, f, []byte("redpanda:\n  node_id: 1\n"), 0o644))
+			}
+
+			var stdout, stderr bytes.Buffer
+			c := showPath(fs)
+			c.SetOut(&stdout)
+			c.SetErr(&stderr)
+			c.SetArgs(test.args)
+			require.NoError(t, c.Execute())
+			require.Equal(t, test.exp+"\n", stdout.String())
+			require.Equal(t, !test.exists, strings.Contains(stderr.String(), "does not exist yet"))
+
+			var res struct {
+				Path   string `json:"path"`
+				Exists bool   `json:"exists"`
+			}
+			stdout.Reset()
+			c = showPath(fs)
+			c.SetOut(&stdout)
+			c.SetArgs(append(test.args, "--format", "json"))
+			require.NoError(t, c.Execute())
+			require.NoError(t, json.Unmarshal(stdout.Bytes(), &res))
+			require.Equal(t, test.exp, res.Path)
+			require.Equal(t, test.exists, res.Exists)
+
+			exists, err := afero.Exists(fs, test.exp)
+			require.NoError(t, err)
+			require.Equal(t, test.exists, exists, "path must not create the file")
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// The flags of the config commands that control how they report their
+// outcome, and the --output formats.
+const (
+	quietFlag  = "quiet"
+	outputFlag = "output"
+
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// printer reports the outcome of a config command, as --quiet and --output
+// request: as text, as a json object, or not at all. Errors are not reported
+// through it, they are always printed.
+type printer struct {
+	w      io.Writer
+	quiet  bool
+	output string
+}
+
+// newPrinter returns the printer of cmd, writing to its stdout. The flags are
+// honored whether cmd inherits them from the config command or defines them,
+// and defaulted if it has none.
+func newPrinter(cmd *cobra.Command) (*printer, error) {
+	p := &printer{w: cmd.OutOrStdout(), output: outputText}
+	if f := cmd.Flags().Lookup(quietFlag); f != nil {
+		p.quiet = f.Value.String() == "true"
+	}
+	if f := cmd.Flags().Lookup(outputFlag); f != nil && f.Value.Type() == "string" {
+		p.output = f.Value.String()
+	}
+	if p.output != outputText && p.output != outputJSON {
+		return nil, fmt.Errorf("invalid --%s %q, must be %s or %s", outputFlag, p.output, outputText, outputJSON)
+	}
+	return p, nil
+}
+
+// json returns whether the outcome is reported as json.
+func (p *printer) json() bool {
+	return p.output == outputJSON
+}
+
+// Messagef reports a message, as is or as {"message": "..."}.
+func (p *printer) Messagef(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	// A string always encodes.
+	_ = p.Result(struct {
+		Message string `json:"message"`
+	}{msg}, func(w io.Writer) { fmt.Fprintln(w, msg) })
+}
+
+// Result reports the result v of the command: encoded as json, or as text
+// writes it, if text is not nil.
+func (p *printer) Result(v interface{}, text func(io.Writer)) error {
+	switch {
+	case p.quiet:
+	case p.json():
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to encode the result: %v", err)
+		}
+		fmt.Fprintln(p.w, string(b))
+	case text != nil:
+		text(p.w)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func rollback(fs afero.Fs) *cobra.Command {
+	var (
+		from         string
+		backupSuffix string
+		lockTimeout  time.Duration
+		configPath   string
+	)
+	c := &cobra.Command{
+		Use:   "rollback",
+		Short: "Restore the config file from its backup",
+		Long: `Restore the config file from its backup.
+
+This restores the config file from the backup that the --backup flag of set,
+unset, edit and bootstrap writes, the config file path suffixed with .bak, or
+with --backup-suffix. Use --from to restore another file instead. The backup
+must be a valid config file.
+
+The config file being replaced is backed up in turn, to the same path as the
+backup, so that running rollback again undoes the rollback:
+
+  rpk redpanda config set redpanda.node_id 2 --backup
+  rpk redpanda config rollback   # back to the previous node_id
+  rpk redpanda config rollback   # node_id is 2 again
+
+rollback fails if there is no backup to restore.
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			p := config.ParamsFromCommand(cmd)
+			if p.ConfigPath == configStdio {
+				out.DieCode(exitInvalidInput, "rollback cannot be used with --config %s, which has no config file", configStdio)
+			}
+			unlock, err := lockConfig(fs, cmd, lockTimeout)
+			maybeDieCode(err, exitIO, "%v", err)
+			defer unlock()
+
+			path, _, err := p.ResolvePath(fs)
+			maybeDieCode(err, exitIO, "unable to resolve the config file path: %v", err)
+			if from == "" {
+				from = path + backupSuffix
+			}
+			replaced, err := config.RestoreBackup(fs, path, from, backupSuffix)
+			maybeDieLoad(err, "unable to roll back: %v", err)
+
+			err = pr.Result(rollbackResult{path, from, replaced}, func(w io.Writer) {
+				fmt.Fprintf(w, "Restored %s from %s.\n", path, from)
+				if replaced != "" {
+					fmt.Fprintf(w, "The replaced config file is backed up to %s.\n", replaced)
+				}
+			})
+			out.MaybeDieErr(err)
+		},
+	}
+	c.Flags().StringVar(&from, "from", "", "File to restore the config file from, instead of its backup")
+	c.Flags().StringVar(&backupSuffix, backupSuffixFlag, defaultBackupSuffix, "Suffix appended to the config file path to name its backup")
+	addLockTimeoutFlag(c, &lockTimeout)
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileFlagDesc,
+	)
+	return c
+}
+
+// rollbackResult is what rollback --output json prints once done.
+type rollbackResult struct {
+	Path     string `json:"path"`
+	From     string `json:"from"`
+	BackedUp string `json:"backed_up,omitempty"`
+}
@@ -0,0 +1,107 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollback(t *testing.T) {
+	const (
+		path = "/etc/redpanda/redpanda.yaml"
+		prev = "redpanda:\n  node_id: 1\n"
+		cur  = "redpanda:\n  node_id: 2\n"
+	)
+	run := func(t *testing.T, fs afero.Fs, args ...string) string {
+		var out bytes.Buffer
+		cmd := rollback(fs)
+		cmd.SetArgs(args)
+		cmd.SetOut(&out)
+		require.NoError(t, cmd.Execute())
+		return out.String()
+	}
+	read := func(t *testing.T, fs afero.Fs, name string) string {
+		b, err := afero.ReadFile(fs, name)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	t.Run("restores the backup", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, path, []byte(cur), 0o600))
+		require.NoError(t, afero.WriteFile(fs, path+".bak", []byte(prev), 0o644))
+
+		out := run(t, fs)
+		require.Contains(t, out, "Restored "+path+" from "+path+".bak.")
+		require.Contains(t, out, "backed up to "+path+".bak.")
+		require.Equal(t, prev, read(t, fs, path))
+		require.Equal(t, cur, read(t, fs, path+".bak"))
+		// The mode of the replaced config file is kept.
+		stat, err := fs.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o600), stat.Mode().Perm())
+
+		// Rolling back again undoes the rollback.
+		run(t, fs)
+		require.Equal(t, cur, read(t, fs, path))
+		require.Equal(t, prev, read(t, fs, path+".bak"))
+	})
+
+	t.Run("from and backup suffix", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, path, []byte(cur), 0o644))
+		require.NoError(t, afero.WriteFile(fs, "/tmp/good.yaml", []byte(prev), 0o644))
+
+		run(t, fs, "--from", "/tmp/good.yaml", "--backup-suffix", ".old")
+		require.Equal(t, prev, read(t, fs, path))
+		require.Equal(t, cur, read(t, fs, path+".old"))
+		require.Equal(t, prev, read(t, fs, "/tmp/good.yaml"))
+	})
+
+	t.Run("without a current config file", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, path+".bak", []byte(prev), 0o644))
+
+		out := run(t, fs)
+		require.NotContains(t, out, "backed up")
+		require.Equal(t, prev, read(t, fs, path))
+	})
+
+	t.Run("invalid backup", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, path, []byte(cur), 0o644))
+		require.NoError(t, afero.WriteFile(fs, path+".bak", []byte("redpanda: ["), 0o644))
+
+		_, err := config.RestoreBackup(fs, path, "", "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "backup "+path+".bak is not a valid config file")
+		require.Equal(t, cur, read(t, fs, path))
+	})
+
+	t.Run("no backup", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, path, []byte(cur), 0o644))
+
+		_, err := config.RestoreBackup(fs, path, "", "")
+		require.True(t, errors.Is(err, config.ErrConfigNotFound))
+		require.Contains(t, err.Error(), "no backup to restore")
+		require.Equal(t, cur, read(t, fs, path))
+	})
+}
@@ -0,0 +1,292 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func seeds(fs afero.Fs) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "seeds",
+		Short: "Add, remove or list the seed servers",
+		Long: `Add, remove or list the seed servers.
+
+The ID of a seed server is its index in redpanda.seed_servers. Adding and
+removing seed servers with these commands keeps the IDs of the other seed
+servers, rather than shifting them as editing the list does, except for the
+seed servers whose ID is past the end of the shortened list after a removal,
+which take the freed IDs.
+`,
+	}
+	c.AddCommand(
+		seedsAdd(fs),
+		seedsRemove(fs),
+		seedsList(fs),
+	)
+	return c
+}
+
+// seedEntry is a seed server along with its ID, as the seeds commands print
+// it.
+type seedEntry struct {
+	ID   int                  `json:"id"`
+	Host config.SocketAddress `json:"host"`
+}
+
+func (e seedEntry) String() string {
+	return net.JoinHostPort(e.Host.Address, strconv.Itoa(e.Host.Port))
+}
+
+// seedsResult is the outcome of seeds add and seeds remove.
+type seedsResult struct {
+	Added   []seedEntry `json:"added,omitempty"`
+	Removed []seedEntry `json:"removed,omitempty"`
+}
+
+func seedEntries(seeds []config.SeedServer) []seedEntry {
+	entries := make([]seedEntry, 0, len(seeds))
+	for i, s := range seeds {
+		entries = append(entries, seedEntry{ID: i, Host: s.Host})
+	}
+	return entries
+}
+
+func seedsAdd(fs afero.Fs) *cobra.Command {
+	var (
+		port         int
+		configPath   string
+		lockTimeout  time.Duration
+		backup       bool
+		backupSuffix string
+	)
+	c := &cobra.Command{
+		Use:   "add <host[:port]>...",
+		Short: "Add seed servers",
+		Long: `Add seed servers.
+
+Each seed server is an IP or hostname, optionally followed by a port, as in
+bootstrap --ips; the port defaults to --port. The seed servers are appended to
+the list, taking the next IDs. The ones already listed are left as is, so
+adding the same seed server again is a no-op.
+`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			err = checkPortFlag("port", port)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			add, err := config.ParseSeedServers(args, port)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+
+			unlock, err := lockConfig(fs, cmd, lockTimeout)
+			maybeDieCode(err, exitIO, "%v", err)
+			defer unlock()
+
+			cfg, err := loadConfig(fs, cmd)
+			maybeDieLoad(err, "unable to load config: %v", err)
+			current := cfg.Redpanda.SeedServers
+			cfg.Redpanda.SeedServers = config.AssignSeedIDs(current, append(append([]config.SeedServer{}, current...), add...))
+
+			var res seedsResult
+			for _, e := range seedEntries(cfg.Redpanda.SeedServers) {
+				if !hasSeed(current, e.Host) {
+					res.Added = append(res.Added, e)
+				}
+			}
+			writeSeeds(fs, cmd, pr, cfg, res, backup, backupSuffix)
+		},
+	}
+	c.Flags().IntVar(&port, "port", config.Default().Redpanda.RPCServer.Port, "RPC port of the seed servers that have no port")
+	addSeedsConfigFlags(c, &configPath, &lockTimeout, &backup, &backupSuffix)
+	return c
+}
+
+func seedsRemove(fs afero.Fs) *cobra.Command {
+	var (
+		configPath   string
+		lockTimeout  time.Duration
+		backup       bool
+		backupSuffix string
+	)
+	c := &cobra.Command{
+		Use:   "remove <host[:port]|id>...",
+		Short: "Remove seed servers",
+		Long: `Remove seed servers.
+
+Each seed server to remove is either its ID, as printed by seeds list, or its
+host. A host with no port removes the seed servers of that host on any port.
+It fails, removing nothing, if any of them is not a seed server.
+`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			unlock, err := lockConfig(fs, cmd, lockTimeout)
+			maybeDieCode(err, exitIO, "%v", err)
+			defer unlock()
+
+			cfg, err := loadConfig(fs, cmd)
+			maybeDieLoad(err, "unable to load config: %v", err)
+			current := cfg.Redpanda.SeedServers
+			removed := make(map[int]bool)
+			for _, arg := range args {
+				ids, err := matchSeeds(current, arg)
+				maybeDieCode(err, exitInvalidInput, "%v", err)
+				for _, id := range ids {
+					removed[id] = true
+				}
+			}
+
+			var (
+				res  seedsResult
+				kept []config.SeedServer
+			)
+			for _, e := range seedEntries(current) {
+				if removed[e.ID] {
+					res.Removed = append(res.Removed, e)
+					continue
+				}
+				kept = append(kept, config.SeedServer{Host: e.Host})
+			}
+			cfg.Redpanda.SeedServers = config.AssignSeedIDs(current, kept)
+			writeSeeds(fs, cmd, pr, cfg, res, backup, backupSuffix)
+		},
+	}
+	addSeedsConfigFlags(c, &configPath, &lockTimeout, &backup, &backupSuffix)
+	return c
+}
+
+func seedsList(fs afero.Fs) *cobra.Command {
+	var configPath string
+	c := &cobra.Command{
+		Use:   "list",
+		Short: "List the seed servers and their IDs",
+		Long: `List the seed servers and their IDs.
+
+With --output json, the seed servers are printed as a json list of objects
+holding their id and host.
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			cfg, err := loadConfig(fs, cmd)
+			maybeDieLoad(err, "unable to load config: %v", err)
+
+			entries := seedEntries(cfg.Redpanda.SeedServers)
+			err = pr.Result(entries, func(w io.Writer) {
+				tw := out.NewTableTo(w, "id", "host")
+				defer tw.Flush()
+				for _, e := range entries {
+					tw.Print(e.ID, e)
+				}
+			})
+			out.MaybeDieErr(err)
+		},
+	}
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileFlagDesc+`, or "-" to read it from stdin`,
+	)
+	addConfigFormatFlag(c)
+	return c
+}
+
+func addSeedsConfigFlags(c *cobra.Command, configPath *string, lockTimeout *time.Duration, backup *bool, backupSuffix *string) {
+	c.Flags().StringVar(
+		configPath,
+		configFileFlag,
+		"",
+		configFileStdioDesc,
+	)
+	addConfigFormatFlag(c)
+	addLockTimeoutFlag(c, lockTimeout)
+	addBackupFlags(c, backup, backupSuffix)
+}
+
+func hasSeed(seeds []config.SeedServer, host config.SocketAddress) bool {
+	for _, s := range seeds {
+		if s.Host == host {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSeeds returns the IDs of the seeds that arg, an ID or a host with an
+// optional port, designates.
+func matchSeeds(seeds []config.SeedServer, arg string) ([]int, error) {
+	if id, err := strconv.Atoi(arg); err == nil {
+		if id < 0 || id >= len(seeds) {
+			return nil, fmt.Errorf("no seed server has ID %d", id)
+		}
+		return []int{id}, nil
+	}
+	address, port := arg, 0
+	if net.ParseIP(arg) == nil {
+		if host, p, err := net.SplitHostPort(arg); err == nil {
+			if port, err = strconv.Atoi(p); err != nil {
+				return nil, fmt.Errorf("invalid seed server %q: %v", arg, err)
+			}
+			address = host
+		}
+	}
+	var ids []int
+	for i, s := range seeds {
+		if s.Host.Address == address && (port == 0 || s.Host.Port == port) {
+			ids = append(ids, i)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%q is not a seed server", arg)
+	}
+	return ids, nil
+}
+
+// writeSeeds writes cfg and reports the seed servers that were added or
+// removed, unless there are none, in which case the config file is left
+// untouched.
+func writeSeeds(fs afero.Fs, cmd *cobra.Command, pr *printer, cfg *config.Config, res seedsResult, backup bool, backupSuffix string) {
+	stdio := config.ParamsFromCommand(cmd).ConfigPath == configStdio
+	if stdio {
+		// Stdout is the configuration itself.
+		pr.w = cmd.ErrOrStderr()
+	}
+	if cfg.File() != nil && !stdio && len(res.Added) == 0 && len(res.Removed) == 0 {
+		pr.Messagef("no change")
+		return
+	}
+	err := writeConfig(fs, cmd, cfg, backup, backupSuffix)
+	maybeDieCode(err, exitIO, "%v", err)
+	err = pr.Result(res, func(w io.Writer) {
+		for _, e := range res.Added {
+			fmt.Fprintf(w, "Added seed server %s with ID %d\n", e, e.ID)
+		}
+		for _, e := range res.Removed {
+			fmt.Fprintf(w, "Removed seed server %s, which had ID %d\n", e, e.ID)
+		}
+	})
+	out.MaybeDieErr(err)
+}
@@ -0,0 +1,86 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSeeds(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	run := func(args ...string) string {
+		var stdout bytes.Buffer
+		c := NewConfigCommand(fs)
+		c.SetOut(&stdout)
+		c.SetArgs(append([]string{"seeds"}, args...))
+		require.NoError(t, c.Execute())
+		return stdout.String()
+	}
+	loadSeeds := func() []config.SeedServer {
+		conf, err := new(config.Params).Load(fs)
+		require.NoError(t, err)
+		return conf.Redpanda.SeedServers
+	}
+	seed := func(address string, port int) config.SeedServer {
+		return config.SeedServer{Host: config.SocketAddress{Address: address, Port: port}}
+	}
+
+	require.Equal(t, "Added seed server 10.0.0.1:33145 with ID 0\nAdded seed server 10.0.0.2:33146 with ID 1\n",
+		run("add", "10.0.0.1", "10.0.0.2:33146"))
+	// Already listed hosts are left as is.
+	require.Equal(t, "Added seed server seed-3:33145 with ID 2\n", run("add", "10.0.0.1:33145", "seed-3", "10.0.0.2:33146"))
+	require.Equal(t, "no change\n", run("add", "10.0.0.1"))
+	run("add", "10.0.0.4", "10.0.0.5")
+	require.Equal(t, []config.SeedServer{
+		seed("10.0.0.1", 33145),
+		seed("10.0.0.2", 33146),
+		seed("seed-3", 33145),
+		seed("10.0.0.4", 33145),
+		seed("10.0.0.5", 33145),
+	}, loadSeeds())
+
+	require.Equal(t, `ID    HOST
+0     10.0.0.1:33145
+1     10.0.0.2:33146
+2     seed-3:33145
+3     10.0.0.4:33145
+4     10.0.0.5:33145
+`, run("list"))
+
+	// By ID: the last seed takes the freed ID, the others keep theirs.
+	require.Equal(t, "Removed seed server 10.0.0.2:33146, which had ID 1\n", run("remove", "1"))
+	require.Equal(t, []config.SeedServer{
+		seed("10.0.0.1", 33145),
+		seed("10.0.0.5", 33145),
+		seed("seed-3", 33145),
+		seed("10.0.0.4", 33145),
+	}, loadSeeds())
+
+	// By host, with or without its port.
+	run("remove", "seed-3", "10.0.0.4:33145")
+	require.Equal(t, []config.SeedServer{
+		seed("10.0.0.1", 33145),
+		seed("10.0.0.5", 33145),
+	}, loadSeeds())
+
+	require.JSONEq(t, `[
+  {"id": 0, "host": {"address": "10.0.0.1", "port": 33145}},
+  {"id": 1, "host": {"address": "10.0.0.5", "port": 33145}}
+]`, run("list", "--output", "json"))
+	require.JSONEq(t, `{"removed": [{"id": 1, "host": {"address": "10.0.0.5", "port": 33145}}]}`, run("remove", "10.0.0.5", "--output", "json"))
+}
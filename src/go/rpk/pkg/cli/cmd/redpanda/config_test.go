@@ -13,11 +13,23 @@
 package redpanda
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
 	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
 )
@@ -29,6 +41,11 @@ func TestBootstrap(t *testing.T) {
 		ips            []string
 		expSeedServers []config.SeedServer
 		self           string
+		addrs          []net.Addr
+		iface          string
+		ifaces         map[string][]net.Addr
+		family         string
+		expSelf        string
 		id             string
 		expectedErr    string
 	}{
@@ -37,6 +54,68 @@ func TestBootstrap(t *testing.T) {
 			id:   "1",
 			self: "192.168.34.5",
 		},
+		{
+			name:    "it should pick the address of the --interface-family on a dual-stack host",
+			id:      "1",
+			addrs:   []net.Addr{testIPNet(t, "10.0.0.4/8"), testIPNet(t, "2001:db8::4/64")},
+			family:  "ipv6",
+			expSelf: "2001:db8::4",
+		},
+		{
+			name: "it should fill the seed servers with IPv6 addresses",
+			ips:  []string{"fe80::1", "[fd00::2]", "[fd00::3]:33146"},
+			expSeedServers: []config.SeedServer{
+				{
+					Host: config.SocketAddress{
+						Address: "fe80::1",
+						Port:    defaultRPCPort,
+					},
+				},
+				{
+					Host: config.SocketAddress{
+						Address: "fd00::2",
+						Port:    defaultRPCPort,
+					},
+				},
+				{
+					Host: config.SocketAddress{
+						Address: "fd00::3",
+						Port:    33146,
+					},
+				},
+			},
+			self: "fd00::2",
+			id:   "1",
+		},
+		{
+			name: "it should fill the seed servers mixing bare IPs and host:port pairs",
+			ips:  []string{"10.0.0.1:33146", "10.0.0.2", "[fd00::3]:33147", "redpanda-3.local:33148"},
+			expSeedServers: []config.SeedServer{
+				{Host: config.SocketAddress{Address: "10.0.0.1", Port: 33146}},
+				{Host: config.SocketAddress{Address: "10.0.0.2", Port: defaultRPCPort}},
+				{Host: config.SocketAddress{Address: "fd00::3", Port: 33147}},
+				{Host: config.SocketAddress{Address: "redpanda-3.local", Port: 33148}},
+			},
+			self: "10.0.0.2",
+			id:   "1",
+		},
+		{
+			name:    "it should detect this node's IP if --self is missing",
+			id:      "1",
+			addrs:   []net.Addr{testIPNet(t, "127.0.0.1/8"), testIPNet(t, "10.0.0.3/8")},
+			expSelf: "10.0.0.3",
+		},
+		{
+			name:  "it should use the address of the given interface",
+			id:    "1",
+			addrs: []net.Addr{testIPNet(t, "10.0.0.3/8"), testIPNet(t, "10.0.0.4/8")},
+			iface: "eth1",
+			ifaces: map[string][]net.Addr{
+				"eth0": {testIPNet(t, "10.0.0.3/8")},
+				"eth1": {testIPNet(t, "fe80::4/64"), testIPNet(t, "34.1.2.3/24")},
+			},
+			expSelf: "34.1.2.3",
+		},
 		{
 			name: "it should fill the seed servers",
 			ips:  []string{"187.89.76.3", "192.168.34.5", "192.168.45.8"},
@@ -101,12 +180,46 @@ func TestBootstrap(t *testing.T) {
 			self: "192.168.34.5",
 			id:   "1",
 		},
+		{
+			name: "it should keep a --self hostname as is",
+			ips:  []string{"redpanda-0.local", "redpanda-1.local"},
+			expSeedServers: []config.SeedServer{
+				{
+					Host: config.SocketAddress{
+						Address: "redpanda-0.local",
+						Port:    defaultRPCPort,
+					},
+				},
+				{
+					Host: config.SocketAddress{
+						Address: "redpanda-1.local",
+						Port:    defaultRPCPort,
+					},
+				},
+			},
+			self: "redpanda-0.local",
+			id:   "1",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			fs := afero.NewMemMapFs()
-			c := bootstrap(fs)
+			c := newBootstrapCommand(fs, configStore, func(iface string) ([]net.Addr, error) {
+				if iface == "" {
+					// --self has to be among the machine's
+					// addresses.
+					if tt.addrs == nil && tt.self != "" {
+						return []net.Addr{&net.IPNet{IP: net.ParseIP(tt.self)}}, nil
+					}
+					return tt.addrs, nil
+				}
+				addrs, ok := tt.ifaces[iface]
+				if !ok {
+					return nil, fmt.Errorf("no such interface %q", iface)
+				}
+				return addrs, nil
+			}, nil, nil)
 			var args []string
 			if len(tt.ips) != 0 {
 				args = append(
@@ -118,6 +231,12 @@ func TestBootstrap(t *testing.T) {
 			if tt.self != "" {
 				args = append(args, "--self", tt.self)
 			}
+			if tt.iface != "" {
+				args = append(args, "--interface", tt.iface)
+			}
+			if tt.family != "" {
+				args = append(args, "--interface-family", tt.family)
+			}
 			if tt.id != "" {
 				args = append(args, "--id", tt.id)
 			}
@@ -133,9 +252,13 @@ func TestBootstrap(t *testing.T) {
 			conf, err := new(config.Params).Load(fs)
 			require.NoError(t, err)
 
-			require.Equal(t, tt.self, conf.Redpanda.RPCServer.Address)
-			require.Equal(t, tt.self, conf.Redpanda.KafkaAPI[0].Address)
-			require.Equal(t, tt.self, conf.Redpanda.AdminAPI[0].Address)
+			expSelf := tt.self
+			if tt.expSelf != "" {
+				expSelf = tt.expSelf
+			}
+			require.Equal(t, expSelf, conf.Redpanda.RPCServer.Address)
+			require.Equal(t, expSelf, conf.Redpanda.KafkaAPI[0].Address)
+			require.Equal(t, expSelf, conf.Redpanda.AdminAPI[0].Address)
 			if len(tt.ips) == 1 {
 				require.Equal(
 					t,
@@ -149,38 +272,2167 @@ func TestBootstrap(t *testing.T) {
 	}
 }
 
-func TestInitNode(t *testing.T) {
+func TestParseSetArgs(t *testing.T) {
 	for _, test := range []struct {
 		name   string
-		prevID string
+		args   []string
+		exp    [][2]string
+		expErr bool
 	}{
-		{name: "without UUID"},
-		{name: "with UUID", prevID: "my_id"},
+		{
+			name: "key and value",
+			args: []string{"redpanda.node_id", "1"},
+			exp:  [][2]string{{"redpanda.node_id", "1"}},
+		},
+		{
+			name: "key and value containing an equal sign",
+			args: []string{"redpanda.rack", "a=b"},
+			exp:  [][2]string{{"redpanda.rack", "a=b"}},
+		},
+		{
+			name: "single pair",
+			args: []string{"redpanda.node_id=1"},
+			exp:  [][2]string{{"redpanda.node_id", "1"}},
+		},
+		{
+			name: "many pairs",
+			args: []string{"redpanda.node_id=1", "redpanda.rpc_server={address: 0.0.0.0, port: 33146}", "redpanda.rack="},
+			exp: [][2]string{
+				{"redpanda.node_id", "1"},
+				{"redpanda.rpc_server", "{address: 0.0.0.0, port: 33146}"},
+				{"redpanda.rack", ""},
+			},
+		},
+		{
+			name:   "single key without value",
+			args:   []string{"redpanda.node_id"},
+			expErr: true,
+		},
+		{
+			name:   "pairs mixed with a bare key",
+			args:   []string{"redpanda.node_id=1", "redpanda.rack", "r1"},
+			expErr: true,
+		},
+		{
+			name:   "empty key",
+			args:   []string{"=1"},
+			expErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			kvs, err := parseSetArgs(test.args)
+			if test.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.exp, kvs)
+		})
+	}
+}
+
+func TestSetMany(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	c := set(fs)
+	c.SetArgs([]string{
+		"redpanda.node_id=3",
+		"redpanda.rpc_server.port=33146",
+		"rpk.tune_network=true",
+	})
+	require.NoError(t, c.Execute())
+
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 3, conf.Redpanda.ID)
+	require.Equal(t, 33146, conf.Redpanda.RPCServer.Port)
+	require.True(t, conf.Rpk.TuneNetwork)
+}
+
+func TestSetFromFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/rpc.json", []byte(`{
+  "address": "10.0.0.1",
+  "port": 33146
+}`), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/rack.txt", []byte("rack-1\n"), 0o644))
+
+	c := set(fs)
+	c.SetArgs([]string{"redpanda.rpc_server", "--from-file", "/rpc.json", "--format", "json"})
+	require.NoError(t, c.Execute())
+	c = set(fs)
+	c.SetArgs([]string{"redpanda.rack", "--from-file", "/rack.txt"})
+	require.NoError(t, c.Execute())
+
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, config.SocketAddress{Address: "10.0.0.1", Port: 33146}, conf.Redpanda.RPCServer)
+	require.Equal(t, "rack-1", conf.Redpanda.Rack)
+
+	for _, args := range [][]string{
+		{"redpanda.rack", "rack-2"},
+		{"redpanda.rack=rack-2"},
+		{"redpanda.rack", "redpanda.node_id"},
+	} {
+		_, err := readSetArgs(fs, args, "/rack.txt", nil)
+		require.EqualError(t, err, "--from-file requires a single key, and no value")
+	}
+	_, err = readSetArgs(fs, []string{"redpanda.rack"}, "/missing.txt", nil)
+	require.Error(t, err)
+}
+
+func TestSetConcurrent(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	var wg sync.WaitGroup
+	for _, arg := range []string{"redpanda.node_id=1", "redpanda.rack=rack-1"} {
+		wg.Add(1)
+		go func(arg string) {
+			defer wg.Done()
+			c := set(fs)
+			c.SetArgs([]string{arg, "--lock-timeout", "10s"})
+			require.NoError(t, c.Execute())
+		}(arg)
+	}
+	wg.Wait()
+
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 1, conf.Redpanda.ID)
+	require.Equal(t, "rack-1", conf.Redpanda.Rack)
+}
+
+func TestSetStdin(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	c := set(fs)
+	c.SetIn(strings.NewReader(`
+address: 10.0.0.1
+port: 33146
+`))
+	c.SetArgs([]string{"redpanda.rpc_server", "--stdin"})
+	require.NoError(t, c.Execute())
+
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, config.SocketAddress{Address: "10.0.0.1", Port: 33146}, conf.Redpanda.RPCServer)
+
+	for _, test := range []struct {
+		args     []string
+		fromFile string
+		exp      string
+	}{
+		{[]string{"redpanda.rpc_server", "{}"}, "", "--stdin requires a single key, and no value"},
+		{[]string{"redpanda.rpc_server={}"}, "", "--stdin requires a single key, and no value"},
+		{[]string{"redpanda.rpc_server"}, "/rpc.yaml", "--from-file and --stdin cannot be used together"},
+	} {
+		_, err := readSetArgs(fs, test.args, test.fromFile, strings.NewReader("{}"))
+		require.EqualError(t, err, test.exp)
+	}
+}
+
+func TestSetFormatEnv(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte("redpanda:\n  node_id: 1\n  rack: r0\n"), 0o644))
+	c := set(fs)
+	c.SetIn(strings.NewReader(`# Node overrides.
+REDPANDA__NODE_ID=2
+REDPANDA__RPC_SERVER__ADDRESS="10.0.0.1"
+
+export REDPANDA__RPC_SERVER__PORT=33146
+REDPANDA__SEED_SERVERS__0__HOST__ADDRESS=10.0.0.2
+REDPANDA__SEED_SERVERS__0__HOST__PORT=33145
+RPK__TUNE_NETWORK=true
+`))
+	c.SetArgs([]string{"--format", "env", "--stdin"})
+	require.NoError(t, c.Execute())
+
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 2, conf.Redpanda.ID)
+	require.Equal(t, "r0", conf.Redpanda.Rack)
+	require.Equal(t, config.SocketAddress{Address: "10.0.0.1", Port: 33146}, conf.Redpanda.RPCServer)
+	require.Equal(t, []config.SeedServer{{Host: config.SocketAddress{Address: "10.0.0.2", Port: 33145}}}, conf.Redpanda.SeedServers)
+	require.True(t, conf.Rpk.TuneNetwork)
+
+	for _, test := range []struct {
+		fromFile string
+		stdin    io.Reader
+		exp      string
+	}{
+		{"", nil, "--format env requires --from-file or --stdin"},
+		{"/env", strings.NewReader(""), "--from-file and --stdin cannot be used together"},
+		{"", strings.NewReader("REDPANDA__NODE_ID"), `line 1: "REDPANDA__NODE_ID" is not a KEY=VALUE pair`},
+	} {
+		_, err := readEnvSetArgs(fs, test.fromFile, test.stdin)
+		require.EqualError(t, err, test.exp)
+	}
+}
+
+func TestSetPatchMerge(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, path, []byte(`redpanda:
+  node_id: 1
+  rack: r0
+  rpc_server:
+    address: 10.0.0.1
+    port: 33145
+  seed_servers:
+    - host:
+        address: 10.0.0.1
+        port: 33145
+    - host:
+        address: 10.0.0.2
+        port: 33145
+  tunables:
+    a: 1
+    b: 2
+`), 0o644))
+	run := func(patch string) string {
+		var out bytes.Buffer
+		c := set(fs)
+		c.SetOut(&out)
+		c.SetIn(strings.NewReader(patch))
+		c.SetArgs([]string{"--patch-type", "merge", "--stdin"})
+		require.NoError(t, c.Execute())
+		return out.String()
+	}
+
+	require.Equal(t, `redpanda.node_id: 1 -> 2
+redpanda.rack: r0 -> <unset>
+redpanda.rpc_server.port: 33145 -> 33146
+redpanda.seed_servers.0.host.address: 10.0.0.1 -> 10.0.0.3
+redpanda.seed_servers.1.host.address: 10.0.0.2 -> <unset>
+redpanda.seed_servers.1.host.port: 33145 -> <unset>
+redpanda.tunables.a: 1 -> <unset>
+redpanda.tunables.c: <unset> -> 3
+`, run(`{
+  "redpanda": {
+    "node_id": 2,
+    "rack": null,
+    "rpc_server": {"port": 33146},
+    "seed_servers": [{"host": {"address": "10.0.0.3", "port": 33145}}],
+    "tunables": {"a": null, "c": 3}
+  }
+}`))
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 2, conf.Redpanda.ID)
+	require.Empty(t, conf.Redpanda.Rack)
+	require.Equal(t, config.SocketAddress{Address: "10.0.0.1", Port: 33146}, conf.Redpanda.RPCServer)
+	require.Equal(t, []config.SeedServer{{Host: config.SocketAddress{Address: "10.0.0.3", Port: 33145}}}, conf.Redpanda.SeedServers)
+	require.Equal(t, map[string]interface{}{"b": 2, "c": 3}, conf.Redpanda.Other["tunables"])
+
+	require.Equal(t, "no change\n", run(`{"redpanda": {"node_id": 2, "absent": null}}`))
+
+	_, err = readSetInput(fs, "", nil, "the patch", "--patch-type merge")
+	require.EqualError(t, err, "--patch-type merge requires --from-file or --stdin")
+}
+
+func TestSetPatchJSON(t *testing.T) {
+	const (
+		path = "/etc/redpanda/redpanda.yaml"
+		orig = "redpanda:\n  node_id: 1\n  rack: r0\n"
+	)
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, path, []byte(orig), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/patch.json", []byte(`[
+  {"op": "test", "path": "/redpanda/node_id", "value": 1},
+  {"op": "replace", "path": "/redpanda/node_id", "value": 2},
+  {"op": "add", "path": "/redpanda/seed_servers/-", "value": {"host": {"address": "10.0.0.3", "port": 33145}}},
+  {"op": "remove", "path": "redpanda.rack"}
+]`), 0o644))
+	c := set(fs)
+	c.SetArgs([]string{"--patch-type", "json", "--from-file", "/patch.json"})
+	require.NoError(t, c.Execute())
+
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 2, conf.Redpanda.ID)
+	require.Empty(t, conf.Redpanda.Rack)
+	require.Equal(t, []config.SeedServer{{Host: config.SocketAddress{Address: "10.0.0.3", Port: 33145}}}, conf.Redpanda.SeedServers)
+
+	// The test of node_id 1 now fails, so that nothing is set.
+	cfg, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	b, err := afero.ReadFile(fs, "/patch.json")
+	require.NoError(t, err)
+	err = applySetPatch(cfg, setPatchJSON, b)
+	require.ErrorIs(t, err, config.ErrPatchTestFailed)
+	require.Equal(t, exitConflict, configExitCode(err, 1))
+	require.Equal(t, conf, cfg)
+}
+
+func TestConfigQuietOutput(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	fs := afero.NewMemMapFs()
+	run := func(args ...string) string {
+		var stdout bytes.Buffer
+		c := NewConfigCommand(fs)
+		c.SetOut(&stdout)
+		c.SetErr(io.Discard)
+		c.SetArgs(args)
+		require.NoError(t, c.Execute())
+		return stdout.String()
+	}
+
+	require.Equal(t, "", run("generate", "--quiet"))
+	require.Equal(t, "", run("set", "redpanda.node_id", "1", "-q"))
+	require.Equal(t, "", run("--quiet", "validate"))
+	require.Equal(t, `Configuration "`+path+`" is valid.`+"\n", run("validate"))
+
+	require.JSONEq(t, `{"changed": [
+  {"key": "redpanda.node_id", "old": 1, "new": 2},
+  {"key": "redpanda.rack", "old": null, "new": "r1"}
+]}`, run("set", "redpanda.node_id=2", "redpanda.rack=r1", "--backup", "--output", "json"))
+	require.JSONEq(t, `{"changed": []}`, run("set", "redpanda.node_id=2", "--output", "json"))
+	require.JSONEq(t, `{"message": "Configuration \"`+path+`\" is valid."}`, run("validate", "--output", "json"))
+	require.JSONEq(t, `{"diffs": [
+  {"key": "redpanda.node_id", "old": 1, "new": 2},
+  {"key": "redpanda.rack", "old": null, "new": "r1"}
+]}`, run("diff", "--against", path+".bak", "--output", "json"))
+	require.JSONEq(t, `{
+  "path": "`+path+`",
+  "from": "`+path+`.bak",
+  "backed_up": "`+path+`.bak"
+}`, run("rollback", "--output", "json"))
+	require.JSONEq(t, `{"path": "`+path+`", "applied": []}`, run("migrate", "--output", "json"))
+	require.Equal(t, "", run("rollback", "-q"))
+
+	require.Equal(t, "", run("unset", "redpanda.rack", "-q"))
+	require.JSONEq(t, `{"changed": [{"key": "redpanda.node_id", "old": 2, "new": 0}]}`, run("unset", "redpanda.node_id", "--output", "json"))
+	require.JSONEq(t, `{"changed": []}`, run("unset", "redpanda.node_id", "--output", "json"))
+	require.JSONEq(t, `{"key": "redpanda.rpc_server.port", "value": 33145, "default": 33145}`, run("get", "redpanda.rpc_server.port", "--default", "--output", "json"))
+	require.JSONEq(t, `[
+  {"key": "redpanda.rpc_server.address", "value": "0.0.0.0"},
+  {"key": "redpanda.rpc_server.port", "value": 33145}
+]`, run("get", "redpanda.rpc_server.*", "--output", "json"))
+	require.Equal(t, "", run("get", "redpanda.rpc_server.port", "--quiet"))
+	require.NoError(t, afero.WriteFile(fs, "/fragment.yaml", []byte("redpanda:\n  rack: r2\n"), 0o644))
+	require.JSONEq(t, `{"changed": [{"key": "redpanda.rack", "old": null, "new": "r2"}]}`, run("import", "/fragment.yaml", "--output", "json"))
+	require.Equal(t, "", run("import", "/fragment.yaml", "-q"))
+
+	// bootstrap prints its summary with the same --output.
+	out := run("--output", "json", "bootstrap", "--id", "3", "--self", "10.0.0.1", "--force-self")
+	require.Contains(t, out, `"id": 3`)
+}
+
+func TestSetNoChange(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	// Indented with two spaces, which any write reformats.
+	const orig = `redpanda:
+  node_id: 1
+  seed_servers: []
+`
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, path, []byte(orig), 0o644))
+	run := func(args ...string) string {
+		var out bytes.Buffer
+		c := set(fs)
+		c.SetOut(&out)
+		c.SetArgs(args)
+		require.NoError(t, c.Execute())
+		return out.String()
+	}
+
+	require.Equal(t, "no change\n", run("redpanda.node_id", "1"))
+	require.Equal(t, "no change\n", run("redpanda.node_id=1", "redpanda.seed_servers=[]"))
+	b, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	require.Equal(t, orig, string(b), "config file was written")
+
+	require.Empty(t, run("redpanda.node_id", "1", "--force"))
+	b, err = afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	require.NotEqual(t, orig, string(b), "config file was not written")
+
+	require.Equal(t, "redpanda.node_id: 1 -> 2\n", run("redpanda.node_id", "2"))
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 2, conf.Redpanda.ID)
+}
+
+func TestSetSummary(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, path, []byte("redpanda:\n  node_id: 1\n  rpc_server:\n    address: 0.0.0.0\n    port: 33145\n"), 0o644))
+	run := func(args ...string) (string, string) {
+		var stdout, stderr bytes.Buffer
+		c := NewConfigCommand(fs)
+		c.SetOut(&stdout)
+		c.SetErr(&stderr)
+		c.SetIn(strings.NewReader("redpanda:\n  node_id: 5\n"))
+		c.SetArgs(append([]string{"set"}, args...))
+		require.NoError(t, c.Execute())
+		return stdout.String(), stderr.String()
+	}
+
+	stdout, _ := run("redpanda.rpc_server.port", "33146")
+	require.Equal(t, "redpanda.rpc_server.port: 33145 -> 33146\n", stdout)
+
+	stdout, _ = run("redpanda.rack=r1", "redpanda.node_id=2")
+	require.Equal(t, "redpanda.node_id: 1 -> 2\nredpanda.rack: <unset> -> r1\n", stdout)
+
+	stdout, _ = run("redpanda.node_id", "3", "--quiet")
+	require.Empty(t, stdout)
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 3, conf.Redpanda.ID)
+
+	// With --config -, stdout is the configuration.
+	stdout, stderr := run("redpanda.node_id", "6", "--config", "-")
+	require.Contains(t, stdout, "node_id: 6")
+	require.Equal(t, "redpanda.node_id: 5 -> 6\n", stderr)
+}
+
+func TestSetIfMatch(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, path, []byte("redpanda:\n  node_id: 1\n"), 0o644))
+	viewHash := func() string {
+		var out bytes.Buffer
+		c := view(fs)
+		c.SetOut(&out)
+		c.SetArgs([]string{"--hash"})
+		require.NoError(t, c.Execute())
+		return strings.TrimSuffix(out.String(), "\n")
+	}
+
+	hash := viewHash()
+	// The sha256 of the file content.
+	require.Equal(t, "59479cae530c26c70ddb7d8f73c6349f29f4641250e4e56f66c0dfa8cd2dc18e", hash)
+
+	c := set(fs)
+	c.SetArgs([]string{"redpanda.node_id", "2", "--if-match", hash})
+	require.NoError(t, c.Execute())
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 2, conf.Redpanda.ID)
+
+	// The write changed the file, so the hash read before no longer
+	// matches; the rejected set is checked in TestExitCodes.
+	require.NotEqual(t, hash, viewHash())
+	require.Error(t, conf.CheckFileHash(fs, hash))
+	require.NoError(t, conf.CheckFileHash(fs, viewHash()))
+}
+
+func TestDeleteEmpty(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	const file = `redpanda:
+  node_id: 1
+  tunables:
+    raft:
+      timeout: 10
+`
+	for _, test := range []struct {
+		name string
+		cmd  func(afero.Fs) *cobra.Command
+		args []string
+	}{
+		{"unset", unset, []string{"redpanda.tunables.raft.timeout"}},
+		{"set", set, []string{"redpanda.tunables", "{}", "--allow-extra"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			for _, deleteEmpty := range []bool{false, true} {
+				fs := afero.NewMemMapFs()
+				require.NoError(t, afero.WriteFile(fs, path, []byte(file), 0o644))
+				c := test.cmd(fs)
+				args := test.args
+				if deleteEmpty {
+					args = append(args, "--delete-empty")
+				}
+				c.SetArgs(args)
+				require.NoError(t, c.Execute())
+
+				conf, err := new(config.Params).Load(fs)
+				require.NoError(t, err)
+				require.Equal(t, 1, conf.Redpanda.ID)
+				_, kept := conf.Redpanda.Other["tunables"]
+				require.Equal(t, !deleteEmpty, kept, "--delete-empty=%v", deleteEmpty)
+			}
+		})
+	}
+}
+
+func TestSetWaitForFile(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	fs := afero.NewMemMapFs()
+
+	// The file is written by another component while set waits.
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		time.Sleep(100 * time.Millisecond)
+		// Atomically, for set not to read a partial file.
+		afero.WriteFile(fs, path+".tmp", []byte("redpanda:\n  node_id: 1\n  rack: r1\n"), 0o644)
+		fs.Rename(path+".tmp", path)
+	}()
+	c := set(fs)
+	c.SetArgs([]string{"redpanda.node_id", "2", "--wait-for-file", "10s"})
+	require.NoError(t, c.Execute())
+	<-written
+
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 2, conf.Redpanda.ID)
+	require.Equal(t, "r1", conf.Redpanda.Rack, "set must update the written file, not a default one")
+
+	// With no file showing up, it gives up without writing one.
+	fs = afero.NewMemMapFs()
+	_, err = new(config.Params).WaitForConfig(fs, 100*time.Millisecond)
+	require.ErrorIs(t, err, config.ErrConfigNotFound)
+	require.EqualError(t, err, "file does not exist: still no config file after waiting 100ms")
+	exists, err := afero.Exists(fs, path)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestSetSeparator(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	run := func(args ...string) {
+		c := set(fs)
+		c.SetArgs(args)
+		require.NoError(t, c.Execute())
+	}
+
+	run("rpk.kafka_api.brokers", "a,b,c", "--format", "single")
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, conf.Rpk.KafkaAPI.Brokers)
+
+	run("rpk.admin_api.addresses", "a,b|c", "--format", "single", "--separator", "|")
+	conf, err = new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a,b", "c"}, conf.Rpk.AdminAPI.Addresses)
+}
+
+func TestSetType(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	run := func(args ...string) {
+		c := set(fs)
+		c.SetArgs(args)
+		require.NoError(t, c.Execute())
+	}
+
+	run("redpanda.cloud_storage_segment_max_upload_interval_sec", "100", "--allow-extra")
+	run("redpanda.cloud_storage_region", "100", "--type", "string", "--allow-extra")
+	run("redpanda.cloud_storage_enabled", "true", "--type", "bool", "--allow-extra")
+	run("redpanda.node_id", "3", "--type", "int")
+
+	b, err := afero.ReadFile(fs, "/etc/redpanda/redpanda.yaml")
+	require.NoError(t, err)
+	require.Contains(t, string(b), "cloud_storage_segment_max_upload_interval_sec: 100\n")
+	require.Contains(t, string(b), `cloud_storage_region: "100"`)
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 100, conf.Redpanda.Other["cloud_storage_segment_max_upload_interval_sec"])
+	require.Equal(t, "100", conf.Redpanda.Other["cloud_storage_region"])
+	require.Equal(t, true, conf.Redpanda.Other["cloud_storage_enabled"])
+	require.Equal(t, 3, conf.Redpanda.ID)
+}
+
+func TestSetAllowExtra(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	run := func(args ...string) string {
+		var stderr bytes.Buffer
+		c := set(fs)
+		c.SetErr(&stderr)
+		c.SetArgs(args)
+		require.NoError(t, c.Execute())
+		return stderr.String()
+	}
+
+	require.Empty(t, run("redpanda.node_id", "1"))
+	require.Empty(t, run("redpanda.seed_servers.0.host.address", "10.0.0.1"))
+	require.Empty(t, run("redpanda.enable_idempotence", "true", "--allow-extra"))
+	require.Empty(t, run("redpanda.unmanaged_map", "{a: 1, b: [x, y]}", "--allow-extra"))
+	require.Contains(t, run("redpanda.kafak_api", "[]"), `"redpanda.kafak_api" is not a key rpk manages`)
+
+	// The extra keys survive rewrites of the file.
+	require.Empty(t, run("redpanda.node_id", "2"))
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 2, conf.Redpanda.ID)
+	require.Equal(t, true, conf.Redpanda.Other["enable_idempotence"])
+	require.Equal(t, map[string]interface{}{"a": 1, "b": []interface{}{"x", "y"}}, conf.Redpanda.Other["unmanaged_map"])
+
+	var b bytes.Buffer
+	c := get(fs)
+	c.SetOut(&b)
+	c.SetArgs([]string{"redpanda.enable_idempotence"})
+	require.NoError(t, c.Execute())
+	require.Equal(t, "true\n", b.String())
+}
+
+func TestSetNoClobber(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	for _, test := range []struct {
+		name      string
+		file      string
+		args      []string
+		expNotice string
+		expID     int
+		expDev    bool
+	}{
+		{
+			name:      "pinned value skipped",
+			file:      "redpanda:\n  node_id: 3\n",
+			args:      []string{"redpanda.node_id", "1"},
+			expNotice: "redpanda.node_id is already set in the config file, not overwriting it\n",
+			expID:     3,
+		},
+		{
+			name:      "pinned zero value skipped",
+			file:      "redpanda:\n  developer_mode: false\n",
+			args:      []string{"redpanda.developer_mode=true", "redpanda.node_id=1"},
+			expNotice: "redpanda.developer_mode is already set in the config file, not overwriting it\n",
+			expID:     1,
+		},
+		{
+			name:   "absent value set",
+			file:   "redpanda:\n  developer_mode: false\n",
+			args:   []string{"redpanda.node_id", "1"},
+			expID:  1,
+			expDev: false,
+		},
+		{
+			name:   "default value set",
+			file:   "redpanda:\n  node_id: 0\n  developer_mode: true\n",
+			args:   []string{"redpanda.node_id=2", "redpanda.developer_mode=false"},
+			expID:  2,
+			expDev: false,
+		},
+		{
+			name:   "no file",
+			args:   []string{"redpanda.node_id", "1"},
+			expID:  1,
+			expDev: true,
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			fs := afero.NewMemMapFs()
-			c := config.Default()
-			if test.prevID != "" {
-				c.NodeUUID = test.prevID
+			if test.file != "" {
+				require.NoError(t, afero.WriteFile(fs, path, []byte(test.file), 0o644))
 			}
+			var stderr bytes.Buffer
+			c := set(fs)
+			c.SetOut(io.Discard)
+			c.SetErr(&stderr)
+			c.SetArgs(append(test.args, "--no-clobber"))
+			require.NoError(t, c.Execute())
+			require.Equal(t, test.expNotice, stderr.String())
 
-			bs, err := yaml.Marshal(c)
-			require.NoError(t, err)
-			err = afero.WriteFile(fs, c.ConfigFile, bs, 0o644)
+			conf, err := new(config.Params).Load(fs)
 			require.NoError(t, err)
+			require.Equal(t, test.expID, conf.Redpanda.ID)
+			require.Equal(t, test.expDev, conf.Redpanda.DeveloperMode)
+		})
+	}
+}
 
-			cmd := initNode(fs)
-			err = cmd.Execute()
-			require.NoError(t, err)
+func TestVerbose(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	fs := afero.NewMemMapFs()
+	run := func(args ...string) (string, string) {
+		var stdout, stderr bytes.Buffer
+		c := NewConfigCommand(fs)
+		c.SetOut(&stdout)
+		c.SetErr(&stderr)
+		c.SetArgs(args)
+		require.NoError(t, c.Execute())
+		return stdout.String(), stderr.String()
+	}
+
+	_, stderr := run("set", "redpanda.node_id", "1", "-v")
+	require.Equal(t, "No config file found, using the default configuration\nWriting new config file "+path+"\n", stderr)
+
+	_, stderr = run("set", "redpanda.node_id", "2", "--verbose")
+	require.Equal(t, "Loaded config file "+path+"\nWriting config file "+path+"\n", stderr)
+
+	stdout, stderr := run("view", "-v")
+	require.Equal(t, "Loaded config file "+path+"\n", stderr)
+	require.NotContains(t, stdout, "Loaded config file")
+
+	_, stderr = run("view")
+	require.Empty(t, stderr)
+}
+
+func TestSetAppend(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, seed := range []string{
+		"{host: {address: 10.0.0.1, port: 33145}}",
+		"{host: {address: 10.0.0.2, port: 33145}}",
+	} {
+		c := set(fs)
+		c.SetArgs([]string{"redpanda.seed_servers", seed, "--append"})
+		require.NoError(t, c.Execute())
+	}
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, []config.SeedServer{
+		{Host: config.SocketAddress{Address: "10.0.0.1", Port: 33145}},
+		{Host: config.SocketAddress{Address: "10.0.0.2", Port: 33145}},
+	}, conf.Redpanda.SeedServers)
+}
+
+func TestSetAppendUnique(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	run := func(seed string) string {
+		var stdout bytes.Buffer
+		c := set(fs)
+		c.SetOut(&stdout)
+		c.SetArgs([]string{"redpanda.seed_servers", seed, "--append-unique"})
+		require.NoError(t, c.Execute())
+		return stdout.String()
+	}
+	run("{host: {address: 10.0.0.1, port: 33145}}")
+	require.Contains(t, run("{host: {address: 10.0.0.2, port: 33145}}"), "redpanda.seed_servers")
+	// Already present: left as is.
+	require.Equal(t, "no change\n", run("{host: {address: 10.0.0.2, port: 33145}}"))
+
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, []config.SeedServer{
+		{Host: config.SocketAddress{Address: "10.0.0.1", Port: 33145}},
+		{Host: config.SocketAddress{Address: "10.0.0.2", Port: 33145}},
+	}, conf.Redpanda.SeedServers)
+}
+
+func TestConfigTOML(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.toml"
+	fs := afero.NewMemMapFs()
+
+	c := set(fs)
+	c.SetArgs([]string{"redpanda.node_id", "2", "--config", path})
+	require.NoError(t, c.Execute())
+	c = bootstrap(fs)
+	c.SetArgs([]string{"--id", "2", "--self", "192.168.0.1", "--force-self", "--ips", "192.168.0.1,192.168.0.2", "--config", path})
+	require.NoError(t, c.Execute())
+
+	b, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	require.Contains(t, string(b), "[redpanda]")
+	require.Contains(t, string(b), "node_id = 2")
+
+	conf, err := (&config.Params{ConfigPath: path}).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 2, conf.Redpanda.ID)
+	require.Equal(t, "192.168.0.1", conf.Redpanda.RPCServer.Address)
+	require.Len(t, conf.Redpanda.SeedServers, 2)
+
+	var viewOut bytes.Buffer
+	c = view(fs)
+	c.SetOut(&viewOut)
+	c.SetArgs([]string{"--config", path})
+	require.NoError(t, c.Execute())
+	require.Contains(t, viewOut.String(), "node_id: 2")
+
+	// A TOML file with another extension needs --config-format.
+	require.NoError(t, fs.Rename(path, "/etc/redpanda/redpanda.conf"))
+	c = set(fs)
+	c.SetArgs([]string{"redpanda.node_id", "3", "--config", "/etc/redpanda/redpanda.conf", "--config-format", "toml"})
+	require.NoError(t, c.Execute())
+	conf, err = (&config.Params{ConfigPath: "/etc/redpanda/redpanda.conf", ConfigFormat: "toml"}).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 3, conf.Redpanda.ID)
+}
+
+func TestBackup(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	orig := `redpanda:
+    node_id: 1
+    rpc_server:
+        address: 0.0.0.0
+        port: 33145
+`
+	for _, test := range []struct {
+		name      string
+		cmd       func(afero.Fs) *cobra.Command
+		args      []string
+		noConfig  bool
+		expBackup string
+	}{
+		{
+			name:      "set",
+			cmd:       set,
+			args:      []string{"redpanda.node_id", "2", "--backup"},
+			expBackup: path + ".bak",
+		},
+		{
+			name:      "bootstrap with a custom suffix",
+			cmd:       bootstrap,
+			args:      []string{"--id", "2", "--self", "192.168.0.1", "--force-self", "--backup", "--backup-suffix", ".20220101"},
+			expBackup: path + ".20220101",
+		},
+		{
+			name:     "no config to back up",
+			cmd:      set,
+			args:     []string{"redpanda.node_id", "2", "--backup"},
+			noConfig: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if !test.noConfig {
+				require.NoError(t, afero.WriteFile(fs, path, []byte(orig), 0o600))
+			}
+
+			c := test.cmd(fs)
+			c.SetArgs(test.args)
+			require.NoError(t, c.Execute())
 
 			conf, err := new(config.Params).Load(fs)
 			require.NoError(t, err)
+			require.Equal(t, 2, conf.Redpanda.ID)
 
-			if test.prevID != "" {
-				require.Exactly(t, conf.NodeUUID, test.prevID)
-			} else {
-				require.NotEmpty(t, conf.NodeUUID)
+			files, err := afero.ReadDir(fs, "/etc/redpanda")
+			require.NoError(t, err)
+			if test.noConfig {
+				require.Len(t, files, 1)
+				return
 			}
+			require.Len(t, files, 2)
+			b, err := afero.ReadFile(fs, test.expBackup)
+			require.NoError(t, err)
+			require.Equal(t, orig, string(b))
+			stat, err := fs.Stat(test.expBackup)
+			require.NoError(t, err)
+			require.Equal(t, os.FileMode(0o600), stat.Mode())
+		})
+	}
+}
+
+func TestSortKeys(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  node_id: 3
+  zeta: 1
+  node10: 2
+  node9: 3
+  alpha:
+    b: 1
+    a: 2
+`), 0o644))
+	run := func(cmd func(afero.Fs) *cobra.Command, args ...string) string {
+		var b bytes.Buffer
+		c := cmd(fs)
+		c.SetOut(&b)
+		c.SetArgs(args)
+		require.NoError(t, c.Execute())
+		return b.String()
+	}
+
+	for _, test := range []struct {
+		name string
+		cmd  func(afero.Fs) *cobra.Command
+		args []string
+	}{
+		{"view", view, []string{"--sort-keys"}},
+		{"export", export, nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			first := run(test.cmd, test.args...)
+			require.Equal(t, first, run(test.cmd, test.args...))
+			alpha, node10, node9, zeta := strings.Index(first, "alpha:"), strings.Index(first, "node10:"), strings.Index(first, "node9:"), strings.Index(first, "zeta:")
+			require.True(t, strings.Index(first, "node_id:") < alpha && alpha < node10 && node10 < node9 && node9 < zeta, "keys out of order:\n%s", first)
+			require.Less(t, strings.Index(first, "a: 2"), strings.Index(first, "b: 1"))
+		})
+	}
+
+	// Without sorting, yaml orders the numbers in the keys numerically.
+	unsorted := run(export, "--sort-keys=false")
+	require.Less(t, strings.Index(unsorted, "node9:"), strings.Index(unsorted, "node10:"))
+}
+
+func TestConfigStdio(t *testing.T) {
+	const in = `redpanda:
+  node_id: 1
+  data_directory: /data
+`
+	for _, test := range []struct {
+		name  string
+		cmd   func(afero.Fs) *cobra.Command
+		args  []string
+		check func(*testing.T, *config.Config)
+	}{
+		{
+			name: "set",
+			cmd:  set,
+			args: []string{"redpanda.node_id", "2", "--config", "-"},
+			check: func(t *testing.T, cfg *config.Config) {
+				require.Equal(t, 2, cfg.Redpanda.ID)
+				require.Equal(t, "/data", cfg.Redpanda.Directory)
+			},
+		},
+		{
+			name: "bootstrap",
+			cmd:  bootstrap,
+			args: []string{"--id", "3", "--self", "192.168.0.1", "--force-self", "--config", "-"},
+			check: func(t *testing.T, cfg *config.Config) {
+				require.Equal(t, 3, cfg.Redpanda.ID)
+				require.Equal(t, "192.168.0.1", cfg.Redpanda.RPCServer.Address)
+				require.Equal(t, "/data", cfg.Redpanda.Directory)
+			},
+		},
+		{
+			name: "view",
+			cmd:  view,
+			args: []string{"--config", "-"},
+			check: func(t *testing.T, cfg *config.Config) {
+				require.Equal(t, 1, cfg.Redpanda.ID)
+				require.Equal(t, "/data", cfg.Redpanda.Directory)
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			var b bytes.Buffer
+			c := test.cmd(fs)
+			c.SetIn(strings.NewReader(in))
+			c.SetOut(&b)
+			c.SetArgs(test.args)
+			require.NoError(t, c.Execute())
+
+			var cfg config.Config
+			require.NoError(t, yaml.Unmarshal(b.Bytes(), &cfg))
+			test.check(t, &cfg)
+
+			files, err := afero.ReadDir(fs, "/")
+			require.NoError(t, err)
+			require.Empty(t, files, "no file should have been written")
+		})
+	}
+}
+
+func TestCompleteKeys(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  seed_servers:
+    - host:
+        address: 10.0.0.1
+        port: 33145
+    - host:
+        address: 10.0.0.2
+        port: 33145
+`), 0o644))
+
+	complete := func(args ...string) []string {
+		var b bytes.Buffer
+		c := NewConfigCommand(fs)
+		c.SetOut(&b)
+		c.SetErr(io.Discard)
+		c.SetArgs(append([]string{cobra.ShellCompRequestCmd}, args...))
+		require.NoError(t, c.Execute())
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		require.Equal(t, fmt.Sprintf(":%d", cobra.ShellCompDirectiveNoFileComp), lines[len(lines)-1])
+		return lines[:len(lines)-1]
+	}
+
+	for _, cmd := range []string{"set", "get", "unset"} {
+		require.Equal(t, []string{
+			"redpanda.rpc_server",
+			"redpanda.rpc_server.address",
+			"redpanda.rpc_server.port",
+			"redpanda.rpc_server_tls",
+			"redpanda.rpc_server_tls.name",
+			"redpanda.rpc_server_tls.key_file",
+			"redpanda.rpc_server_tls.cert_file",
+			"redpanda.rpc_server_tls.truststore_file",
+			"redpanda.rpc_server_tls.enabled",
+			"redpanda.rpc_server_tls.require_client_auth",
+		}, complete(cmd, "redpanda.rpc_server"), cmd)
+	}
+
+	// The indexes of the existing list elements are completed.
+	require.Equal(t, []string{
+		"redpanda.seed_servers.1",
+		"redpanda.seed_servers.1.host",
+		"redpanda.seed_servers.1.host.address",
+		"redpanda.seed_servers.1.host.port",
+	}, complete("set", "redpanda.seed_servers.1"))
+
+	// Only the key is completed.
+	require.Empty(t, complete("get", "redpanda.node_id", ""))
+}
+
+func TestBootstrapJoin(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  node_id: 1
+  seed_servers:
+    - host:
+        address: 10.0.0.1
+        port: 33145
+    - host:
+        address: 10.0.0.2
+        port: 33145
+`), 0o644))
+
+	c := newBootstrapCommand(fs, configStore, nil, testDial(map[string]bool{"10.0.0.3:33146": true}), nil)
+	c.SetErr(io.Discard)
+	c.SetArgs([]string{"--join", "--id", "3", "--self", "10.0.0.3", "--force-self", "--ips", "10.0.0.2,10.0.0.3:33146"})
+	require.NoError(t, c.Execute())
+
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 3, conf.Redpanda.ID)
+	require.Equal(t, "10.0.0.3", conf.Redpanda.RPCServer.Address)
+	require.Equal(t, []config.SeedServer{
+		{Host: config.SocketAddress{Address: "10.0.0.1", Port: 33145}},
+		{Host: config.SocketAddress{Address: "10.0.0.2", Port: 33145}},
+		{Host: config.SocketAddress{Address: "10.0.0.3", Port: 33146}},
+	}, conf.Redpanda.SeedServers)
+}
+
+func TestJoinSeeds(t *testing.T) {
+	current := []config.SeedServer{{Host: config.SocketAddress{Address: "10.0.0.1", Port: 33145}}}
+	members := []config.SeedServer{{Host: config.SocketAddress{Address: "10.0.0.2", Port: 33145}}}
+
+	_, err := joinSeeds(io.Discard, current, nil, testDial(nil), time.Second, false)
+	require.EqualError(t, err, "--join requires --ips or --ips-file to be set to the existing cluster members")
+
+	_, err = joinSeeds(io.Discard, current, members, testDial(nil), time.Second, false)
+	require.EqualError(t, err, "unable to reach any of the cluster members in --ips")
+
+	var warnings bytes.Buffer
+	seeds, err := joinSeeds(&warnings, current, append(members, current...), testDial(map[string]bool{"10.0.0.1:33145": true}), time.Second, false)
+	require.NoError(t, err)
+	require.Equal(t, append(current, members...), seeds)
+	require.Contains(t, warnings.String(), "unable to reach cluster member 10.0.0.2:33145")
+}
+
+func TestBootstrapTimeout(t *testing.T) {
+	// slowLookup never resolves, until the lookup is canceled.
+	slowLookup := func(ctx context.Context, _ string) ([]string, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	const timeout = 10 * time.Millisecond
+
+	_, err := resolveHost("slow.local", slowLookup, timeout)
+	require.EqualError(t, err, `unable to resolve "slow.local": timed out after 10ms`)
+
+	lookup := func(ctx context.Context, host string) ([]string, error) {
+		switch host {
+		case "slow.local":
+			return slowLookup(ctx, host)
+		case "fast.local":
+			return []string{"10.0.0.1"}, nil
+		}
+		return nil, fmt.Errorf("no such host %q", host)
+	}
+	seeds := []config.SeedServer{
+		{Host: config.SocketAddress{Address: "fast.local", Port: 33145}},
+		{Host: config.SocketAddress{Address: "slow.local", Port: 33145}},
+		{Host: config.SocketAddress{Address: "failing.local", Port: 33145}},
+	}
+	_, err = resolveSeeds(io.Discard, seeds, lookup, timeout, false)
+	require.EqualError(t, err, `unable to resolve "slow.local": timed out after 10ms`)
+
+	var warnings bytes.Buffer
+	resolved, err := resolveSeeds(&warnings, seeds, lookup, timeout, true)
+	require.NoError(t, err)
+	require.Equal(t, []config.SeedServer{
+		{Host: config.SocketAddress{Address: "10.0.0.1", Port: 33145}},
+		{Host: config.SocketAddress{Address: "slow.local", Port: 33145}},
+		{Host: config.SocketAddress{Address: "failing.local", Port: 33145}},
+	}, resolved)
+	require.Contains(t, warnings.String(), `Warning: unable to resolve "slow.local": timed out after 10ms, keeping it as is`)
+	require.Contains(t, warnings.String(), `Warning: unable to resolve "failing.local": no such host "failing.local", keeping it as is`)
+
+	// The dialer is passed the --timeout, and fails as a dial timing out.
+	var dialTimeouts []time.Duration
+	slowDial := func(_, addr string, timeout time.Duration) (net.Conn, error) {
+		dialTimeouts = append(dialTimeouts, timeout)
+		return nil, fmt.Errorf("dial tcp %s: i/o timeout", addr)
+	}
+	members := []config.SeedServer{{Host: config.SocketAddress{Address: "10.0.0.2", Port: 33145}}}
+	_, err = joinSeeds(io.Discard, nil, members, slowDial, timeout, false)
+	require.EqualError(t, err, "unable to reach any of the cluster members in --ips")
+	require.Equal(t, []time.Duration{timeout}, dialTimeouts)
+
+	warnings.Reset()
+	joined, err := joinSeeds(&warnings, nil, members, slowDial, timeout, true)
+	require.NoError(t, err)
+	require.Equal(t, members, joined)
+	require.Contains(t, warnings.String(), "unable to reach cluster member 10.0.0.2:33145: dial tcp 10.0.0.2:33145: i/o timeout")
+	require.Contains(t, warnings.String(), "Warning: unable to reach any of the cluster members in --ips, joining anyway")
+
+	// With --skip-checks, bootstrap goes on with the unresolved hosts.
+	fs := afero.NewMemMapFs()
+	c := newBootstrapCommand(fs, configStore, interfaceAddrs, slowDial, lookup)
+	c.SetErr(io.Discard)
+	c.SetArgs([]string{
+		"--id", "1",
+		"--self", "10.0.0.1", "--force-self",
+		"--resolve", "--join", "--skip-checks", "--timeout", "10ms",
+		"--ips", "fast.local,slow.local",
+	})
+	require.NoError(t, c.Execute())
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, []config.SeedServer{
+		{Host: config.SocketAddress{Address: "10.0.0.1", Port: 33145}},
+		{Host: config.SocketAddress{Address: "slow.local", Port: 33145}},
+	}, conf.Redpanda.SeedServers)
+}
+
+func TestBootstrapDuplicateSeeds(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	var stderr bytes.Buffer
+	c := bootstrap(fs)
+	c.SetErr(&stderr)
+	c.SetArgs([]string{
+		"--id", "1",
+		"--self", "10.0.0.1",
+		"--force-self",
+		"--ips", "10.0.0.2,10.0.0.1,10.0.0.2:33145,10.0.0.3,10.0.0.1",
+	})
+	require.NoError(t, c.Execute())
+
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, []config.SeedServer{
+		{Host: config.SocketAddress{Address: "10.0.0.2", Port: 33145}},
+		{Host: config.SocketAddress{Address: "10.0.0.1", Port: 33145}},
+		{Host: config.SocketAddress{Address: "10.0.0.3", Port: 33145}},
+	}, conf.Redpanda.SeedServers)
+	require.Contains(t, stderr.String(), "Warning: ignoring duplicate seed server 10.0.0.2:33145 in --ips")
+	require.Contains(t, stderr.String(), "Warning: ignoring duplicate seed server 10.0.0.1:33145 in --ips")
+}
+
+func TestBootstrapIPsFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/ips.txt", []byte(`# rack 1
+10.0.0.1
+  10.0.0.2:33146  # custom port
+
+# rack 2
+10.0.0.3
+10.0.0.4:33145
+`), 0o644))
+
+	var stderr bytes.Buffer
+	c := NewConfigCommand(fs)
+	c.SetErr(&stderr)
+	c.SetArgs([]string{
+		"bootstrap",
+		"--id", "1",
+		"--self", "10.0.0.1",
+		"--force-self",
+		"--strict",
+		"--ips", "10.0.0.5,10.0.0.4",
+		"--ips-file", "/ips.txt",
+	})
+	require.NoError(t, c.Execute())
+	// 10.0.0.4 is listed in both, which is not an error with --strict.
+	require.NotContains(t, stderr.String(), "Warning")
+
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, []config.SeedServer{
+		{Host: config.SocketAddress{Address: "10.0.0.5", Port: 33145}},
+		{Host: config.SocketAddress{Address: "10.0.0.4", Port: 33145}},
+		{Host: config.SocketAddress{Address: "10.0.0.1", Port: 33145}},
+		{Host: config.SocketAddress{Address: "10.0.0.2", Port: 33146}},
+		{Host: config.SocketAddress{Address: "10.0.0.3", Port: 33145}},
+	}, conf.Redpanda.SeedServers)
+
+	// The file alone.
+	fs = afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/ips.txt", []byte("10.0.0.1\n10.0.0.2\n"), 0o644))
+	c = bootstrap(fs)
+	c.SetArgs([]string{"--id", "1", "--self", "10.0.0.1", "--force-self", "--ips-file", "/ips.txt"})
+	require.NoError(t, c.Execute())
+	conf, err = new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Len(t, conf.Redpanda.SeedServers, 2)
+}
+
+func TestDedupeSeeds(t *testing.T) {
+	a := config.SeedServer{Host: config.SocketAddress{Address: "10.0.0.1", Port: 33145}}
+	b := config.SeedServer{Host: config.SocketAddress{Address: "10.0.0.2", Port: 33145}}
+
+	seeds, err := dedupeSeeds(io.Discard, []config.SeedServer{a, b}, true)
+	require.NoError(t, err)
+	require.Equal(t, []config.SeedServer{a, b}, seeds)
+
+	_, err = dedupeSeeds(io.Discard, []config.SeedServer{a, b, a}, true)
+	require.EqualError(t, err, "invalid --ips: duplicate seed server 10.0.0.1:33145")
+}
+
+func TestBootstrapOutputJSON(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	args := []string{"bootstrap", "--id", "2", "--self", "10.0.0.2", "--force-self", "--ips", "10.0.0.1,10.0.0.2", "--output", "json"}
+	for i := 0; i < 2; i++ {
+		// The summary is printed on the first run, which writes the
+		// config, and on the second one, which leaves it as is.
+		var stdout bytes.Buffer
+		c := NewConfigCommand(fs)
+		c.SetOut(&stdout)
+		c.SetArgs(args)
+		require.NoError(t, c.Execute())
+		require.JSONEq(t, `{
+  "id": 2,
+  "self_ip": "10.0.0.2",
+  "seed_servers": [
+    {"host": {"address": "10.0.0.1", "port": 33145}},
+    {"host": {"address": "10.0.0.2", "port": 33145}}
+  ],
+  "config_path": "/etc/redpanda/redpanda.yaml"
+}`, stdout.String())
+	}
+}
+
+func TestBootstrapDryRun(t *testing.T) {
+	fs := &writeRecordingFs{Fs: afero.NewMemMapFs()}
+	args := []string{"--id", "1", "--self", "10.0.0.1", "--force-self", "--ips", "10.0.0.1,10.0.0.2", "--dry-run"}
+
+	var stdout bytes.Buffer
+	c := bootstrap(fs)
+	c.SetOut(&stdout)
+	c.SetArgs(args)
+	require.NoError(t, c.Execute())
+	require.Zero(t, fs.writes, "a dry run should not write anything")
+	exists, err := afero.Exists(fs, config.Default().ConfigFile)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	var printed config.Config
+	require.NoError(t, yaml.Unmarshal(stdout.Bytes(), &printed))
+	require.Zero(t, printed.Version, "a new file is not stamped")
+	require.Equal(t, 1, printed.Redpanda.ID)
+	require.Equal(t, []config.SeedServer{
+		{Host: config.SocketAddress{Address: "10.0.0.1", Port: 33145}},
+		{Host: config.SocketAddress{Address: "10.0.0.2", Port: 33145}},
+	}, printed.Redpanda.SeedServers)
+
+	stdout.Reset()
+	c = bootstrap(fs)
+	c.SetOut(&stdout)
+	c.SetArgs(append(args, "--format", "json"))
+	require.NoError(t, c.Execute())
+	require.Zero(t, fs.writes)
+	require.Contains(t, stdout.String(), `"address": "10.0.0.2"`)
+}
+
+// memStore stores the configuration in memory, to check that set and
+// bootstrap only go through their store.
+type memStore struct{ b []byte }
+
+func (s *memStore) Read() (*config.Config, error) {
+	return new(config.Params).LoadFrom(bytes.NewReader(s.b))
+}
+
+func (s *memStore) Write(c *config.Config) error {
+	b, err := config.Render(c, config.FormatYAML)
+	s.b = b
+	return err
+}
+
+func TestConfigStore(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	s := &memStore{b: []byte("redpanda:\n  rack: r1\n")}
+	storeFn := func(afero.Fs, *cobra.Command) config.ConfigStore { return s }
+
+	c := newBootstrapCommand(fs, storeFn, interfaceAddrs, nil, nil)
+	c.SetArgs([]string{"--id", "1", "--self", "10.0.0.1", "--force-self", "--ips", "10.0.0.1,10.0.0.2"})
+	require.NoError(t, c.Execute())
+
+	c = newSetCommand(fs, storeFn)
+	c.SetArgs([]string{"redpanda.developer_mode", "false"})
+	require.NoError(t, c.Execute())
+
+	cfg, err := s.Read()
+	require.NoError(t, err)
+	require.Equal(t, 1, cfg.Redpanda.ID)
+	require.Equal(t, "r1", cfg.Redpanda.Rack)
+	require.False(t, cfg.Redpanda.DeveloperMode)
+	require.Len(t, cfg.Redpanda.SeedServers, 2)
+
+	exists, err := afero.Exists(fs, "/etc/redpanda/redpanda.yaml")
+	require.NoError(t, err)
+	require.False(t, exists, "the config file must not be written")
+
+	var b bytes.Buffer
+	require.Error(t, writeStore(new(cobra.Command), s, cfg, true, ".bak"), "backups need a config file")
+	require.NoError(t, writeStore(new(cobra.Command), config.NewStreamStore(new(config.Params), nil, &b), cfg, false, ""))
+	require.Contains(t, b.String(), "rack: r1")
+}
+
+// faultyStore is a config file store whose serializer does not write the
+// configuration as is, but as fault changes it.
+type faultyStore struct {
+	*config.FsStore
+	fault func(*config.Config)
+}
+
+func (s *faultyStore) Write(c *config.Config) error {
+	faulty := c.Clone()
+	s.fault(faulty)
+	return s.FsStore.Write(faulty)
+}
+
+func TestSetRoundTripCheck(t *testing.T) {
+	const file = "redpanda:\n    node_id: 1\n    rack: r1\n"
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(file), 0o644))
+	upperRack := func(c *config.Config) { c.Redpanda.Rack = strings.ToUpper(c.Redpanda.Rack) }
+	s := &faultyStore{config.NewFsStore(fs, new(config.Params)), upperRack}
+
+	orig, err := s.Read()
+	require.NoError(t, err)
+	cfg := orig.Clone()
+	cfg.Redpanda.Rack = "r2"
+	err = writeStoreChecked(new(cobra.Command), s, orig, cfg, false, "")
+	require.EqualError(t, err, "round trip check failed, the change was rolled back: redpanda.rack did not read back as set")
+	b, err := afero.ReadFile(fs, "/etc/redpanda/redpanda.yaml")
+	require.NoError(t, err)
+	require.Equal(t, file, string(b))
+
+	// Only the keys that changed are checked.
+	cfg = orig.Clone()
+	cfg.Redpanda.ID = 2
+	s.fault = func(c *config.Config) { c.Redpanda.Rack = "r1" }
+	require.NoError(t, writeStoreChecked(new(cobra.Command), s, orig, cfg, false, ""))
+
+	// Without a config file, the file written is removed.
+	fs = afero.NewMemMapFs()
+	s = &faultyStore{config.NewFsStore(fs, new(config.Params)), upperRack}
+	orig, err = s.Read()
+	require.NoError(t, err)
+	cfg = orig.Clone()
+	cfg.Redpanda.Rack = "r2"
+	require.Error(t, writeStoreChecked(new(cobra.Command), s, orig, cfg, false, ""))
+	exists, err := afero.Exists(fs, "/etc/redpanda/redpanda.yaml")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// set checks the round trip by default, see TestExitCodes, unless
+	// --round-trip-check=false is passed.
+	fs = afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(file), 0o644))
+	storeFn := func(fs afero.Fs, cmd *cobra.Command) config.ConfigStore {
+		return &faultyStore{config.NewFsStore(fs, config.ParamsFromCommand(cmd)), upperRack}
+	}
+	c := newSetCommand(fs, storeFn)
+	c.SetArgs([]string{"redpanda.rack", "r2", "--round-trip-check=false"})
+	require.NoError(t, c.Execute())
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, "R2", conf.Redpanda.Rack)
+}
+
+func TestBootstrapOut(t *testing.T) {
+	const base = `# Base config, shared by every node.
+redpanda:
+  data_directory: /data
+  node_id: 0
+  rpc_server:
+    address: 0.0.0.0
+    port: 33145
+`
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/base.yaml", []byte(base), 0o600))
+
+	var stdout bytes.Buffer
+	c := NewConfigCommand(fs)
+	c.SetOut(&stdout)
+	c.SetArgs([]string{
+		"bootstrap", "--config", "/base.yaml", "--out", "/staging/node.yaml", "--output", "json",
+		"--id", "1", "--self", "10.0.0.1", "--force-self", "--ips", "10.0.0.1,10.0.0.2",
+	})
+	require.NoError(t, fs.MkdirAll("/staging", 0o755))
+	require.NoError(t, c.Execute())
+	require.Contains(t, stdout.String(), `"config_path": "/staging/node.yaml"`)
+
+	b, err := afero.ReadFile(fs, "/base.yaml")
+	require.NoError(t, err)
+	require.Equal(t, base, string(b), "the source config should be untouched")
+
+	b, err = afero.ReadFile(fs, "/staging/node.yaml")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(b), "# Base config, shared by every node.\n"), "comments should be kept")
+	conf, err := (&config.Params{ConfigPath: "/staging/node.yaml"}).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 1, conf.Redpanda.ID)
+	require.Equal(t, "/data", conf.Redpanda.Directory)
+	require.Equal(t, "/staging/node.yaml", conf.ConfigFile)
+	require.Len(t, conf.Redpanda.SeedServers, 2)
+	require.Equal(t, "10.0.0.1", conf.Redpanda.RPCServer.Address)
+}
+
+func TestBootstrapAnnotate(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	args := []string{"--id", "1", "--self", "10.0.0.1", "--force-self", "--ips", "10.0.0.1,10.0.0.2"}
+
+	plain := afero.NewMemMapFs()
+	c := bootstrap(plain)
+	c.SetArgs(args)
+	require.NoError(t, c.Execute())
+
+	fs := afero.NewMemMapFs()
+	c = bootstrap(fs)
+	c.SetArgs(append(args, "--annotate"))
+	require.NoError(t, c.Execute())
+
+	b, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	require.Regexp(t, `\n    # Seed servers written by rpk redpanda config bootstrap\n    # on \d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ by .+ with --ips "10.0.0.1,10.0.0.2"\n    seed_servers:\n`, string(b))
+
+	annotated, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	unannotated, err := new(config.Params).Load(plain)
+	require.NoError(t, err)
+	require.Equal(t, unannotated.Redpanda, annotated.Redpanda)
+}
+
+func TestBootstrapPorts(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	c := bootstrap(fs)
+	c.SetArgs([]string{
+		"--id", "1",
+		"--self", "192.168.0.1",
+		"--force-self",
+		"--ips", "192.168.0.1,192.168.0.2:33147",
+		"--rpc-port", "33146",
+		"--kafka-port", "19092",
+		"--admin-port", "19644",
+	})
+	require.NoError(t, c.Execute())
+
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, config.SocketAddress{Address: "192.168.0.1", Port: 33146}, conf.Redpanda.RPCServer)
+	require.Equal(t, []config.NamedSocketAddress{{Address: "192.168.0.1", Port: 19092}}, conf.Redpanda.KafkaAPI)
+	require.Equal(t, []config.NamedSocketAddress{{Address: "192.168.0.1", Port: 19644}}, conf.Redpanda.AdminAPI)
+	require.Equal(t, []config.SeedServer{
+		{Host: config.SocketAddress{Address: "192.168.0.1", Port: 33146}},
+		{Host: config.SocketAddress{Address: "192.168.0.2", Port: 33147}},
+	}, conf.Redpanda.SeedServers)
+}
+
+func TestBootstrapAutoID(t *testing.T) {
+	run := func(self string) int {
+		fs := afero.NewMemMapFs()
+		var stderr bytes.Buffer
+		c := bootstrap(fs)
+		c.SetErr(&stderr)
+		c.SetArgs([]string{"--auto-id", "--self", self, "--force-self"})
+		require.NoError(t, c.Execute())
+
+		conf, err := new(config.Params).Load(fs)
+		require.NoError(t, err)
+		require.Contains(t, stderr.String(), fmt.Sprintf("Using node ID %d, derived from %s", conf.Redpanda.ID, self))
+		return conf.Redpanda.ID
+	}
+	id := run("192.168.0.1")
+	require.GreaterOrEqual(t, id, 0)
+	require.Equal(t, id, run("192.168.0.1"), "the derived ID should be stable")
+	require.NotEqual(t, id, run("192.168.0.2"))
+	require.Equal(t, deriveNodeID(net.ParseIP("fd00::1")), run("fd00::1"))
+}
+
+func TestBootstrapResolve(t *testing.T) {
+	lookup := func(_ context.Context, host string) ([]string, error) {
+		switch host {
+		case "redpanda-0.local":
+			return []string{"10.0.0.1", "fd00::1"}, nil
+		case "redpanda-1.local":
+			return []string{"10.0.0.2"}, nil
+		}
+		return nil, fmt.Errorf("no such host %q", host)
+	}
+	addrs := func(string) ([]net.Addr, error) {
+		return []net.Addr{testIPNet(t, "10.0.0.1/8")}, nil
+	}
+
+	fs := afero.NewMemMapFs()
+	c := newBootstrapCommand(fs, configStore, addrs, nil, lookup)
+	c.SetArgs([]string{
+		"--resolve",
+		"--auto-id",
+		"--self", "redpanda-0.local",
+		"--ips", "redpanda-0.local,redpanda-1.local:33146,10.0.0.3",
+	})
+	c.SetErr(io.Discard)
+	require.NoError(t, c.Execute())
+
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1", conf.Redpanda.RPCServer.Address)
+	require.Equal(t, deriveNodeID(net.ParseIP("10.0.0.1")), conf.Redpanda.ID)
+	require.Equal(t, []config.SeedServer{
+		{Host: config.SocketAddress{Address: "10.0.0.1", Port: 33145}},
+		{Host: config.SocketAddress{Address: "10.0.0.2", Port: 33146}},
+		{Host: config.SocketAddress{Address: "10.0.0.3", Port: 33145}},
+	}, conf.Redpanda.SeedServers)
+
+	_, err = resolveSeeds(io.Discard, []config.SeedServer{{Host: config.SocketAddress{Address: "unknown.local"}}}, lookup, time.Second, false)
+	require.EqualError(t, err, `unable to resolve "unknown.local": no such host "unknown.local"`)
+	_, err = resolveHost("empty.local", func(context.Context, string) ([]string, error) { return nil, nil }, time.Second)
+	require.EqualError(t, err, `unable to resolve "empty.local": no addresses found`)
+}
+
+func TestCheckSelfHost(t *testing.T) {
+	require.NoError(t, checkSelfHost("redpanda-0.local"))
+	require.EqualError(t, checkSelfHost("redpanda-0.local:33145"), `invalid --self "redpanda-0.local:33145", must be an IP or a hostname with no port`)
+	require.Error(t, checkSelfHost("tcp://redpanda-0.local"))
+	require.Error(t, checkSelfHost("redpanda 0"))
+}
+
+func TestCheckSelfIP(t *testing.T) {
+	addrs := func(string) ([]net.Addr, error) {
+		return []net.Addr{
+			testIPNet(t, "127.0.0.1/8"),
+			testIPNet(t, "10.0.0.3/8"),
+			testIPNet(t, "fd00::3/64"),
+		}, nil
+	}
+	require.NoError(t, checkSelfIP(net.ParseIP("10.0.0.3"), addrs))
+	require.NoError(t, checkSelfIP(net.ParseIP("fd00::3"), addrs))
+	require.EqualError(t, checkSelfIP(net.ParseIP("10.0.0.4"), addrs), "10.0.0.4 is not among this machine's non-loopback addresses, use --force-self to use it anyway")
+	require.Error(t, checkSelfIP(net.ParseIP("127.0.0.1"), addrs))
+
+	// --force-self skips the check.
+	ip, err := parseSelfIP("10.0.0.4", "", preferIPv4, "", nil, true, addrs)
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.4", ip.String())
+	_, err = parseSelfIP("10.0.0.4", "", preferIPv4, "", nil, false, addrs)
+	require.Error(t, err)
+}
+
+func TestCheckPortFlag(t *testing.T) {
+	require.NoError(t, checkPortFlag("rpc-port", 1))
+	require.NoError(t, checkPortFlag("rpc-port", 65535))
+	require.EqualError(t, checkPortFlag("kafka-port", 0), "invalid --kafka-port 0, must be in the range [1, 65535]")
+	require.EqualError(t, checkPortFlag("admin-port", 65536), "invalid --admin-port 65536, must be in the range [1, 65535]")
+}
+
+// writeRecordingFs counts the operations that modify files.
+type writeRecordingFs struct {
+	afero.Fs
+	writes int
+}
+
+func (fs *writeRecordingFs) Create(name string) (afero.File, error) {
+	fs.writes++
+	return fs.Fs.Create(name)
+}
+
+func (fs *writeRecordingFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		fs.writes++
+	}
+	return fs.Fs.OpenFile(name, flag, perm)
+}
+
+func (fs *writeRecordingFs) Rename(oldname, newname string) error {
+	fs.writes++
+	return fs.Fs.Rename(oldname, newname)
+}
+
+func TestBootstrapIdempotent(t *testing.T) {
+	fs := &writeRecordingFs{Fs: afero.NewMemMapFs()}
+	args := []string{"--id", "1", "--self", "192.168.0.1", "--force-self", "--ips", "192.168.0.1,192.168.0.2"}
+
+	summary := func(id int) string {
+		return fmt.Sprintf(`Config file:  /etc/redpanda/redpanda.yaml
+Node ID:      %d
+Seed servers: 192.168.0.1:33145, 192.168.0.2:33145
+`, id)
+	}
+
+	var b bytes.Buffer
+	c := bootstrap(fs)
+	c.SetOut(&b)
+	c.SetArgs(args)
+	require.NoError(t, c.Execute())
+	require.NotZero(t, fs.writes)
+	require.Equal(t, summary(1), b.String())
+
+	b.Reset()
+	fs.writes = 0
+	c = bootstrap(fs)
+	c.SetOut(&b)
+	c.SetArgs(args)
+	require.NoError(t, c.Execute())
+	require.Zero(t, fs.writes, "an up to date config should not be written")
+	require.Equal(t, "config already up to date\n"+summary(1), b.String())
+
+	b.Reset()
+	c = bootstrap(fs)
+	c.SetOut(&b)
+	c.SetArgs([]string{"--id", "2", "--self", "192.168.0.1", "--force-self", "--ips", "192.168.0.1,192.168.0.2"})
+	require.NoError(t, c.Execute())
+	require.NotZero(t, fs.writes)
+	require.Equal(t, summary(2), b.String())
+}
+
+func TestBootstrapKeepsSeedIDs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	run := func(ips string) []config.SeedServer {
+		c := bootstrap(fs)
+		c.SetArgs([]string{"--id", "1", "--self", "192.168.0.1", "--force-self", "--ips", ips})
+		require.NoError(t, c.Execute())
+		conf, err := new(config.Params).Load(fs)
+		require.NoError(t, err)
+		return conf.Redpanda.SeedServers
+	}
+	seeds := func(addrs ...string) []config.SeedServer {
+		var s []config.SeedServer
+		for _, a := range addrs {
+			s = append(s, config.SeedServer{Host: config.SocketAddress{Address: a, Port: 33145}})
+		}
+		return s
+	}
+
+	require.Equal(t, seeds("192.168.0.1", "192.168.0.2", "192.168.0.3"), run("192.168.0.1,192.168.0.2,192.168.0.3"))
+	// The same members in another order keep their ID.
+	require.Equal(t, seeds("192.168.0.1", "192.168.0.2", "192.168.0.3"), run("192.168.0.3,192.168.0.1,192.168.0.2"))
+	// A new member takes the ID of the removed one.
+	require.Equal(t, seeds("192.168.0.1", "192.168.0.4", "192.168.0.3"), run("192.168.0.4,192.168.0.3,192.168.0.1"))
+}
+
+// testDial returns a dialFunc that only connects to the reachable addresses.
+func testDial(reachable map[string]bool) dialFunc {
+	return func(_, addr string, _ time.Duration) (net.Conn, error) {
+		if !reachable[addr] {
+			return nil, errors.New("connection refused")
+		}
+		c1, c2 := net.Pipe()
+		c2.Close()
+		return c1, nil
+	}
+}
+
+// testIPNet returns cidr as an interface address, the IP being the address
+// of the interface within the network.
+func testIPNet(t *testing.T, cidr string) net.Addr {
+	ip, n, err := net.ParseCIDR(cidr)
+	require.NoError(t, err)
+	n.IP = ip
+	return n
+}
+
+func TestPickOwnIP(t *testing.T) {
+	mixed := []net.Addr{
+		testIPNet(t, "127.0.0.1/8"),
+		testIPNet(t, "::1/128"),
+		testIPNet(t, "10.0.0.4/8"),
+		testIPNet(t, "fe80::1/64"),
+		testIPNet(t, "2001:db8::4/64"),
+	}
+	for _, test := range []struct {
+		name   string
+		addrs  []net.Addr
+		prefer string
+		family string
+		exp    string
+		expErr string
+	}{
+		{
+			name:   "no addresses",
+			expErr: "couldn't find any non-loopback IPs for the current node",
+		},
+		{
+			name:   "only loopback and public addresses",
+			addrs:  []net.Addr{testIPNet(t, "127.0.0.1/8"), testIPNet(t, "8.8.8.8/24"), testIPNet(t, "::1/128")},
+			expErr: "couldn't find any non-loopback IPs for the current node",
+		},
+		{
+			name:  "a single private address",
+			addrs: []net.Addr{testIPNet(t, "127.0.0.1/8"), testIPNet(t, "192.168.34.5/24")},
+			exp:   "192.168.34.5",
+		},
+		{
+			name:   "many private addresses",
+			addrs:  []net.Addr{testIPNet(t, "10.0.0.4/8"), testIPNet(t, "192.168.34.5/24")},
+			expErr: "found multiple private non-loopback v4 IPs for the current node. Please set one with --self",
+		},
+		{
+			name:  "non IPNet addresses are skipped",
+			addrs: []net.Addr{&net.IPAddr{IP: net.ParseIP("10.0.0.1")}, testIPNet(t, "172.16.0.3/12")},
+			exp:   "172.16.0.3",
+		},
+		{
+			name:  "mixed families prefer ipv4 by default",
+			addrs: mixed,
+			exp:   "10.0.0.4",
+		},
+		{
+			name:   "mixed families prefer ipv6",
+			addrs:  mixed,
+			prefer: preferIPv6,
+			exp:    "2001:db8::4",
+		},
+		{
+			name:   "mixed families with any is ambiguous",
+			addrs:  mixed,
+			prefer: preferAny,
+			expErr: "found multiple non-loopback IPs for the current node. Please set one with --self",
+		},
+		{
+			name:   "link-local v6 addresses are not picked",
+			addrs:  []net.Addr{testIPNet(t, "10.0.0.4/8"), testIPNet(t, "fe80::1/64")},
+			prefer: preferAny,
+			exp:    "10.0.0.4",
+		},
+		{
+			name:   "only link-local v6 addresses",
+			addrs:  []net.Addr{testIPNet(t, "::1/128"), testIPNet(t, "fe80::1/64")},
+			prefer: preferIPv6,
+			expErr: "couldn't find any non-loopback IPs for the current node",
+		},
+		{
+			name:   "many v6 addresses",
+			addrs:  []net.Addr{testIPNet(t, "fd00::4/8"), testIPNet(t, "2001:db8::4/64")},
+			prefer: preferIPv6,
+			expErr: "found multiple non-loopback v6 IPs for the current node. Please set one with --self",
+		},
+		{
+			name:   "dual-stack with an ipv4 family preference",
+			addrs:  mixed,
+			prefer: preferAny,
+			family: preferIPv4,
+			exp:    "10.0.0.4",
+		},
+		{
+			name:   "dual-stack with an ipv6 family preference",
+			addrs:  mixed,
+			prefer: preferAny,
+			family: preferIPv6,
+			exp:    "2001:db8::4",
+		},
+		{
+			name:   "dual-stack with an ambiguous family preference",
+			addrs:  append([]net.Addr{testIPNet(t, "192.168.1.4/24")}, mixed...),
+			prefer: preferAny,
+			family: preferIPv4,
+			expErr: "found multiple private non-loopback v4 IPs for the current node. Please set one with --self",
+		},
+		{
+			name:   "dual-stack with an unambiguous other family",
+			addrs:  append([]net.Addr{testIPNet(t, "192.168.1.4/24")}, mixed...),
+			prefer: preferAny,
+			family: preferIPv6,
+			exp:    "2001:db8::4",
+		},
+		{
+			name:   "family preference with no address of the family",
+			addrs:  []net.Addr{testIPNet(t, "fd00::4/8"), testIPNet(t, "2001:db8::4/64")},
+			prefer: preferAny,
+			family: preferIPv4,
+			expErr: "found multiple non-loopback IPs for the current node. Please set one with --self",
+		},
+		{
+			name:   "family preference with a single address of the other family",
+			addrs:  []net.Addr{testIPNet(t, "127.0.0.1/8"), testIPNet(t, "2001:db8::4/64")},
+			prefer: preferAny,
+			family: preferIPv4,
+			exp:    "2001:db8::4",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			prefer := test.prefer
+			if prefer == "" {
+				prefer = preferIPv4
+			}
+			ip, err := pickOwnIP(test.addrs, prefer, test.family, true)
+			if test.expErr != "" {
+				require.EqualError(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.exp, ip.String())
+		})
+	}
+}
+
+func TestInitNode(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		prevID string
+	}{
+		{name: "without UUID"},
+		{name: "with UUID", prevID: "my_id"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			c := config.Default()
+			if test.prevID != "" {
+				c.NodeUUID = test.prevID
+			}
+
+			bs, err := yaml.Marshal(c)
+			require.NoError(t, err)
+			err = afero.WriteFile(fs, c.ConfigFile, bs, 0o644)
+			require.NoError(t, err)
+
+			cmd := initNode(fs)
+			err = cmd.Execute()
+			require.NoError(t, err)
+
+			conf, err := new(config.Params).Load(fs)
+			require.NoError(t, err)
+
+			if test.prevID != "" {
+				require.Exactly(t, conf.NodeUUID, test.prevID)
+			} else {
+				require.NotEmpty(t, conf.NodeUUID)
+			}
+		})
+	}
+}
+
+func TestConfigLogLevel(t *testing.T) {
+	defer config.SetLogger(config.SetLogger(nil))
+	const path = "/etc/redpanda/redpanda.yaml"
+	for _, test := range []struct {
+		name string
+		args []string
+		exp  bool
+	}{
+		{"default", nil, false},
+		{"info", []string{"--log-level", "info"}, false},
+		{"debug", []string{"--log-level", "debug"}, true},
+		{"verbose", []string{"-v"}, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, path, []byte("redpanda:\n  node_id: 1\n"), 0o644))
+			var stderr bytes.Buffer
+			cmd := NewConfigCommand(fs)
+			cmd.SetArgs(append([]string{"set", "redpanda.node_id", "2", "--config", path}, test.args...))
+			cmd.SetErr(&stderr)
+			require.NoError(t, cmd.Execute())
+
+			logs := stderr.String()
+			if !test.exp {
+				require.NotContains(t, logs, "Resolved config file")
+				return
+			}
+			require.Contains(t, logs, "Resolved config file "+path)
+			require.Contains(t, logs, "Replacing config file "+path)
+		})
+	}
+}
+
+func TestCreateDirs(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		cmd  func(afero.Fs) *cobra.Command
+		args []string
+	}{
+		{"set", set, []string{"redpanda.node_id", "1"}},
+		{"bootstrap", bootstrap, []string{"--id", "1", "--self", "10.0.0.1", "--force-self"}},
+		{"generate", generate, nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewOsFs()
+			dir := filepath.Join(t.TempDir(), "etc", "redpanda")
+			path := filepath.Join(dir, "redpanda.yaml")
+
+			c := test.cmd(fs)
+			c.SetOut(io.Discard)
+			c.SetArgs(append(test.args, "--config", path, "--create-dirs"))
+			require.NoError(t, c.Execute())
+
+			stat, err := fs.Stat(dir)
+			require.NoError(t, err)
+			require.True(t, stat.IsDir())
+			require.Equal(t, os.FileMode(0o755), stat.Mode().Perm())
+			conf, err := (&config.Params{ConfigPath: path}).Load(fs)
+			require.NoError(t, err)
+			require.Equal(t, path, conf.LoadedPath())
+		})
+	}
+
+	t.Run("bootstrap out", func(t *testing.T) {
+		fs := afero.NewOsFs()
+		base := t.TempDir()
+		path := filepath.Join(base, "redpanda.yaml")
+		outPath := filepath.Join(base, "nodes", "1", "redpanda.yaml")
+
+		c := bootstrap(fs)
+		c.SetOut(io.Discard)
+		c.SetArgs([]string{"--id", "1", "--self", "10.0.0.1", "--force-self", "--config", path, "--out", outPath, "--create-dirs"})
+		require.NoError(t, c.Execute())
+		exists, err := afero.Exists(fs, outPath)
+		require.NoError(t, err)
+		require.True(t, exists)
+	})
+
+	t.Run("bootstrap out without the config directory", func(t *testing.T) {
+		// Only the --out file is written: the --config file is neither
+		// written nor locked, and its directory is not created.
+		fs := afero.NewOsFs()
+		base := t.TempDir()
+		dir := filepath.Join(base, "missing")
+		outPath := filepath.Join(base, "redpanda.yaml")
+
+		c := bootstrap(fs)
+		c.SetOut(io.Discard)
+		c.SetArgs([]string{"--id", "1", "--self", "10.0.0.1", "--force-self", "--config", filepath.Join(dir, "redpanda.yaml"), "--out", outPath})
+		require.NoError(t, c.Execute())
+		exists, err := afero.Exists(fs, outPath)
+		require.NoError(t, err)
+		require.True(t, exists)
+		exists, err = afero.DirExists(fs, dir)
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+
+	t.Run("load creates the directory", func(t *testing.T) {
+		fs := afero.NewOsFs()
+		dir := filepath.Join(t.TempDir(), "missing")
+		_, err := (&config.Params{ConfigPath: filepath.Join(dir, "redpanda.yaml")}).Load(fs)
+		require.NoError(t, err)
+		exists, err := afero.DirExists(fs, dir)
+		require.NoError(t, err)
+		require.True(t, exists)
+	})
+
+	t.Run("load does not create the directory with NoCreateDirs", func(t *testing.T) {
+		fs := afero.NewOsFs()
+		dir := filepath.Join(t.TempDir(), "missing")
+		_, err := (&config.Params{ConfigPath: filepath.Join(dir, "redpanda.yaml"), NoCreateDirs: true}).Load(fs)
+		require.NoError(t, err)
+		exists, err := afero.DirExists(fs, dir)
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+
+	// The commands without --create-dirs create the directory, as they
+	// always have.
+	t.Run("init", func(t *testing.T) {
+		fs := afero.NewOsFs()
+		path := filepath.Join(t.TempDir(), "etc", "redpanda", "redpanda.yaml")
+		c := initNode(fs)
+		c.SetArgs([]string{"--config", path})
+		require.NoError(t, c.Execute())
+		exists, err := afero.Exists(fs, path)
+		require.NoError(t, err)
+		require.True(t, exists)
+	})
+}
+
+func TestGetOwnIPInterface(t *testing.T) {
+	addrsFn := func(iface string) ([]net.Addr, error) {
+		switch iface {
+		case "lo":
+			return []net.Addr{testIPNet(t, "127.0.0.1/8")}, nil
+		case "eth0":
+			return []net.Addr{testIPNet(t, "10.0.0.3/8"), testIPNet(t, "fe80::3/64")}, nil
+		}
+		return nil, fmt.Errorf("unable to find interface %q", iface)
+	}
+
+	ip, err := getOwnIP("eth0", preferIPv4, "", nil, addrsFn)
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.3", ip.String())
+
+	_, err = getOwnIP("lo", preferIPv4, "", nil, addrsFn)
+	require.EqualError(t, err, `interface "lo" has no usable address: couldn't find any non-loopback IPs for the current node`)
+
+	_, err = getOwnIP("eth9", preferIPv4, "", nil, addrsFn)
+	require.EqualError(t, err, `unable to find interface "eth9"`)
+}
+
+func TestGetOwnIPCIDR(t *testing.T) {
+	addrs := []net.Addr{
+		testIPNet(t, "127.0.0.1/8"),
+		testIPNet(t, "192.168.1.10/24"),
+		testIPNet(t, "10.1.0.10/16"),
+		testIPNet(t, "10.2.0.10/16"),
+		testIPNet(t, "34.1.2.3/24"),
+	}
+	addrsFn := func(string) ([]net.Addr, error) { return addrs, nil }
+	for _, test := range []struct {
+		name   string
+		cidr   string
+		exp    string
+		expErr string
+	}{
+		{
+			name:   "without a network every private address is a candidate",
+			expErr: "found multiple private non-loopback v4 IPs for the current node. Please set one with --self",
+		},
+		{
+			name: "a single address matches",
+			cidr: "192.168.0.0/16",
+			exp:  "192.168.1.10",
+		},
+		{
+			name: "public addresses are picked within the network",
+			cidr: "34.1.2.0/24",
+			exp:  "34.1.2.3",
+		},
+		{
+			name:   "several addresses still match",
+			cidr:   "10.0.0.0/8",
+			expErr: "network 10.0.0.0/8: found multiple non-loopback v4 IPs for the current node. Please set one with --self",
+		},
+		{
+			name:   "no address matches",
+			cidr:   "172.16.0.0/12",
+			expErr: "network 172.16.0.0/12: couldn't find any non-loopback IPs for the current node",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var network *net.IPNet
+			if test.cidr != "" {
+				var err error
+				_, network, err = net.ParseCIDR(test.cidr)
+				require.NoError(t, err)
+			}
+			ip, err := getOwnIP("", preferIPv4, "", network, addrsFn)
+			if test.expErr != "" {
+				require.EqualError(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.exp, ip.String())
+		})
+	}
+}
+
+// TestExitCodes runs the failing commands in a subprocess of the test binary,
+// since they exit the process.
+func TestExitCodes(t *testing.T) {
+	withConfig := func(file string) afero.Fs {
+		fs := afero.NewMemMapFs()
+		if err := afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(file), 0o644); err != nil {
+			panic(err)
+		}
+		return fs
+	}
+	withBackup := func(file string) afero.Fs {
+		fs := withConfig("redpanda:\n  node_id: 1\n")
+		if err := afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml.bak", []byte(file), 0o644); err != nil {
+			panic(err)
+		}
+		return fs
+	}
+	withPatch := func(patch string) afero.Fs {
+		fs := withConfig("redpanda:\n  node_id: 1\n")
+		if err := afero.WriteFile(fs, "/patch.json", []byte(patch), 0o644); err != nil {
+			panic(err)
+		}
+		return fs
+	}
+	withIPsFile := func(ips string) afero.Fs {
+		fs := afero.NewMemMapFs()
+		if err := afero.WriteFile(fs, "/ips.txt", []byte(ips), 0o644); err != nil {
+			panic(err)
+		}
+		return fs
+	}
+	withCerts := func() afero.Fs {
+		fs := afero.NewMemMapFs()
+		for _, f := range []string{"/certs/node.crt", "/certs/node.key"} {
+			if err := afero.WriteFile(fs, f, []byte("PEM"), 0o600); err != nil {
+				panic(err)
+			}
+		}
+		return fs
+	}
+	// Never created, the commands fail without --create-dirs.
+	missingPath := filepath.Join(os.TempDir(), "rpk-exit-codes-missing", "redpanda.yaml")
+	readOnly := afero.NewReadOnlyFs(afero.NewMemMapFs())
+	tests := []struct {
+		name string
+		cmd  *cobra.Command
+		args []string
+		exp  int
+	}{
+		{"set unknown key", set(afero.NewMemMapFs()), []string{"redpanda.rpc_server.unknown", "1"}, exitInvalidInput},
+		{"set invalid value", set(afero.NewMemMapFs()), []string{"redpanda.node_id", "{", "--format", "json"}, exitInvalidInput},
+		{"set invalid config file", set(withConfig("redpanda: [")), []string{"redpanda.node_id", "1"}, exitInvalidConfig},
+		{"bootstrap invalid config file", bootstrap(withConfig("redpanda: [")), []string{"--id", "1", "--self", "10.0.0.1", "--force-self"}, exitInvalidConfig},
+		{"migrate invalid config file", migrate(withConfig("redpanda: [")), nil, exitInvalidConfig},
+		{"set read-only", set(readOnly), []string{"redpanda.node_id", "1"}, exitIO},
+		{"set round trip", newSetCommand(withConfig("redpanda:\n  rack: r1\n"), func(fs afero.Fs, cmd *cobra.Command) config.ConfigStore {
+			return &faultyStore{config.NewFsStore(fs, config.ParamsFromCommand(cmd)), func(c *config.Config) { c.Redpanda.Rack = "" }}
+		}), []string{"redpanda.rack", "r2"}, exitIO},
+		{"ensure-dirs missing", ensureDirs(afero.NewMemMapFs()), nil, exitIO},
+		{"doctor invalid timeout", doctor(afero.NewMemMapFs()), []string{"--timeout", "0s"}, exitInvalidInput},
+		{"ensure-dirs read-only", ensureDirs(readOnly), []string{"--create-data-dir"}, exitIO},
+		{"bootstrap invalid flags", bootstrap(afero.NewMemMapFs()), []string{"--id", "1", "--auto-id"}, exitInvalidInput},
+		{"bootstrap invalid interface family", bootstrap(afero.NewMemMapFs()), []string{"--id", "1", "--interface-family", "any"}, exitInvalidInput},
+		{"bootstrap prefer and interface family", bootstrap(afero.NewMemMapFs()), []string{"--id", "1", "--prefer", "ipv6", "--interface-family", "ipv4"}, exitInvalidInput},
+		{"bootstrap read-only", bootstrap(readOnly), []string{"--id", "1", "--self", "10.0.0.1", "--force-self"}, exitIO},
+		{"validate invalid config", validate(withConfig("redpanda:\n  node_id: -1\n")), nil, exitInvalidConfig},
+		{"validate invalid config file", validate(withConfig("redpanda: [")), nil, exitInvalidConfig},
+		{"validate strict unknown keys", NewConfigCommand(withConfig("redpanda:\n  not_a_key: 1\n")), []string{"validate", "--strict"}, exitInvalidConfig},
+		{"set separator not a list", set(afero.NewMemMapFs()), []string{"redpanda.rack", "a,b", "--format", "single", "--separator", ","}, exitInvalidInput},
+		{"set separator without single", set(afero.NewMemMapFs()), []string{"rpk.kafka_api.brokers", "a,b", "--separator", ","}, exitInvalidInput},
+		{"set wait-for-file timeout", set(afero.NewMemMapFs()), []string{"redpanda.node_id", "1", "--wait-for-file", "50ms"}, exitIO},
+		{"rollback no backup", rollback(withConfig("redpanda:\n  node_id: 1\n")), nil, exitIO},
+		{"rollback invalid backup", rollback(withBackup("redpanda: [")), nil, exitInvalidConfig},
+		{"invalid log level", NewConfigCommand(afero.NewMemMapFs()), []string{"path", "--log-level", "loud"}, exitInvalidInput},
+		{"set missing directory", set(afero.NewOsFs()), []string{"redpanda.node_id", "1", "--config", missingPath}, exitIO},
+		{"bootstrap missing directory", bootstrap(afero.NewOsFs()), []string{"--id", "1", "--self", "10.0.0.1", "--force-self", "--config", missingPath}, exitIO},
+		// Invalid flags fail before the directory or the lock file is created.
+		{"set create dirs append and append unique", set(afero.NewOsFs()), []string{"redpanda.seed_servers", "{}", "--config", missingPath, "--create-dirs", "--append", "--append-unique"}, exitInvalidInput},
+		{"set create dirs type and append", set(afero.NewOsFs()), []string{"redpanda.seed_servers", "{}", "--config", missingPath, "--create-dirs", "--append", "--type", "string"}, exitInvalidInput},
+		{"set create dirs invalid separator", set(afero.NewOsFs()), []string{"redpanda.seed_servers", "a,b", "--config", missingPath, "--create-dirs", "--separator", ","}, exitInvalidInput},
+		{"set create dirs strict unknown key", NewConfigCommand(afero.NewOsFs()), []string{"set", "redpanda.not_a_key", "1", "--config", missingPath, "--create-dirs", "--strict"}, exitInvalidInput},
+		{"bootstrap create dirs invalid ips", bootstrap(afero.NewOsFs()), []string{"--id", "1", "--self", "10.0.0.1", "--force-self", "--config", missingPath, "--create-dirs", "--ips", "10.0.0.1:port"}, exitInvalidInput},
+		{"bootstrap create dirs invalid format", bootstrap(afero.NewOsFs()), []string{"--id", "1", "--self", "10.0.0.1", "--force-self", "--config", missingPath, "--create-dirs", "--dry-run", "--format", "xml"}, exitInvalidInput},
+		{"bootstrap create dirs invalid port", bootstrap(afero.NewOsFs()), []string{"--id", "1", "--self", "10.0.0.1", "--force-self", "--config", missingPath, "--create-dirs", "--kafka-port", "70000"}, exitInvalidInput},
+		{"generate missing directory", generate(afero.NewOsFs()), []string{"--config", missingPath}, exitIO},
+		{"describe unknown key", describe(), []string{"redpanda.unknown"}, exitInvalidInput},
+		{"set unknown patch type", set(withPatch("{}")), []string{"--patch-type", "strategic", "--from-file", "/patch.json"}, exitInvalidInput},
+		{"set patch type with format", set(withPatch("{}")), []string{"--patch-type", "merge", "--from-file", "/patch.json", "--format", "json"}, exitInvalidInput},
+		{"set invalid merge patch", set(withPatch(`{"redpanda": `)), []string{"--patch-type", "merge", "--from-file", "/patch.json"}, exitInvalidInput},
+		{"set merge patch invalid value", set(withPatch(`{"redpanda": {"node_id": "one"}}`)), []string{"--patch-type", "merge", "--from-file", "/patch.json"}, exitInvalidInput},
+		{"set invalid json patch", set(withPatch(`[{"op": "merge", "path": "/redpanda"}]`)), []string{"--patch-type", "json", "--from-file", "/patch.json"}, exitInvalidInput},
+		{"set json patch missing key", set(withPatch(`[{"op": "remove", "path": "/redpanda/absent"}]`)), []string{"--patch-type", "json", "--from-file", "/patch.json"}, exitInvalidInput},
+		{"set json patch failed test", set(withPatch(`[{"op": "test", "path": "/redpanda/node_id", "value": 2}]`)), []string{"--patch-type", "json", "--from-file", "/patch.json"}, exitConflict},
+		{"set append and append-unique", set(afero.NewMemMapFs()), []string{"redpanda.seed_servers", "{}", "--append", "--append-unique"}, exitInvalidInput},
+		{"set append-unique type", set(afero.NewMemMapFs()), []string{"rpk.kafka_api.brokers", "a", "--append-unique", "--type", "string"}, exitInvalidInput},
+		{"bootstrap missing ips file", bootstrap(afero.NewMemMapFs()), []string{"--id", "1", "--self", "10.0.0.1", "--force-self", "--ips-file", "/ips.txt"}, exitIO},
+		{"bootstrap invalid ips file", bootstrap(withIPsFile("10.0.0.1:port\n")), []string{"--id", "1", "--self", "10.0.0.1", "--force-self", "--ips-file", "/ips.txt"}, exitInvalidInput},
+		{"set watch with append", set(afero.NewMemMapFs()), []string{"redpanda.seed_servers", "{}", "--append", "--watch"}, exitInvalidInput},
+		{"set watch with stdio", set(afero.NewMemMapFs()), []string{"redpanda.node_id", "1", "--watch", "--config", "-"}, exitInvalidInput},
+		{"set watch interval", set(afero.NewMemMapFs()), []string{"redpanda.node_id", "1", "--watch", "--watch-interval", "0s"}, exitInvalidInput},
+		{"tls enable missing cert", tls(afero.NewMemMapFs()), []string{"enable", "--cert", "/certs/node.crt", "--key", "/certs/node.key"}, exitInvalidInput},
+		{"tls enable without key", tls(withCerts()), []string{"enable", "--cert", "/certs/node.crt"}, exitInvalidInput},
+		{"tls client auth without truststore", tls(withCerts()), []string{"enable", "--cert", "/certs/node.crt", "--key", "/certs/node.key", "--require-client-auth"}, exitInvalidInput},
+		{"tls unknown listener", tls(withCerts()), []string{"enable", "--cert", "/certs/node.crt", "--key", "/certs/node.key", "--listener", "pandaproxy"}, exitInvalidInput},
+		{"seeds remove unknown id", seeds(withConfig("redpanda:\n  seed_servers:\n    - host: {address: 10.0.0.1, port: 33145}\n")), []string{"remove", "1"}, exitInvalidInput},
+		{"seeds remove unknown host", seeds(withConfig("redpanda:\n  seed_servers:\n    - host: {address: 10.0.0.1, port: 33145}\n")), []string{"remove", "10.0.0.1:33146"}, exitInvalidInput},
+		{"seeds add invalid", seeds(afero.NewMemMapFs()), []string{"add", "10.0.0.1:70000"}, exitInvalidInput},
+		{"invalid output", NewConfigCommand(withConfig("redpanda:\n  node_id: 1\n")), []string{"validate", "--output", "yaml"}, exitInvalidInput},
+		{"unset dry run output json", NewConfigCommand(withConfig("redpanda:\n  node_id: 1\n")), []string{"unset", "redpanda.node_id", "--dry-run", "--output", "json"}, exitInvalidInput},
+		{"import dry run output json", NewConfigCommand(withPatch("{}")), []string{"import", "/patch.json", "--dry-run", "--output", "json"}, exitInvalidInput},
+		{"get raw with default", get(afero.NewMemMapFs()), []string{"redpanda.node_id", "--raw", "--default"}, exitInvalidInput},
+		{"get raw with wildcard", get(afero.NewMemMapFs()), []string{"redpanda.*", "--raw"}, exitInvalidInput},
+		{"get raw output json", NewConfigCommand(afero.NewMemMapFs()), []string{"get", "redpanda.node_id", "--raw", "--output", "json"}, exitInvalidInput},
+		{"set if-match mismatch", set(withConfig("redpanda:\n  node_id: 1\n")), []string{"redpanda.node_id", "2", "--if-match", strings.Repeat("0", 64)}, exitConflict},
+	}
+	// The subprocess runs its command only, without spawning the others.
+	if run := os.Getenv("RPK_TEST_EXIT_CODE"); run != "" {
+		for _, test := range tests {
+			if strings.ReplaceAll(test.name, " ", "_") == run {
+				test.cmd.SetArgs(test.args)
+				test.cmd.Execute()
+				os.Exit(0)
+			}
+		}
+		t.Fatalf("unknown exit code test %s", run)
+	}
+	for _, test := range tests {
+		name := strings.ReplaceAll(test.name, " ", "_")
+		t.Run(name, func(t *testing.T) {
+			cmd := exec.Command(os.Args[0], "-test.run=^TestExitCodes$")
+			cmd.Env = append(os.Environ(), "RPK_TEST_EXIT_CODE="+name)
+			err := cmd.Run()
+			var exitErr *exec.ExitError
+			require.True(t, errors.As(err, &exitErr), "expected the command to fail, got %v", err)
+			require.Equal(t, test.exp, exitErr.ExitCode())
 		})
 	}
+	exists, err := afero.DirExists(afero.NewOsFs(), filepath.Dir(missingPath))
+	require.NoError(t, err)
+	require.False(t, exists, "a failing command created %s", filepath.Dir(missingPath))
 }
@@ -0,0 +1,208 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func tls(fs afero.Fs) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "tls",
+		Short: "Enable or disable TLS on the redpanda listeners",
+		Long: `Enable or disable TLS on the redpanda listeners.
+
+The TLS configuration of the Kafka API, admin API and RPC server listeners is
+a list of nested objects, matched to the listener addresses by name. These
+commands write or remove the whole object of a listener at once:
+
+  rpk redpanda config tls enable --listener kafka --cert /etc/redpanda/certs/node.crt --key /etc/redpanda/certs/node.key
+  rpk redpanda config tls disable --listener kafka
+`,
+	}
+	c.AddCommand(
+		tlsEnable(fs),
+		tlsDisable(fs),
+	)
+	return c
+}
+
+func tlsEnable(fs afero.Fs) *cobra.Command {
+	var (
+		listener          string
+		name              string
+		cert              string
+		key               string
+		truststore        string
+		requireClientAuth bool
+
+		configPath   string
+		lockTimeout  time.Duration
+		backup       bool
+		backupSuffix string
+	)
+	c := &cobra.Command{
+		Use:   "enable --listener <kafka|admin|rpc> --cert <path> --key <path>",
+		Short: "Enable TLS on a listener",
+		Long: `Enable TLS on a listener.
+
+This writes the TLS configuration of the --listener (kafka, admin or rpc),
+e.g. redpanda.kafka_api_tls for kafka, enabled and with the given certificate
+and key files, which must exist. With --truststore, the CA certificate to
+verify the clients with is set as well, and --require-client-auth, which
+requires --truststore, enables mTLS.
+
+A listener's TLS configuration applies to its addresses of the same name:
+--name defaults to the name of the first address of the listener. The current
+configuration of that name, if any, is replaced. The listener addresses are
+left untouched.
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			switch {
+			case cert == "" || key == "":
+				out.DieCode(exitInvalidInput, "both --cert and --key are required")
+			case requireClientAuth && truststore == "":
+				out.DieCode(exitInvalidInput, "--require-client-auth requires --truststore, to verify the clients with")
+			}
+			for _, f := range []struct{ flag, path string }{
+				{"cert", cert},
+				{"key", key},
+				{"truststore", truststore},
+			} {
+				if f.path == "" {
+					continue
+				}
+				if _, err := fs.Stat(f.path); err != nil {
+					out.DieCode(exitInvalidInput, "invalid --%s: %v", f.flag, err)
+				}
+			}
+
+			unlock, err := lockConfig(fs, cmd, lockTimeout)
+			maybeDieCode(err, exitIO, "%v", err)
+			defer unlock()
+
+			cfg, err := loadConfig(fs, cmd)
+			maybeDieLoad(err, "unable to load config: %v", err)
+			if !cmd.Flags().Changed("name") {
+				name, err = config.ListenerName(cfg, listener)
+				maybeDieCode(err, exitInvalidInput, "invalid --listener: %v", err)
+			}
+
+			orig := cfg.Clone()
+			err = config.SetListenerTLS(cfg, listener, config.ServerTLS{
+				Name:              name,
+				KeyFile:           key,
+				CertFile:          cert,
+				TruststoreFile:    truststore,
+				Enabled:           true,
+				RequireClientAuth: requireClientAuth,
+			})
+			maybeDieCode(err, exitInvalidInput, "invalid --listener: %v", err)
+			writeTLSConfig(fs, cmd, pr, orig, cfg, backup, backupSuffix)
+		},
+	}
+	c.Flags().StringVar(&listener, "listener", config.ListenerKafka, "Listener to enable TLS on (kafka/admin/rpc)")
+	c.Flags().StringVar(&name, "name", "", "Name of the listener addresses the TLS configuration applies to (default the name of the first address)")
+	c.Flags().StringVar(&cert, "cert", "", "Path of the PEM certificate of the listener")
+	c.Flags().StringVar(&key, "key", "", "Path of the PEM private key of the listener")
+	c.Flags().StringVar(&truststore, "truststore", "", "Path of the PEM CA certificate to verify the clients with")
+	c.Flags().BoolVar(&requireClientAuth, "require-client-auth", false, "Require the clients to present a certificate (mTLS)")
+	addTLSConfigFlags(c, &configPath, &lockTimeout, &backup, &backupSuffix)
+	return c
+}
+
+func tlsDisable(fs afero.Fs) *cobra.Command {
+	var (
+		listener string
+		name     string
+
+		configPath   string
+		lockTimeout  time.Duration
+		backup       bool
+		backupSuffix string
+	)
+	c := &cobra.Command{
+		Use:   "disable --listener <kafka|admin|rpc>",
+		Short: "Disable TLS on a listener",
+		Long: `Disable TLS on a listener.
+
+This removes the TLS configuration of the --listener (kafka, admin or rpc)
+that applies to its addresses named --name, which defaults to the name of the
+first address of the listener. If there is none, the file is left untouched.
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			unlock, err := lockConfig(fs, cmd, lockTimeout)
+			maybeDieCode(err, exitIO, "%v", err)
+			defer unlock()
+
+			cfg, err := loadConfig(fs, cmd)
+			maybeDieLoad(err, "unable to load config: %v", err)
+			if !cmd.Flags().Changed("name") {
+				name, err = config.ListenerName(cfg, listener)
+				maybeDieCode(err, exitInvalidInput, "invalid --listener: %v", err)
+			}
+
+			orig := cfg.Clone()
+			_, err = config.RemoveListenerTLS(cfg, listener, name)
+			maybeDieCode(err, exitInvalidInput, "invalid --listener: %v", err)
+			writeTLSConfig(fs, cmd, pr, orig, cfg, backup, backupSuffix)
+		},
+	}
+	c.Flags().StringVar(&listener, "listener", config.ListenerKafka, "Listener to disable TLS on (kafka/admin/rpc)")
+	c.Flags().StringVar(&name, "name", "", "Name of the listener addresses to disable TLS on (default the name of the first address)")
+	addTLSConfigFlags(c, &configPath, &lockTimeout, &backup, &backupSuffix)
+	return c
+}
+
+func addTLSConfigFlags(c *cobra.Command, configPath *string, lockTimeout *time.Duration, backup *bool, backupSuffix *string) {
+	c.Flags().StringVar(
+		configPath,
+		configFileFlag,
+		"",
+		configFileStdioDesc,
+	)
+	addConfigFormatFlag(c)
+	addLockTimeoutFlag(c, lockTimeout)
+	addBackupFlags(c, backup, backupSuffix)
+}
+
+// writeTLSConfig writes cfg and reports the keys that changed from orig,
+// unless there are none, in which case the config file is left untouched.
+func writeTLSConfig(fs afero.Fs, cmd *cobra.Command, pr *printer, orig, cfg *config.Config, backup bool, backupSuffix string) {
+	if cfg.File() != nil && config.ParamsFromCommand(cmd).ConfigPath != configStdio && config.Equal(orig, cfg) {
+		err := pr.Result(setResult{Changed: []setChange{}}, func(w io.Writer) { fmt.Fprintln(w, "no change") })
+		out.MaybeDieErr(err)
+		return
+	}
+	err := writeConfig(fs, cmd, cfg, backup, backupSuffix)
+	maybeDieCode(err, exitIO, "%v", err)
+	if config.ParamsFromCommand(cmd).ConfigPath == configStdio {
+		// Stdout is the configuration itself.
+		pr.w = cmd.ErrOrStderr()
+	}
+	err = printSetResult(pr, orig, cfg, "")
+	out.MaybeDieErr(err)
+}
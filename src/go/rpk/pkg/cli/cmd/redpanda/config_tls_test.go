@@ -0,0 +1,121 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigTLS(t *testing.T) {
+	for _, test := range []struct {
+		listener string
+		args     []string
+		exp      func(*config.RedpandaConfig) []config.ServerTLS
+	}{
+		{
+			listener: "kafka",
+			args:     []string{"--truststore", "/certs/ca.crt", "--require-client-auth"},
+			exp:      func(c *config.RedpandaConfig) []config.ServerTLS { return c.KafkaAPITLS },
+		},
+		{
+			listener: "admin",
+			exp:      func(c *config.RedpandaConfig) []config.ServerTLS { return c.AdminAPITLS },
+		},
+		{
+			listener: "rpc",
+			exp:      func(c *config.RedpandaConfig) []config.ServerTLS { return c.RPCServerTLS },
+		},
+	} {
+		t.Run(test.listener, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for _, f := range []string{"/certs/node.crt", "/certs/node.key", "/certs/ca.crt"} {
+				require.NoError(t, afero.WriteFile(fs, f, []byte("PEM"), 0o600))
+			}
+			run := func(args ...string) string {
+				var stdout bytes.Buffer
+				c := tls(fs)
+				c.SetOut(&stdout)
+				c.SetArgs(args)
+				require.NoError(t, c.Execute())
+				return stdout.String()
+			}
+
+			args := append([]string{"enable", "--listener", test.listener, "--cert", "/certs/node.crt", "--key", "/certs/node.key"}, test.args...)
+			require.Contains(t, run(args...), "cert_file: <unset> -> /certs/node.crt")
+			conf, err := new(config.Params).Load(fs)
+			require.NoError(t, err)
+			exp := config.ServerTLS{
+				CertFile: "/certs/node.crt",
+				KeyFile:  "/certs/node.key",
+				Enabled:  true,
+			}
+			if len(test.args) > 0 {
+				exp.TruststoreFile = "/certs/ca.crt"
+				exp.RequireClientAuth = true
+			}
+			require.Equal(t, []config.ServerTLS{exp}, test.exp(&conf.Redpanda))
+			// The listener addresses are untouched.
+			require.Equal(t, config.Default().Redpanda.KafkaAPI, conf.Redpanda.KafkaAPI)
+
+			// Enabling it again is a no-op.
+			require.Equal(t, "no change\n", run(args...))
+
+			run("disable", "--listener", test.listener)
+			conf, err = new(config.Params).Load(fs)
+			require.NoError(t, err)
+			require.Empty(t, test.exp(&conf.Redpanda))
+		})
+	}
+}
+
+func TestConfigTLSName(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  kafka_api:
+    - name: internal
+      address: 0.0.0.0
+      port: 9092
+    - name: external
+      address: 0.0.0.0
+      port: 9093
+  kafka_api_tls:
+    - name: internal
+      enabled: true
+      cert_file: /certs/old.crt
+      key_file: /certs/old.key
+`), 0o644))
+	for _, f := range []string{"/certs/node.crt", "/certs/node.key"} {
+		require.NoError(t, afero.WriteFile(fs, f, []byte("PEM"), 0o600))
+	}
+	for _, args := range [][]string{
+		{"enable", "--cert", "/certs/node.crt", "--key", "/certs/node.key"},
+		{"enable", "--cert", "/certs/node.crt", "--key", "/certs/node.key", "--name", "external"},
+	} {
+		c := tls(fs)
+		c.SetOut(io.Discard)
+		c.SetArgs(args)
+		require.NoError(t, c.Execute())
+	}
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, []config.ServerTLS{
+		{Name: "internal", CertFile: "/certs/node.crt", KeyFile: "/certs/node.key", Enabled: true},
+		{Name: "external", CertFile: "/certs/node.crt", KeyFile: "/certs/node.key", Enabled: true},
+	}, conf.Redpanda.KafkaAPITLS)
+}
@@ -0,0 +1,125 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func unset(fs afero.Fs) *cobra.Command {
+	var (
+		dryRun      bool
+		deleteEmpty bool
+		configPath  string
+		lockTimeout time.Duration
+	)
+	c := &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Unset configuration values, falling back to their defaults",
+		Long: `Unset configuration values, falling back to their defaults
+
+The key follows the same dotted notation used in 'rpk redpanda config set'.
+Elements of a list can be removed by their index, e.g:
+
+  rpk redpanda config unset redpanda.seed_servers.1
+
+Unsetting a key that is not present in the configuration does nothing. The
+keys that changed are printed along with their old and new values, as set
+does, e.g:
+
+  redpanda.rpc_server.port: 33146 -> 33145
+
+With --delete-empty, the maps of keys that rpk does not manage that are left
+empty by the unset are removed as well, up to the first managed key, e.g. to
+remove redpanda.tunables once its last key is unset:
+
+  rpk redpanda config unset redpanda.tunables.raft.timeout --delete-empty
+`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeKeys(fs),
+		Run: func(cmd *cobra.Command, args []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			if pr.json() && dryRun {
+				out.DieCode(exitInvalidInput, "--output %s cannot be used with --dry-run, which prints the config", pr.output)
+			}
+			p := config.ParamsFromCommand(cmd)
+			// unsetKey loads the config and unsets the key, returning
+			// the config before and after the unset.
+			unsetKey := func() (orig, cfg *config.Config) {
+				cfg, err := p.Load(fs)
+				out.MaybeDie(err, "unable to load config: %v", err)
+				logLoaded(cmd, cfg)
+				orig = cfg.Clone()
+
+				err = cfg.Unset(args[0])
+				out.MaybeDie(err, "unable to unset %q: %v", args[0], err)
+				if deleteEmpty {
+					err = cfg.PruneEmpty(args[0])
+					out.MaybeDie(err, "unable to prune %q: %v", args[0], err)
+				}
+				return orig, cfg
+			}
+			noChange := func() {
+				err := pr.Result(setResult{Changed: []setChange{}}, func(w io.Writer) { fmt.Fprintln(w, "no change") })
+				out.MaybeDieErr(err)
+			}
+
+			orig, cfg := unsetKey()
+			if dryRun {
+				b, err := yaml.Marshal(cfg)
+				out.MaybeDie(err, "unable to render config: %v", err)
+				fmt.Fprint(cmd.OutOrStdout(), string(b))
+				return
+			}
+			if config.Equal(orig, cfg) {
+				// Not even the lock file is created.
+				noChange()
+				return
+			}
+
+			unlock, err := p.LockConfig(fs, lockTimeout)
+			out.MaybeDieErr(err)
+			defer unlock()
+			// Again under the lock, for the write not to drop the
+			// changes of another rpk since the first load.
+			if orig, cfg = unsetKey(); config.Equal(orig, cfg) {
+				noChange()
+				return
+			}
+			logWrite(cmd, cfg)
+			err = cfg.Write(fs)
+			out.MaybeDieErr(err)
+			err = printSetResult(pr, orig, cfg, "")
+			out.MaybeDieErr(err)
+		},
+	}
+	c.Flags().BoolVar(&dryRun, "dry-run", false, "Print the resulting configuration instead of writing it")
+	c.Flags().BoolVar(&deleteEmpty, "delete-empty", false, "Remove the unmanaged maps left empty by the unset, along with their empty parents")
+	addLockTimeoutFlag(c, &lockTimeout)
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileFlagDesc,
+	)
+	return c
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsetCommand(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	run := func(fs afero.Fs, args ...string) string {
+		var b bytes.Buffer
+		c := unset(fs)
+		c.SetOut(&b)
+		c.SetArgs(args)
+		require.NoError(t, c.Execute())
+		return b.String()
+	}
+
+	// An unknown or absent key writes nothing, not even the lock file.
+	fs := afero.NewMemMapFs()
+	require.Equal(t, "no change\n", run(fs, "redpanda.not_a_key"))
+	require.Equal(t, "no change\n", run(fs, "redpanda.rack"))
+	files, err := afero.Glob(fs, "/etc/redpanda/*")
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	// A managed key falls back to its default.
+	require.NoError(t, afero.WriteFile(fs, path, []byte(`redpanda:
+  data_directory: /var/lib/redpanda/data
+  rpc_server:
+    address: 0.0.0.0
+    port: 33146
+  kafka_api:
+    - address: 0.0.0.0
+      port: 9092
+`), 0o644))
+	dry := run(fs, "redpanda.rpc_server.port", "--dry-run")
+	require.Contains(t, dry, "port: 33145")
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 33146, conf.Redpanda.RPCServer.Port, "--dry-run must not write")
+	_, err = fs.Stat(path + ".lock")
+	require.ErrorIs(t, err, os.ErrNotExist, "--dry-run must not lock")
+
+	require.Equal(t, "redpanda.rpc_server.port: 33146 -> 33145\n", run(fs, "redpanda.rpc_server.port"))
+	conf, err = new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 33145, conf.Redpanda.RPCServer.Port)
+	require.Empty(t, config.Validate(conf))
+}
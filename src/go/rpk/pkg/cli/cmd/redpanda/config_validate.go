@@ -0,0 +1,68 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func validate(fs afero.Fs) *cobra.Command {
+	var configPath string
+	c := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the configuration, reporting every problem found",
+		Long: `Validate the configuration, reporting every problem found
+
+This checks that every socket address is a valid IP or hostname with a port
+within 1-65535, that seed servers are unique, that no two of the RPC, Kafka API
+and Admin API listeners share an address and port, that the node ID is not
+negative, and that the rpk section has no unknown properties and holds booleans
+and integers where expected, among others. The builds embedding rpk can
+register checks of their own, e.g. that node IDs are below 1000, which run
+after these. The command exits with 4 if any problem is found, as well as if
+the config file cannot be decoded or, with --strict, has unknown keys.
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			pr, err := newPrinter(cmd)
+			maybeDieCode(err, exitInvalidInput, "%v", err)
+			p := config.ParamsFromCommand(cmd)
+			cfg, err := p.Load(fs)
+			maybeDieLoad(err, "unable to load config: %v", err)
+			logLoaded(cmd, cfg)
+
+			errs := config.Validate(cfg)
+			if len(errs) == 0 {
+				pr.Messagef("Configuration %q is valid.", cfg.ConfigFile)
+				return
+			}
+			for _, err := range errs {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			out.DieCode(exitInvalidConfig, "found %d problem(s) in %q", len(errs), cfg.ConfigFile)
+		},
+	}
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileFlagDesc,
+	)
+	return c
+}
@@ -0,0 +1,112 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"fmt"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func view(fs afero.Fs) *cobra.Command {
+	var (
+		format          string
+		includeDefaults bool
+		redact          bool
+		hash            bool
+		sortKeys        bool
+		configPath      string
+	)
+	c := &cobra.Command{
+		Use:   "view",
+		Short: "Print the effective configuration",
+		Long: `Print the effective configuration.
+
+Unlike the configuration file itself, this prints the configuration as rpk
+loads it, with any environment or flag override applied, including the
+REDPANDA_ID, REDPANDA_RPC_ADDRESS and REDPANDA_SEED_SERVERS environment
+variables that 'rpk redpanda start' honors. Fields that are absent from the
+configuration file are only filled with their default value if
+--include-defaults is set.
+
+The output can be printed as yaml (default), json, toml or hcl, which rpk
+only writes and never reads back, e.g. to pipe it into jq:
+
+  rpk redpanda config view --format json | jq .redpanda.seed_servers
+
+Use --redact to mask the values of the sensitive fields, such as SASL
+passwords and the license key, with ***, e.g. to share the configuration in a
+support ticket.
+
+With --hash, the SHA-256 of the content of the config file is printed instead,
+to pass to 'rpk redpanda config set --if-match'. If there is no config file,
+this is the hash of empty content.
+
+Use --sort-keys for a stable output, e.g. to check snapshots into git: the
+keys of the maps, such as the properties rpk does not manage, are then sorted
+bytewise, as in json, while the other keys follow their documented order.
+
+With --config -, the configuration is read from stdin.
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			if hash && configPath == configStdio {
+				out.Die("--hash cannot be used with --config %s, which has no config file", configStdio)
+			}
+			cfg, err := loadConfig(fs, cmd)
+			out.MaybeDie(err, "unable to load config: %v", err)
+			if hash {
+				h, err := cfg.FileHash(fs)
+				out.MaybeDie(err, "unable to hash config file: %v", err)
+				fmt.Fprintln(cmd.OutOrStdout(), h)
+				return
+			}
+			cfg, err = config.ApplyEnvOverrides(cfg)
+			out.MaybeDie(err, "unable to apply environment overrides: %v", err)
+
+			if includeDefaults {
+				cfg, err = cfg.WithDefaults(fs)
+				out.MaybeDie(err, "unable to fill defaults: %v", err)
+			}
+
+			if redact {
+				cfg, err = cfg.Redacted()
+				out.MaybeDie(err, "unable to redact config: %v", err)
+			}
+
+			render := config.Render
+			if sortKeys {
+				render = config.RenderSorted
+			}
+			b, err := render(cfg, format)
+			out.MaybeDieErr(err)
+			fmt.Fprint(cmd.OutOrStdout(), string(b))
+		},
+	}
+	c.Flags().StringVar(&format, "format", "yaml", "Output format (yaml/json/toml/hcl)")
+	c.Flags().BoolVar(&includeDefaults, "include-defaults", false, "Fill the fields absent from the config file with their default value")
+	c.Flags().BoolVar(&hash, "hash", false, "Print the hash of the config file, for set --if-match")
+	c.Flags().BoolVar(&redact, "redact", false, "Mask the values of sensitive fields, such as passwords, with ***")
+	c.Flags().BoolVar(&sortKeys, "sort-keys", false, "Sort the keys of the maps, for a stable output")
+	c.Flags().StringVar(
+		&configPath,
+		configFileFlag,
+		"",
+		configFileFlagDesc+`, or "-" to read it from stdin`,
+	)
+	addConfigFormatFlag(c)
+	return c
+}
@@ -0,0 +1,86 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestView(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		args   []string
+		expDir string
+	}{
+		{
+			name: "json",
+			args: []string{"--format", "json"},
+		},
+		{
+			name:   "json with defaults",
+			args:   []string{"--format", "json", "--include-defaults"},
+			expDir: config.Default().Redpanda.Directory,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  node_id: 3
+  developer_mode: false
+`), 0o644))
+
+			var b bytes.Buffer
+			c := view(fs)
+			c.SetOut(&b)
+			c.SetArgs(test.args)
+			require.NoError(t, c.Execute())
+
+			var cfg config.Config
+			require.NoError(t, json.Unmarshal(b.Bytes(), &cfg))
+			require.Equal(t, 3, cfg.Redpanda.ID)
+			require.Equal(t, test.expDir, cfg.Redpanda.Directory)
+			require.False(t, cfg.Redpanda.DeveloperMode)
+		})
+	}
+}
+
+func TestViewHCL(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  node_id: 3
+  seed_servers:
+    - host:
+        address: 10.0.0.1
+        port: 33145
+`), 0o644))
+
+	for _, args := range [][]string{
+		{"--format", "hcl"},
+		{"--format", "hcl", "--sort-keys"},
+	} {
+		var b bytes.Buffer
+		c := view(fs)
+		c.SetOut(&b)
+		c.SetArgs(args)
+		require.NoError(t, c.Execute())
+		require.Contains(t, b.String(), "redpanda {\n", "%v", args)
+		require.Contains(t, b.String(), "  node_id", "%v", args)
+		require.Contains(t, b.String(), "  seed_servers {\n    host {\n      address = \"10.0.0.1\"\n      port    = 33145\n    }\n  }\n", "%v", args)
+	}
+}
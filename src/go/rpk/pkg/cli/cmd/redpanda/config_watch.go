@@ -0,0 +1,141 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// setWatcher is set --watch: it watches the config file and, whenever it was
+// changed, sets the values again if the change drifted them.
+type setWatcher struct {
+	cmd         *cobra.Command
+	fs          afero.Fs
+	store       config.ConfigStore
+	path        string
+	lockTimeout time.Duration
+
+	// apply sets the values onto the configuration, and write writes it.
+	apply func(*config.Config) error
+	write func(*config.Config) error
+
+	pr *printer
+}
+
+// run watches the config file until ctx is done. The directory of the file is
+// watched with inotify rather than the file itself, for the file being
+// replaced by a rename, as rpk and most editors write it, not to end the
+// watch. If the directory cannot be watched, e.g. on a filesystem other than
+// the OS one, the file is polled every interval instead.
+func (w *setWatcher) run(ctx context.Context, interval time.Duration) {
+	logf(w.cmd, "Watching config file %s", w.path)
+	last, _ := afero.ReadFile(w.fs, w.path)
+	check := func() {
+		// A removed file is left to whoever removed it, rather
+		// than written again with the defaults.
+		b, err := afero.ReadFile(w.fs, w.path)
+		if err != nil || bytes.Equal(b, last) {
+			return
+		}
+		if err := w.correct(); err != nil {
+			fmt.Fprintf(w.cmd.ErrOrStderr(), "Warning: unable to set the values again: %v\n", err)
+		}
+		last, _ = afero.ReadFile(w.fs, w.path)
+	}
+
+	var (
+		events <-chan fsnotify.Event
+		errs   <-chan error
+		tick   <-chan time.Time
+	)
+	if watcher, err := w.watchDir(); err == nil {
+		defer watcher.Close()
+		events, errs = watcher.Events, watcher.Errors
+	} else {
+		logf(w.cmd, "Polling config file %s every %v, as it cannot be watched: %v", w.path, interval, err)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		tick = t.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			logf(w.cmd, "Stopped watching config file %s", w.path)
+			return
+		case <-tick:
+			check()
+		case ev := <-events:
+			if filepath.Clean(ev.Name) == filepath.Clean(w.path) {
+				check()
+			}
+		case err := <-errs:
+			// E.g. the event queue overflowed, and the change of
+			// the file may be one of the dropped events.
+			logf(w.cmd, "Error watching config file %s: %v", w.path, err)
+			check()
+		}
+	}
+}
+
+// watchDir returns the watcher of the events of the directory of the config
+// file.
+func (w *setWatcher) watchDir() (*fsnotify.Watcher, error) {
+	if _, ok := w.fs.(*afero.OsFs); !ok {
+		return nil, errors.New("the config file is not on the OS filesystem")
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(w.path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return watcher, nil
+}
+
+// correct sets the values onto the changed config file, which is only
+// written if they drifted.
+func (w *setWatcher) correct() error {
+	unlock, err := lockConfig(w.fs, w.cmd, w.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cfg, err := readStore(w.cmd, w.store)
+	if err != nil {
+		return fmt.Errorf("unable to load config: %v", err)
+	}
+	orig := cfg.Clone()
+	if err := w.apply(cfg); err != nil {
+		return err
+	}
+	if config.Equal(orig, cfg) {
+		return nil
+	}
+	if err := w.write(cfg); err != nil {
+		return err
+	}
+	return printSetResult(w.pr, orig, cfg, fmt.Sprintf("Corrected an external change of %s:", w.path))
+}
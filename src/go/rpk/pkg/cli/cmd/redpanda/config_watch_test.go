@@ -0,0 +1,133 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+//go:build linux
+// +build linux
+
+package redpanda
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetWatch(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, path, []byte("redpanda:\n  node_id: 1\n"), 0o644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var stdout lockedBuffer
+	c := set(fs)
+	c.SetOut(&stdout)
+	c.SetArgs([]string{"redpanda.node_id", "2", "--watch", "--watch-interval", "10ms"})
+	done := make(chan error)
+	go func() { done <- c.ExecuteContext(ctx) }()
+
+	nodeID := func() int {
+		conf, err := new(config.Params).Load(fs)
+		require.NoError(t, err)
+		return conf.Redpanda.ID
+	}
+	require.Eventually(t, func() bool { return nodeID() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	// An external edit of another key is kept.
+	require.NoError(t, afero.WriteFile(fs, path, []byte("redpanda:\n  node_id: 2\n  rack: r1\n"), 0o644))
+	time.Sleep(50 * time.Millisecond)
+	conf, err := new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, "r1", conf.Redpanda.Rack)
+
+	// A drift of the value is corrected.
+	require.NoError(t, afero.WriteFile(fs, path, []byte("redpanda:\n  node_id: 3\n  rack: r1\n"), 0o644))
+	require.Eventually(t, func() bool { return nodeID() == 2 }, 5*time.Second, 10*time.Millisecond)
+	conf, err = new(config.Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, "r1", conf.Redpanda.Rack)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("set --watch did not stop")
+	}
+	require.Equal(t, "redpanda.node_id: 1 -> 2\nCorrected an external change of "+path+":\nredpanda.node_id: 3 -> 2\n", stdout.String())
+}
+
+func TestSetWatchRename(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "redpanda.yaml")
+	fs := afero.NewOsFs()
+	require.NoError(t, afero.WriteFile(fs, path, []byte("redpanda:\n  node_id: 1\n"), 0o644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var stdout lockedBuffer
+	c := set(fs)
+	c.SetOut(&stdout)
+	// The interval is long enough for only the inotify events to see the
+	// change in time.
+	c.SetArgs([]string{"redpanda.node_id", "2", "--watch", "--watch-interval", "1h", "--config", path})
+	done := make(chan error)
+	go func() { done <- c.ExecuteContext(ctx) }()
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(stdout.String(), "redpanda.node_id: 1 -> 2\n")
+	}, 5*time.Second, 10*time.Millisecond)
+	// For the watch to have started.
+	time.Sleep(100 * time.Millisecond)
+
+	// The file is replaced by a rename, as editors write it.
+	tmp := path + ".tmp"
+	require.NoError(t, afero.WriteFile(fs, tmp, []byte("redpanda:\n  node_id: 3\n"), 0o644))
+	require.NoError(t, fs.Rename(tmp, path))
+	require.Eventually(t, func() bool {
+		return strings.Contains(stdout.String(), "redpanda.node_id: 3 -> 2\n")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("set --watch did not stop")
+	}
+	conf, err := (&config.Params{ConfigPath: path}).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 2, conf.Redpanda.ID)
+}
+
+// lockedBuffer is a bytes.Buffer that a command can write to while the test
+// reads it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
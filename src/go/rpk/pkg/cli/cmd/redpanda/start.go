@@ -350,6 +350,11 @@ func NewStartCommand(fs afero.Fs, launcher rp.Launcher) *cobra.Command {
 			}
 
 			err = cfg.Write(fs)
+			if err == nil {
+				// redpanda does not know about includes, it reads
+				// the config with the included values resolved.
+				rpArgs.ConfigFilePath, err = cfg.WriteResolved(fs)
+			}
 			if err != nil {
 				sendEnv(fs, env, cfg, !prestartCfg.checkEnabled, err)
 				return err
@@ -774,6 +774,45 @@ func TestStartCommand(t *testing.T) {
 				conf.Redpanda.RPCServer,
 			)
 		},
+	}, {
+		name: "it should hand redpanda the config with the included values resolved",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+		},
+		before: func(fs afero.Fs) error {
+			if err := afero.WriteFile(fs, "/etc/redpanda/rack.yaml", []byte("redpanda:\n  rack: r1\n"), 0o644); err != nil {
+				return err
+			}
+			cfg := config.Default()
+			cfg.Includes = []string{"rack.yaml"}
+			return cfg.Write(fs)
+		},
+		postCheck: func(fs afero.Fs, rpArgs *redpanda.RedpandaArgs, st *testing.T) {
+			require.Equal(st, "/etc/redpanda/redpanda.resolved.yaml", rpArgs.ConfigFilePath)
+			b, err := afero.ReadFile(fs, rpArgs.ConfigFilePath)
+			require.NoError(st, err)
+			require.Contains(st, string(b), "rack: r1\n")
+			require.NotContains(st, string(b), "includes:")
+			b, err = afero.ReadFile(fs, config.Default().ConfigFile)
+			require.NoError(st, err)
+			require.NotContains(st, string(b), "rack:", "the included values should not be copied into the config file")
+		},
+	}, {
+		name: "it should not persist REDPANDA_ID, which only applies to the config of view",
+		args: []string{
+			"--install-dir", "/var/lib/redpanda",
+		},
+		before: func(_ afero.Fs) error {
+			return os.Setenv(config.EnvNodeID, "7")
+		},
+		after: func() {
+			os.Unsetenv(config.EnvNodeID)
+		},
+		postCheck: func(fs afero.Fs, _ *redpanda.RedpandaArgs, st *testing.T) {
+			conf, err := new(config.Params).Load(fs)
+			require.NoError(st, err)
+			require.Equal(st, config.Default().Redpanda.ID, conf.Redpanda.ID)
+		},
 	}, {
 		name: "it should leave the RPC addr untouched if the env var & flag weren't set",
 		args: []string{
@@ -0,0 +1,51 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import "gopkg.in/yaml.v3"
+
+// Canonicalize returns the canonical form of the configuration, which is the
+// configuration as it is read back once written: writing and reading back a
+// canonical configuration returns it unchanged. In the canonical form,
+//
+//  * the nil lists that are written as empty lists, such as
+//    redpanda.kafka_api, are empty,
+//  * the values of unmanaged keys are as YAML decodes them, e.g. nested maps
+//    are map[string]interface{},
+//  * the deprecated fields are back-compat'ed and the derived values, such
+//    as rpk.kafka_api.brokers and rpk.admin_api.addresses, are set, as Load
+//    does.
+//
+// Map keys have no order in the configuration and are always written sorted,
+// so that the canonical form renders deterministically. The returned copy is
+// tied to the same config file as conf. A configuration that cannot be
+// rendered, which Write would fail on, is returned as a shallow copy.
+func Canonicalize(conf *Config) *Config {
+	cp := *conf
+	b, err := yaml.Marshal(&cp)
+	if err != nil {
+		return &cp
+	}
+	c := loadDefaults(conf.ConfigFile)
+	if err := yaml.Unmarshal(b, c); err != nil {
+		return &cp
+	}
+	c.backcompat()
+	c.addUnsetDefaults()
+
+	c.file = conf.file
+	c.fileNode = conf.fileNode
+	c.included = conf.included
+	c.loadedPath = conf.loadedPath
+	c.format = conf.format
+	c.invalidRpk = conf.invalidRpk
+	c.comments = conf.comments
+	return c
+}
@@ -0,0 +1,173 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// randConfig generates a configuration exercising the fields that do not
+// round-trip as is: omitted empty fields, nil pointers, deprecated fields and
+// unmanaged keys.
+func randConfig(r *rand.Rand) *Config {
+	c := Default()
+	randString := func() string {
+		return []string{"", "a", "r1", "a b", "true", "1", "x: y"}[r.Intn(7)]
+	}
+	randAddrs := func() []NamedSocketAddress {
+		var addrs []NamedSocketAddress
+		for i := r.Intn(3); i > 0; i-- {
+			addrs = append(addrs, NamedSocketAddress{fmt.Sprintf("10.0.0.%d", r.Intn(4)), r.Intn(3) * 9092, randString()})
+		}
+		return addrs
+	}
+	var randValue func(depth int) interface{}
+	randValue = func(depth int) interface{} {
+		switch n := r.Intn(5); {
+		case n == 0:
+			return r.Intn(100)
+		case n == 1:
+			return r.Intn(2) == 0
+		case n == 2 || depth > 2:
+			return randString() + "s"
+		default:
+			m := make(map[string]interface{})
+			for i := r.Intn(3); i > 0; i-- {
+				m[fmt.Sprintf("k%d", r.Intn(5))] = randValue(depth + 1)
+			}
+			return m
+		}
+	}
+	randOther := func() map[string]interface{} {
+		if r.Intn(2) == 0 {
+			return nil
+		}
+		m := make(map[string]interface{})
+		for i := r.Intn(3); i > 0; i-- {
+			m[fmt.Sprintf("extra_%d", r.Intn(5))] = randValue(0)
+		}
+		return m
+	}
+
+	rp := &c.Redpanda
+	rp.ID = r.Intn(10)
+	rp.Rack = randString()
+	rp.DeveloperMode = r.Intn(2) == 0
+	for i := r.Intn(4); i > 0; i-- {
+		rp.SeedServers = append(rp.SeedServers, SeedServer{SocketAddress{fmt.Sprintf("10.0.0.%d", r.Intn(4)), 33145}})
+	}
+	rp.KafkaAPI = randAddrs()
+	rp.AdminAPI = randAddrs()
+	rp.AdvertisedKafkaAPI = randAddrs()
+	if r.Intn(2) == 0 {
+		rp.AdvertisedRPCAPI = &SocketAddress{"10.0.0.1", r.Intn(2) * 33145}
+	}
+	rp.Other = randOther()
+
+	rpk := &c.Rpk
+	rpk.TuneNetwork = r.Intn(2) == 0
+	rpk.CoredumpDir = randString()
+	rpk.KafkaAPI.Brokers = []string(nil)
+	for i := r.Intn(3); i > 0; i-- {
+		rpk.KafkaAPI.Brokers = append(rpk.KafkaAPI.Brokers, fmt.Sprintf("10.0.0.%d:9092", i))
+	}
+	if r.Intn(2) == 0 {
+		rpk.TLS = &TLS{CertFile: randString()}
+	}
+	if r.Intn(2) == 0 {
+		smp := r.Intn(3)
+		rpk.SMP = &smp
+	}
+
+	switch r.Intn(3) {
+	case 0:
+		c.Pandaproxy = nil
+	case 1:
+		c.Pandaproxy = &Pandaproxy{PandaproxyAPI: randAddrs(), Other: randOther()}
+	}
+	if r.Intn(2) == 0 {
+		c.SchemaRegistry = nil
+	}
+	if r.Intn(2) == 0 {
+		c.PandaproxyClient = &KafkaClient{Other: randOther()}
+	}
+	c.Other = randOther()
+	return c
+}
+
+// exported returns the configuration without its unexported fields, which
+// are not part of what is written.
+func exported(c *Config) Config {
+	return Config{
+		Version:              c.Version,
+		Includes:             c.Includes,
+		NodeUUID:             c.NodeUUID,
+		Organization:         c.Organization,
+		LicenseKey:           c.LicenseKey,
+		ClusterID:            c.ClusterID,
+		ConfigFile:           c.ConfigFile,
+		Redpanda:             c.Redpanda,
+		Rpk:                  c.Rpk,
+		Pandaproxy:           c.Pandaproxy,
+		PandaproxyClient:     c.PandaproxyClient,
+		SchemaRegistry:       c.SchemaRegistry,
+		SchemaRegistryClient: c.SchemaRegistryClient,
+		Other:                c.Other,
+	}
+}
+
+func TestCanonicalizeRoundTrip(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	// The generated unmanaged keys are warned about on every load.
+	defer log.SetLevel(log.GetLevel())
+	log.SetLevel(log.ErrorLevel)
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		canon := Canonicalize(randConfig(r))
+
+		fs := afero.NewMemMapFs()
+		require.NoError(t, canon.Write(fs), "config %d", i)
+		read, err := (&Params{ConfigPath: path}).Load(fs)
+		require.NoError(t, err, "config %d", i)
+		require.Equal(t, exported(canon), exported(read), "config %d", i)
+
+		require.Equal(t, exported(canon), exported(Canonicalize(canon)), "config %d: Canonicalize is not idempotent", i)
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	c := Default()
+	c.Redpanda.AdminAPI = nil
+	c.Redpanda.Other = map[string]interface{}{"b": map[string]interface{}{"c": int64(1)}, "a": 1}
+	c.Rpk.TLS = &TLS{CertFile: "cert.pem"}
+
+	canon := Canonicalize(c)
+	require.Equal(t, []NamedSocketAddress{}, canon.Redpanda.AdminAPI)
+	require.Equal(t, c.Rpk.TLS, canon.Rpk.KafkaAPI.TLS)
+	require.Equal(t, c.Rpk.TLS, canon.Rpk.AdminAPI.TLS)
+	require.Equal(t, []string{"0.0.0.0:9092"}, canon.Rpk.KafkaAPI.Brokers)
+	require.Equal(t, []string{"127.0.0.1:9644"}, canon.Rpk.AdminAPI.Addresses)
+	require.Equal(t, map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": 1}}, canon.Redpanda.Other)
+	require.Zero(t, c.Version, "the configuration must not be modified")
+	require.Nil(t, c.Redpanda.AdminAPI, "the configuration must not be modified")
+
+	a, err := Render(canon, FormatYAML)
+	require.NoError(t, err)
+	b, err := Render(Canonicalize(canon), FormatYAML)
+	require.NoError(t, err)
+	require.Equal(t, string(a), string(b))
+}
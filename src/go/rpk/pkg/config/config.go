@@ -12,6 +12,9 @@ package config
 import (
 	"fmt"
 	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -56,6 +59,121 @@ func Default() *Config {
 	}
 }
 
+// WithDefaults returns a copy of the configuration in which every field that
+// is absent from the loaded configuration file is set to its value in
+// Default(). If no file was loaded, the configuration is already based on the
+// defaults and this returns an unchanged copy.
+func (c *Config) WithDefaults(fs afero.Fs) (*Config, error) {
+	var cfgNode, defNode yaml.Node
+	if err := cfgNode.Encode(c); err != nil {
+		return nil, err
+	}
+	if err := defNode.Encode(Default()); err != nil {
+		return nil, err
+	}
+	if c.loadedPath != "" {
+		b, err := afero.ReadFile(fs, c.loadedPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %q: %v", c.loadedPath, err)
+		}
+		if c.format == FormatTOML {
+			if b, err = tomlToYAML(b); err != nil {
+				return nil, fmt.Errorf("unable to parse %q: %v", c.loadedPath, err)
+			}
+		}
+		var fileNode yaml.Node
+		if err := yaml.Unmarshal(b, &fileNode); err != nil {
+			return nil, fmt.Errorf("unable to parse %q: %v", c.loadedPath, err)
+		}
+		if len(fileNode.Content) > 0 {
+			fillDefaults(&cfgNode, &defNode, fileNode.Content[0])
+		}
+	}
+
+	merged := new(Config)
+	if err := cfgNode.Decode(merged); err != nil {
+		return nil, err
+	}
+	merged.file = c.file
+	merged.fileNode = c.fileNode
+	merged.included = c.included
+	merged.loadedPath = c.loadedPath
+	merged.format = c.format
+	merged.ConfigFile = c.ConfigFile
+	return merged, nil
+}
+
+// MergeFragment returns a copy of the configuration with the YAML or JSON
+// fragment deep merged onto it: mappings are merged recursively, while
+// scalars and lists from the fragment replace the current ones.
+func (c *Config) MergeFragment(fragment []byte) (*Config, error) {
+	var frag yaml.Node
+	if err := yaml.Unmarshal(fragment, &frag); err != nil {
+		return nil, fmt.Errorf("unable to parse fragment: %v", err)
+	}
+	if len(frag.Content) == 0 {
+		return c, nil
+	}
+	if frag.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("fragment must be an object, got %s", frag.Content[0].ShortTag())
+	}
+
+	var cfgNode yaml.Node
+	if err := cfgNode.Encode(c); err != nil {
+		return nil, err
+	}
+	overlayNode(&cfgNode, frag.Content[0])
+
+	merged := new(Config)
+	if err := cfgNode.Decode(merged); err != nil {
+		return nil, fmt.Errorf("unable to decode merged config: %v", err)
+	}
+	merged.file = c.file
+	merged.fileNode = c.fileNode
+	merged.included = c.included
+	merged.loadedPath = c.loadedPath
+	merged.format = c.format
+	merged.ConfigFile = c.ConfigFile
+	return merged, nil
+}
+
+// Replace returns fresh, a configuration decoded on its own such as with
+// ReadFromBytes, tied to the file c was loaded from: Write writes it there,
+// in the format of that file, as it would write c. Nothing of c but the file
+// is kept, so that the keys absent from fresh are absent once written.
+func (c *Config) Replace(fresh *Config) *Config {
+	replaced := *fresh
+	replaced.file = c.file
+	replaced.fileNode = c.fileNode
+	replaced.included = c.included
+	replaced.loadedPath = c.loadedPath
+	replaced.format = c.format
+	replaced.ConfigFile = c.ConfigFile
+	return &replaced
+}
+
+// Overrides returns the YAML mapping of the configuration keys whose value
+// differs from Default(), keeping the nesting of the configuration. Lists are
+// kept whole if any of their elements differ, and config_file and
+// config_version are never included.
+//
+// The values that Load derives for absent keys, such as
+// rpk.kafka_api.brokers, are not defaults: they depend on the rest of the
+// configuration, so they are kept.
+func (c *Config) Overrides() (*yaml.Node, error) {
+	var cfgNode, defNode yaml.Node
+	if err := cfgNode.Encode(c); err != nil {
+		return nil, err
+	}
+	if err := defNode.Encode(Default()); err != nil {
+		return nil, err
+	}
+	pruneDefaults(&cfgNode, &defNode)
+	deleteMappingKey(&cfgNode, "config_file")
+	deleteMappingKey(&cfgNode, "config_version")
+	return &cfgNode, nil
+}
+
 func SetMode(mode string, conf *Config) (*Config, error) {
 	m, err := NormalizeMode(mode)
 	if err != nil {
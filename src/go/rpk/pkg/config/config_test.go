@@ -10,11 +10,14 @@
 package config
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/utils"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func getValidConfig() *Config {
@@ -52,6 +55,7 @@ func TestSet(t *testing.T) {
 		key       string
 		value     string
 		format    string
+		before    func(c *Config)
 		check     func(st *testing.T, c *Config)
 		expectErr bool
 	}{
@@ -284,6 +288,58 @@ tune_cpu: true`,
 				require.Exactly(st, 80, c.Redpanda.SeedServers[0].Host.Port)
 			},
 		},
+		{
+			name:  "set slice element by index in place",
+			key:   "redpanda.seed_servers.1.host.address",
+			value: "10.0.0.2",
+			before: func(c *Config) {
+				c.Redpanda.SeedServers = []SeedServer{
+					{Host: SocketAddress{"10.0.0.1", 33145}},
+					{Host: SocketAddress{"10.0.0.5", 33145}},
+				}
+			},
+			check: func(st *testing.T, c *Config) {
+				require.Exactly(st, []SeedServer{
+					{Host: SocketAddress{"10.0.0.1", 33145}},
+					{Host: SocketAddress{"10.0.0.2", 33145}},
+				}, c.Redpanda.SeedServers)
+			},
+		},
+		{
+			name:  "append slice element one past the end",
+			key:   "redpanda.seed_servers.1",
+			value: `{host: {address: 10.0.0.2, port: 33146}}`,
+			before: func(c *Config) {
+				c.Redpanda.SeedServers = []SeedServer{
+					{Host: SocketAddress{"10.0.0.1", 33145}},
+				}
+			},
+			check: func(st *testing.T, c *Config) {
+				require.Exactly(st, []SeedServer{
+					{Host: SocketAddress{"10.0.0.1", 33145}},
+					{Host: SocketAddress{"10.0.0.2", 33146}},
+				}, c.Redpanda.SeedServers)
+			},
+		},
+		{
+			name:  "append to an empty slice",
+			key:   "redpanda.advertised_kafka_api.0.port",
+			value: "9093",
+			check: func(st *testing.T, c *Config) {
+				require.Exactly(st, []NamedSocketAddress{{Port: 9093}}, c.Redpanda.AdvertisedKafkaAPI)
+			},
+		},
+		{
+			name:  "fail if the index leaves a gap",
+			key:   "redpanda.seed_servers.5.host.address",
+			value: "10.0.0.2",
+			before: func(c *Config) {
+				c.Redpanda.SeedServers = []SeedServer{
+					{Host: SocketAddress{"10.0.0.1", 33145}},
+				}
+			},
+			expectErr: true,
+		},
 		{
 			name:      "fail if the value isn't well formatted (json)",
 			key:       "redpanda",
@@ -318,6 +374,24 @@ tune_cpu: true`,
 			value:     "foo",
 			expectErr: true,
 		},
+		{
+			name:      "fail if an unknown rpk property is passed",
+			key:       "rpk.tune_netwrok",
+			value:     "true",
+			expectErr: true,
+		},
+		{
+			name:      "fail if an rpk toggle is not a boolean",
+			key:       "rpk.tune_network",
+			value:     "yes please",
+			expectErr: true,
+		},
+		{
+			name:      "fail if rpk.smp is not an integer",
+			key:       "rpk.smp",
+			value:     "two",
+			expectErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -325,6 +399,9 @@ tune_cpu: true`,
 			fs := afero.NewMemMapFs()
 			cfg, err := new(Params).Load(fs)
 			require.NoError(t, err)
+			if tt.before != nil {
+				tt.before(cfg)
+			}
 			err = cfg.Set(tt.key, tt.value, tt.format)
 			if tt.expectErr {
 				require.Error(t, err)
@@ -338,6 +415,905 @@ tune_cpu: true`,
 	}
 }
 
+func TestAppend(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		value     string
+		format    string
+		before    func(c *Config)
+		check     func(st *testing.T, c *Config)
+		expectErr bool
+	}{
+		{
+			name:  "append a scalar",
+			key:   "rpk.kafka_api.brokers",
+			value: "127.0.0.2:9092",
+			before: func(c *Config) {
+				c.Rpk.KafkaAPI.Brokers = []string{"127.0.0.1:9092"}
+			},
+			check: func(st *testing.T, c *Config) {
+				require.Equal(st, []string{"127.0.0.1:9092", "127.0.0.2:9092"}, c.Rpk.KafkaAPI.Brokers)
+			},
+		},
+		{
+			name:  "append a scalar to an empty list",
+			key:   "rpk.kafka_api.brokers",
+			value: "127.0.0.1:9092",
+			before: func(c *Config) {
+				c.Rpk.KafkaAPI.Brokers = nil
+			},
+			check: func(st *testing.T, c *Config) {
+				require.Equal(st, []string{"127.0.0.1:9092"}, c.Rpk.KafkaAPI.Brokers)
+			},
+		},
+		{
+			name:  "append an object",
+			key:   "redpanda.seed_servers",
+			value: "{host: {address: 10.0.0.2, port: 33145}}",
+			before: func(c *Config) {
+				c.Redpanda.SeedServers = []SeedServer{{Host: SocketAddress{"10.0.0.1", 33145}}}
+			},
+			check: func(st *testing.T, c *Config) {
+				require.Equal(st, []SeedServer{
+					{Host: SocketAddress{"10.0.0.1", 33145}},
+					{Host: SocketAddress{"10.0.0.2", 33145}},
+				}, c.Redpanda.SeedServers)
+			},
+		},
+		{
+			name:   "append a json object",
+			key:    "redpanda.kafka_api",
+			value:  `{"name": "external", "address": "0.0.0.0", "port": 9093}`,
+			format: "json",
+			check: func(st *testing.T, c *Config) {
+				require.Equal(st, NamedSocketAddress{Name: "external", Address: "0.0.0.0", Port: 9093}, c.Redpanda.KafkaAPI[len(c.Redpanda.KafkaAPI)-1])
+			},
+		},
+		{
+			name:  "append to an unmanaged list",
+			key:   "redpanda.unmanaged_list",
+			value: "b",
+			before: func(c *Config) {
+				c.Redpanda.Other = map[string]interface{}{"unmanaged_list": []interface{}{"a"}}
+			},
+			check: func(st *testing.T, c *Config) {
+				require.Equal(st, []interface{}{"a", "b"}, c.Redpanda.Other["unmanaged_list"])
+			},
+		},
+		{
+			name:      "fail if the key is not a list",
+			key:       "redpanda.node_id",
+			value:     "1",
+			expectErr: true,
+		},
+		{
+			name:      "fail if the unmanaged key is not a list",
+			key:       "redpanda.unmanaged",
+			value:     "1",
+			expectErr: true,
+		},
+		{
+			name:      "fail if the value does not match the list elements",
+			key:       "redpanda.seed_servers",
+			value:     "[1, 2]",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			cfg, err := new(Params).Load(fs)
+			require.NoError(t, err)
+			if tt.before != nil {
+				tt.before(cfg)
+			}
+			err = cfg.Append(tt.key, tt.value, tt.format)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.check != nil {
+				tt.check(t, cfg)
+			}
+		})
+	}
+}
+
+func TestAppendUnique(t *testing.T) {
+	seeds := func() []SeedServer {
+		return []SeedServer{
+			{Host: SocketAddress{"10.0.0.1", 33145}},
+			{Host: SocketAddress{"10.0.0.2", 33145}},
+		}
+	}
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		exp   func(c *Config)
+	}{
+		{
+			name:  "append a new seed",
+			key:   "redpanda.seed_servers",
+			value: "{host: {address: 10.0.0.3, port: 33145}}",
+			exp: func(c *Config) {
+				c.Redpanda.SeedServers = append(seeds(), SeedServer{Host: SocketAddress{"10.0.0.3", 33145}})
+			},
+		},
+		{
+			name:  "existing seed is a no-op",
+			key:   "redpanda.seed_servers",
+			value: "{host: {port: 33145, address: 10.0.0.2}}",
+			exp:   func(*Config) {},
+		},
+		{
+			name:  "same address on another port is new",
+			key:   "redpanda.seed_servers",
+			value: "{host: {address: 10.0.0.2, port: 33146}}",
+			exp: func(c *Config) {
+				c.Redpanda.SeedServers = append(seeds(), SeedServer{Host: SocketAddress{"10.0.0.2", 33146}})
+			},
+		},
+		{
+			name:  "existing unmanaged element is a no-op",
+			key:   "redpanda.unmanaged_list",
+			value: "{a: 1}",
+			exp:   func(*Config) {},
+		},
+		{
+			name:  "new unmanaged element",
+			key:   "redpanda.unmanaged_list",
+			value: "b",
+			exp: func(c *Config) {
+				c.Redpanda.Other["unmanaged_list"] = []interface{}{map[string]interface{}{"a": 1}, "b"}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := func() *Config {
+				c := Default()
+				c.Redpanda.SeedServers = seeds()
+				c.Redpanda.Other = map[string]interface{}{
+					"unmanaged_list": []interface{}{map[string]interface{}{"a": 1}},
+				}
+				return c
+			}
+			c := base()
+			require.NoError(t, c.AppendUnique(tt.key, tt.value, ""))
+			exp := base()
+			tt.exp(exp)
+			require.Equal(t, exp.Redpanda, c.Redpanda)
+		})
+	}
+
+	require.Error(t, Default().AppendUnique("redpanda.node_id", "1", ""))
+}
+
+func TestSetTyped(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		value     string
+		typ       string
+		check     func(st *testing.T, c *Config)
+		expectErr bool
+	}{
+		{
+			name:  "unmanaged string that reads as an integer",
+			key:   "redpanda.unmanaged",
+			value: "100",
+			typ:   "string",
+			check: func(st *testing.T, c *Config) {
+				require.Equal(st, "100", c.Redpanda.Other["unmanaged"])
+			},
+		},
+		{
+			name:  "unmanaged integer",
+			key:   "redpanda.unmanaged",
+			value: "100",
+			typ:   "int",
+			check: func(st *testing.T, c *Config) {
+				require.Equal(st, 100, c.Redpanda.Other["unmanaged"])
+			},
+		},
+		{
+			name:  "unmanaged float",
+			key:   "pandaproxy.unmanaged",
+			value: "0.5",
+			typ:   "float",
+			check: func(st *testing.T, c *Config) {
+				require.Equal(st, 0.5, c.Pandaproxy.Other["unmanaged"])
+			},
+		},
+		{
+			name:  "managed integer",
+			key:   "redpanda.node_id",
+			value: "3",
+			typ:   "int",
+			check: func(st *testing.T, c *Config) {
+				require.Equal(st, 3, c.Redpanda.ID)
+			},
+		},
+		{
+			name:  "managed pointer",
+			key:   "schema_registry.schema_registry_replication_factor",
+			value: "3",
+			typ:   "int",
+			check: func(st *testing.T, c *Config) {
+				require.Equal(st, 3, *c.SchemaRegistry.SchemaRegistryReplicationFactor)
+			},
+		},
+		{
+			name:  "managed string",
+			key:   "redpanda.rack",
+			value: "1",
+			typ:   "string",
+			check: func(st *testing.T, c *Config) {
+				require.Equal(st, "1", c.Redpanda.Rack)
+			},
+		},
+		{
+			name:      "fail if the field is of another type",
+			key:       "redpanda.node_id",
+			value:     "3",
+			typ:       "string",
+			expectErr: true,
+		},
+		{
+			name:      "fail if the field is an object",
+			key:       "redpanda.rpc_server",
+			value:     "true",
+			typ:       "bool",
+			expectErr: true,
+		},
+		{
+			name:      "fail if the value is not of the type",
+			key:       "redpanda.unmanaged",
+			value:     "abc",
+			typ:       "int",
+			expectErr: true,
+		},
+		{
+			name:      "fail on unknown types",
+			key:       "redpanda.unmanaged",
+			value:     "abc",
+			typ:       "bytes",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			err := cfg.SetTyped(tt.key, tt.value, tt.typ)
+			if tt.expectErr {
+				require.ErrorIs(t, err, ErrInvalidFormat)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestSetList(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		value     string
+		sep       string
+		format    string
+		exp       []string
+		expectErr bool
+	}{
+		{
+			name:  "comma list",
+			key:   "rpk.kafka_api.brokers",
+			value: "a,b,c",
+			sep:   ",",
+			exp:   []string{"a", "b", "c"},
+		},
+		{
+			name:  "trimmed elements",
+			key:   "rpk.admin_api.addresses",
+			value: " 10.0.0.1:9644 , 10.0.0.2:9644",
+			sep:   ",",
+			exp:   []string{"10.0.0.1:9644", "10.0.0.2:9644"},
+		},
+		{
+			name:  "custom separator",
+			key:   "rpk.kafka_api.brokers",
+			value: "a,b;c",
+			sep:   ";",
+			exp:   []string{"a,b", "c"},
+		},
+		{
+			name:  "empty value",
+			key:   "rpk.kafka_api.brokers",
+			value: "",
+			sep:   ",",
+			exp:   []string{},
+		},
+		{
+			name:   "set single",
+			key:    "rpk.kafka_api.brokers",
+			value:  "a,b,c",
+			format: "single",
+			exp:    []string{"a", "b", "c"},
+		},
+		{
+			name:   "set single flow sequence",
+			key:    "rpk.kafka_api.brokers",
+			value:  "[a, 'b,c']",
+			format: "single",
+			exp:    []string{"a", "b,c"},
+		},
+		{
+			name:      "fail if the field is not a list of strings",
+			key:       "redpanda.seed_servers",
+			value:     "a,b",
+			sep:       ",",
+			expectErr: true,
+		},
+		{
+			name:      "fail if the field is a scalar",
+			key:       "redpanda.rack",
+			value:     "a,b",
+			sep:       ",",
+			expectErr: true,
+		},
+		{
+			name:      "fail if the field is unmanaged",
+			key:       "redpanda.unmanaged",
+			value:     "a,b",
+			sep:       ",",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			var err error
+			if tt.format != "" {
+				err = cfg.Set(tt.key, tt.value, tt.format)
+			} else {
+				err = cfg.SetList(tt.key, tt.value, tt.sep)
+			}
+			if tt.expectErr {
+				require.ErrorIs(t, err, ErrInvalidFormat)
+				return
+			}
+			require.NoError(t, err)
+			v, err := lookupField(strings.Split(tt.key, "."), reflect.ValueOf(cfg).Elem())
+			require.NoError(t, err)
+			require.Equal(t, tt.exp, v.Interface())
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		format   string
+		exp      string
+		notFound bool
+		expErr   bool
+	}{
+		{
+			name: "nested scalar",
+			key:  "redpanda.rpc_server.port",
+			exp:  "33145",
+		},
+		{
+			name:   "scalar with single format",
+			key:    "redpanda.data_directory",
+			format: "single",
+			exp:    "/var/lib/redpanda/data",
+		},
+		{
+			name: "object as yaml",
+			key:  "redpanda.rpc_server",
+			exp:  "address: 0.0.0.0\nport: 33145",
+		},
+		{
+			name:   "object as json",
+			key:    "redpanda.kafka_api",
+			format: "json",
+			exp:    `[{"address":"0.0.0.0","port":9092}]`,
+		},
+		{
+			name:   "indexed slice element",
+			key:    "redpanda.admin.0.port",
+			format: "single",
+			exp:    "9644",
+		},
+		{
+			name:   "first slice element if there is no index",
+			key:    "redpanda.kafka_api.port",
+			format: "json",
+			exp:    "9092",
+		},
+		{
+			name:   "nil pointer resolves to its zero value",
+			key:    "rpk.kafka_api.tls",
+			format: "json",
+			exp:    `{"key_file":"","cert_file":"","truststore_file":""}`,
+		},
+		{
+			name:   "unmanaged field",
+			key:    "redpanda.enable_idempotence",
+			format: "single",
+			exp:    "true",
+		},
+		{
+			name:     "unknown field",
+			key:      "redpanda.not_a_key",
+			notFound: true,
+		},
+		{
+			name:     "index out of range",
+			key:      "redpanda.admin.3",
+			notFound: true,
+		},
+		{
+			name:     "field of a scalar",
+			key:      "redpanda.rpc_server.port.foo",
+			notFound: true,
+		},
+		{
+			name:   "fail if the format isn't supported",
+			key:    "redpanda.node_id",
+			format: "toml",
+			expErr: true,
+		},
+		{
+			name:   "fail if no key is passed",
+			expErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Redpanda.Other = map[string]interface{}{"enable_idempotence": true}
+
+			v, err := cfg.Get(tt.key, tt.format)
+			if tt.notFound {
+				require.ErrorIs(t, err, ErrKeyNotFound)
+				return
+			}
+			if tt.expErr {
+				require.Error(t, err)
+				require.NotErrorIs(t, err, ErrKeyNotFound)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.exp, v)
+		})
+	}
+}
+
+func TestGetRawNull(t *testing.T) {
+	cfg, err := ReadFromBytes([]byte("redpanda:\n  extra: null\n  rack: r1\n"), FormatYAML)
+	require.NoError(t, err)
+	_, err = cfg.GetRaw("redpanda.extra", "")
+	require.EqualError(t, err, `"redpanda.extra" is null, which has no raw value`)
+	v, err := cfg.GetRaw("redpanda.rack", "")
+	require.NoError(t, err)
+	require.Equal(t, "r1", v)
+}
+
+func TestGlob(t *testing.T) {
+	cfg := Default()
+	cfg.Redpanda.KafkaAPI = []NamedSocketAddress{
+		{Address: "10.0.0.1", Port: 9092, Name: "internal"},
+		{Address: "10.0.0.2", Port: 19092, Name: "external"},
+	}
+	cfg.Redpanda.Other = map[string]interface{}{
+		"tuning": map[string]interface{}{"address": "unmanaged"},
+	}
+
+	for _, test := range []struct {
+		pattern string
+		exp     []string
+		expErr  bool
+	}{
+		{
+			pattern: "redpanda.*.address",
+			exp: []string{
+				"redpanda.admin.0.address",
+				"redpanda.kafka_api.0.address",
+				"redpanda.kafka_api.1.address",
+				"redpanda.rpc_server.address",
+				"redpanda.tuning.address",
+			},
+		},
+		{
+			pattern: "redpanda.kafka_api.*.port",
+			exp:     []string{"redpanda.kafka_api.0.port", "redpanda.kafka_api.1.port"},
+		},
+		{
+			pattern: "redpanda.kafka_api.1.*",
+			exp:     []string{"redpanda.kafka_api.1.address", "redpanda.kafka_api.1.name", "redpanda.kafka_api.1.port"},
+		},
+		{
+			pattern: "*.kafka_api.name",
+			exp:     []string{"redpanda.kafka_api.0.name", "redpanda.kafka_api.1.name"},
+		},
+		{pattern: "redpanda.*.nope", expErr: true},
+		{pattern: "redpanda.advertised_rpc_api.*", expErr: true}, // nil
+		{pattern: "", expErr: true},
+	} {
+		t.Run(test.pattern, func(t *testing.T) {
+			keys, err := cfg.Glob(test.pattern)
+			if test.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.exp, keys)
+			for _, k := range keys {
+				_, err := cfg.Get(k, "")
+				require.NoError(t, err, k)
+			}
+		})
+	}
+}
+
+func TestIsPinned(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  node_id: 0
+  developer_mode: false
+  rpc_server:
+    port: 33145
+  seed_servers:
+    - host:
+        address: 10.0.0.1
+  enable_idempotence: true
+`), 0o644))
+	cfg, err := new(Params).Load(fs)
+	require.NoError(t, err)
+
+	for key, exp := range map[string]bool{
+		"redpanda.node_id":                     false, // default value
+		"redpanda.developer_mode":              true,  // non default zero value
+		"redpanda.rpc_server.port":             false,
+		"redpanda.rpc_server.address":          false, // absent
+		"redpanda.seed_servers":                true,
+		"redpanda.seed_servers.0.host.address": true,
+		"redpanda.seed_servers.host.address":   true,
+		"redpanda.seed_servers.1.host.address": false,
+		"redpanda.seed_servers.0.host.port":    false,
+		"redpanda.enable_idempotence":          true, // unmanaged
+		"rpk.tune_network":                     false,
+	} {
+		require.Equal(t, exp, cfg.IsPinned(key), key)
+	}
+
+	require.False(t, Default().IsPinned("redpanda.node_id"))
+}
+
+func TestUnset(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		cfg       func(c *Config)
+		check     func(st *testing.T, c *Config)
+		expectErr bool
+	}{
+		{
+			name: "reset managed field to its zero value",
+			key:  "rpk.tune_network",
+			cfg:  func(c *Config) { c.Rpk.TuneNetwork = true },
+			check: func(st *testing.T, c *Config) {
+				require.False(st, c.Rpk.TuneNetwork)
+			},
+		},
+		{
+			name: "reset nested managed field to its default",
+			key:  "redpanda.rpc_server.port",
+			cfg:  func(c *Config) { c.Redpanda.RPCServer.Port = 33146 },
+			check: func(st *testing.T, c *Config) {
+				require.Exactly(st, SocketAddress{Address: "0.0.0.0", Port: 33145}, c.Redpanda.RPCServer)
+			},
+		},
+		{
+			name: "reset list element field to its default",
+			key:  "redpanda.kafka_api.0.port",
+			cfg:  func(c *Config) { c.Redpanda.KafkaAPI[0].Port = 9093 },
+			check: func(st *testing.T, c *Config) {
+				require.Equal(st, 9092, c.Redpanda.KafkaAPI[0].Port)
+			},
+		},
+		{
+			name: "reset field without a default to its zero value",
+			key:  "redpanda.kafka_api.1.port",
+			cfg: func(c *Config) {
+				c.Redpanda.KafkaAPI = append(c.Redpanda.KafkaAPI, NamedSocketAddress{Address: "10.0.0.1", Port: 9093})
+			},
+			check: func(st *testing.T, c *Config) {
+				require.Equal(st, NamedSocketAddress{Address: "10.0.0.1"}, c.Redpanda.KafkaAPI[1])
+			},
+		},
+		{
+			name: "reset managed section to its default",
+			key:  "redpanda.seed_servers",
+			cfg: func(c *Config) {
+				c.Redpanda.SeedServers = []SeedServer{{SocketAddress{"10.0.0.1", 33145}}}
+			},
+			check: func(st *testing.T, c *Config) {
+				require.Equal(st, Default().Redpanda.SeedServers, c.Redpanda.SeedServers)
+			},
+		},
+		{
+			name: "remove and compact a slice element",
+			key:  "redpanda.seed_servers.1",
+			cfg: func(c *Config) {
+				c.Redpanda.SeedServers = []SeedServer{
+					{SocketAddress{"10.0.0.1", 33145}},
+					{SocketAddress{"10.0.0.2", 33145}},
+					{SocketAddress{"10.0.0.3", 33145}},
+				}
+			},
+			check: func(st *testing.T, c *Config) {
+				require.Exactly(st, []SeedServer{
+					{SocketAddress{"10.0.0.1", 33145}},
+					{SocketAddress{"10.0.0.3", 33145}},
+				}, c.Redpanda.SeedServers)
+			},
+		},
+		{
+			name: "delete unmanaged field",
+			key:  "redpanda.enable_idempotence",
+			cfg:  func(c *Config) { c.Redpanda.Other = map[string]interface{}{"enable_idempotence": true, "foo": "bar"} },
+			check: func(st *testing.T, c *Config) {
+				require.Exactly(st, map[string]interface{}{"foo": "bar"}, c.Redpanda.Other)
+			},
+		},
+		{
+			name: "delete nested unmanaged field",
+			key:  "pandaproxy.foo.bar",
+			cfg: func(c *Config) {
+				c.Pandaproxy.Other = map[string]interface{}{"foo": map[string]interface{}{"bar": 1, "baz": 2}}
+			},
+			check: func(st *testing.T, c *Config) {
+				require.Exactly(st, map[string]interface{}{"foo": map[string]interface{}{"baz": 2}}, c.Pandaproxy.Other)
+			},
+		},
+		{
+			name: "unknown key is a no-op",
+			key:  "redpanda.not_a_key.really",
+			check: func(st *testing.T, c *Config) {
+				require.Exactly(st, Default().Redpanda, c.Redpanda)
+			},
+		},
+		{
+			name: "out of range index is a no-op",
+			key:  "redpanda.kafka_api.4",
+			check: func(st *testing.T, c *Config) {
+				require.Len(st, c.Redpanda.KafkaAPI, 1)
+			},
+		},
+		{
+			name:      "fail if no key is passed",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			if tt.cfg != nil {
+				tt.cfg(cfg)
+			}
+			err := cfg.Unset(tt.key)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.check != nil {
+				tt.check(t, cfg)
+			}
+		})
+	}
+}
+
+func TestPruneEmpty(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		other map[string]interface{}
+		prune bool
+		exp   map[string]interface{}
+	}{
+		{
+			name:  "prunes the emptied parents",
+			other: map[string]interface{}{"foo": map[string]interface{}{"bar": map[string]interface{}{"baz": 1}}, "qux": 2},
+			prune: true,
+			exp:   map[string]interface{}{"qux": 2},
+		},
+		{
+			name:  "keeps the parents without pruning",
+			other: map[string]interface{}{"foo": map[string]interface{}{"bar": map[string]interface{}{"baz": 1}}, "qux": 2},
+			exp:   map[string]interface{}{"foo": map[string]interface{}{"bar": map[string]interface{}{}}, "qux": 2},
+		},
+		{
+			name:  "stops at a non empty parent",
+			other: map[string]interface{}{"foo": map[string]interface{}{"bar": map[string]interface{}{"baz": 1}, "quux": 3}},
+			prune: true,
+			exp:   map[string]interface{}{"foo": map[string]interface{}{"quux": 3}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := Default()
+			c.Redpanda.Other = test.other
+			require.NoError(t, c.Unset("redpanda.foo.bar.baz"))
+			if test.prune {
+				require.NoError(t, c.PruneEmpty("redpanda.foo.bar.baz"))
+			}
+			require.Exactly(t, test.exp, c.Redpanda.Other)
+		})
+	}
+
+	t.Run("removes an empty map", func(t *testing.T) {
+		c := Default()
+		c.Redpanda.Other = map[string]interface{}{"foo": map[string]interface{}{}}
+		require.NoError(t, c.PruneEmpty("redpanda.foo"))
+		require.Empty(t, c.Redpanda.Other)
+	})
+
+	t.Run("keeps modeled fields", func(t *testing.T) {
+		c := Default()
+		c.Rpk.KafkaAPI.Brokers = []string{}
+		require.NoError(t, c.PruneEmpty("rpk.kafka_api.brokers"))
+		require.NotNil(t, c.Rpk.KafkaAPI.Brokers)
+		require.NoError(t, c.PruneEmpty("pandaproxy_client"))
+	})
+}
+
+func TestWithDefaults(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	err := afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  node_id: 3
+  developer_mode: false
+  rpc_server:
+    port: 33146
+`), 0o644)
+	require.NoError(t, err)
+
+	cfg, err := new(Params).Load(fs)
+	require.NoError(t, err)
+	require.Empty(t, cfg.Redpanda.Directory)
+
+	merged, err := cfg.WithDefaults(fs)
+	require.NoError(t, err)
+
+	def := Default()
+	require.Equal(t, 3, merged.Redpanda.ID)
+	require.False(t, merged.Redpanda.DeveloperMode)
+	require.Equal(t, def.Redpanda.Directory, merged.Redpanda.Directory)
+	require.Equal(t, def.Redpanda.KafkaAPI, merged.Redpanda.KafkaAPI)
+	// rpc_server is in the file, so only its absent address is filled.
+	require.Equal(t, SocketAddress{def.Redpanda.RPCServer.Address, 33146}, merged.Redpanda.RPCServer)
+	require.Equal(t, def.Rpk.CoredumpDir, merged.Rpk.CoredumpDir)
+	// Unset defaults that are added on load are kept.
+	require.Equal(t, cfg.Rpk.KafkaAPI, merged.Rpk.KafkaAPI)
+	require.Equal(t, cfg.ConfigFile, merged.ConfigFile)
+}
+
+func TestMergeFragment(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	err := afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  node_id: 3
+  rack: r1
+  kafka_api:
+    - address: 0.0.0.0
+      port: 9092
+  seed_servers:
+    - host:
+        address: 10.0.0.1
+        port: 33145
+`), 0o644)
+	require.NoError(t, err)
+	cfg, err := new(Params).Load(fs)
+	require.NoError(t, err)
+
+	merged, err := cfg.MergeFragment([]byte(`redpanda:
+  rack: r2
+  kafka_api:
+    - address: 10.0.0.3
+      port: 9093
+      name: internal
+  seed_servers: []
+rpk:
+  tune_network: true
+  kafka_api:
+    tls:
+      truststore_file: /etc/ca.pem
+`))
+	require.NoError(t, err)
+
+	// Untouched keys are kept.
+	require.Equal(t, 3, merged.Redpanda.ID)
+	require.Equal(t, cfg.Redpanda.RPCServer, merged.Redpanda.RPCServer)
+	// Scalars and lists are replaced.
+	require.Equal(t, "r2", merged.Redpanda.Rack)
+	require.Equal(t, []NamedSocketAddress{{Address: "10.0.0.3", Port: 9093, Name: "internal"}}, merged.Redpanda.KafkaAPI)
+	require.Empty(t, merged.Redpanda.SeedServers)
+	// New nested blocks are added.
+	require.True(t, merged.Rpk.TuneNetwork)
+	require.Equal(t, &TLS{TruststoreFile: "/etc/ca.pem"}, merged.Rpk.KafkaAPI.TLS)
+	require.Equal(t, cfg.Rpk.KafkaAPI.Brokers, merged.Rpk.KafkaAPI.Brokers)
+	require.Equal(t, cfg.ConfigFile, merged.ConfigFile)
+	// The receiver is not modified.
+	require.Equal(t, "r1", cfg.Redpanda.Rack)
+
+	merged, err = cfg.MergeFragment([]byte(`{"redpanda": {"node_id": 4}}`))
+	require.NoError(t, err)
+	require.Equal(t, 4, merged.Redpanda.ID)
+	require.Equal(t, "r1", merged.Redpanda.Rack)
+
+	_, err = cfg.MergeFragment([]byte(`- redpanda`))
+	require.Error(t, err)
+}
+
+func TestReplace(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.toml"
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, path, []byte("[redpanda]\nnode_id = 3\nrack = \"r1\"\n"), 0o644))
+	cfg, err := (&Params{ConfigPath: path}).Load(fs)
+	require.NoError(t, err)
+
+	fresh, err := ReadFromBytes([]byte("redpanda:\n  node_id: 4\n"), FormatYAML)
+	require.NoError(t, err)
+	replaced := cfg.Replace(fresh)
+	require.Equal(t, path, replaced.ConfigFile)
+	require.Empty(t, replaced.Redpanda.Rack)
+
+	// It is written to the file cfg was loaded from, in its format.
+	require.NoError(t, replaced.Write(fs))
+	b, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	require.Contains(t, string(b), "node_id = 4")
+	require.NotContains(t, string(b), "rack")
+}
+
+func TestOverrides(t *testing.T) {
+	cfg := Default()
+	n, err := cfg.Overrides()
+	require.NoError(t, err)
+	require.Empty(t, n.Content, "a default config should have no overrides")
+
+	cfg.ConfigFile = "/etc/redpanda/other.yaml"
+	cfg.Redpanda.ID = 3
+	cfg.Redpanda.RPCServer.Address = "10.0.0.1"
+	cfg.Redpanda.AdminAPI = append(cfg.Redpanda.AdminAPI, NamedSocketAddress{Address: "10.0.0.1", Port: 9645})
+	cfg.Rpk.TuneCPU = true
+	n, err = cfg.Overrides()
+	require.NoError(t, err)
+
+	b, err := yaml.Marshal(n)
+	require.NoError(t, err)
+	require.Equal(t, `redpanda:
+    node_id: 3
+    rpc_server:
+        address: 10.0.0.1
+    admin:
+        - address: 0.0.0.0
+          port: 9644
+        - address: 10.0.0.1
+          port: 9645
+rpk:
+    tune_cpu: true
+`, string(b))
+}
+
 func TestDefault(t *testing.T) {
 	defaultConfig := Default()
 	expected := &Config{
@@ -851,3 +1827,67 @@ func TestCheckConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestUnknownKeys(t *testing.T) {
+	const file = `redpanda:
+  node_id: 1
+  kafak_api:
+    - address: 0.0.0.0
+      port: 9092
+  kafka_api:
+    - address: 0.0.0.0
+      port: 9092
+      nmae: internal
+  seed_servers:
+    - host:
+        address: 10.0.0.1
+        prot: 33145
+rpk:
+  tune_network: true
+pandaproxy: {}
+`
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(file), 0o644))
+
+	cfg, err := new(Params).Load(fs)
+	require.NoError(t, err, "unknown keys are only a warning by default")
+	exp := []string{
+		"redpanda.kafak_api",
+		"redpanda.kafka_api.0.nmae",
+		"redpanda.seed_servers.0.host.prot",
+	}
+	require.Equal(t, exp, cfg.UnknownKeys())
+	require.Equal(t, 1, cfg.Redpanda.ID)
+
+	_, err = (&Params{Strict: true}).Load(fs)
+	require.ErrorIs(t, err, ErrUnknownKey)
+	require.Contains(t, err.Error(), strings.Join(exp, ", "))
+
+	_, err = (&Params{Strict: true}).LoadFrom(strings.NewReader(file))
+	require.ErrorIs(t, err, ErrUnknownKey)
+
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte("redpanda:\n  node_id: 1\n"), 0o644))
+	cfg, err = (&Params{Strict: true}).Load(fs)
+	require.NoError(t, err)
+	require.Empty(t, cfg.UnknownKeys())
+}
+
+func TestIsManaged(t *testing.T) {
+	for key, exp := range map[string]bool{
+		"redpanda":                             true,
+		"redpanda.node_id":                     true,
+		"redpanda.seed_servers":                true,
+		"redpanda.seed_servers.1":              true,
+		"redpanda.seed_servers.1.host.address": true,
+		"redpanda.seed_servers.host.address":   true,
+		"rpk.kafka_api.sasl.password":          true,
+		"schema_registry.schema_registry_api":  true,
+		"redpanda.kafak_api":                   false,
+		"redpanda.seed_servers.0.host.prot":    false,
+		"redpanda.node_id.sub":                 false,
+		"unmanaged":                            false,
+		"":                                     false,
+	} {
+		require.Equal(t, exp, IsManaged(key), key)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/afero"
+)
+
+// dataDirMode is the mode of the data directory that EnsureDataDir creates.
+// Redpanda runs as its own user, which owns the directory.
+const dataDirMode = 0o755
+
+// CheckDataDir checks that the data directory of the configuration,
+// redpanda.data_directory, exists, is a directory, and that files can be
+// created in it.
+//
+// If the directory is missing, the returned error is an os.ErrNotExist. If
+// it is not writable, the returned error is an ErrWritePermission.
+func CheckDataDir(fs afero.Fs, conf *Config) error {
+	return checkDataDir(fs, conf, false)
+}
+
+// EnsureDataDir is CheckDataDir, but it creates the data directory and its
+// missing parents if it does not exist, rather than failing.
+func EnsureDataDir(fs afero.Fs, conf *Config) error {
+	return checkDataDir(fs, conf, true)
+}
+
+func checkDataDir(fs afero.Fs, conf *Config, create bool) error {
+	dir := conf.Redpanda.Directory
+	if dir == "" {
+		return errors.New("redpanda.data_directory is not set")
+	}
+	info, err := fs.Stat(dir)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && create:
+		if err := fs.MkdirAll(dir, dataDirMode); err != nil {
+			return permissionKind(fmt.Errorf("unable to create the data directory %s: %w", dir, err))
+		}
+	case err != nil:
+		return fmt.Errorf("unable to stat the data directory %s: %w", dir, err)
+	case !info.IsDir():
+		return fmt.Errorf("the data directory %s is not a directory", dir)
+	}
+
+	// A write probe, as the mode bits do not tell whether this process
+	// can write, e.g. on a read-only mount.
+	f, err := afero.TempFile(fs, dir, ".rpk-probe-")
+	if err != nil {
+		return permissionKind(fmt.Errorf("the data directory %s is not writable: %w", dir, err))
+	}
+	f.Close()
+	fs.Remove(f.Name())
+	return nil
+}
+
+// permissionKind returns err as an ErrWritePermission if it is due to
+// missing permissions.
+func permissionKind(err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return withKind(ErrWritePermission, err)
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureDataDir(t *testing.T) {
+	withDir := func(dir string) *Config {
+		c := Default()
+		c.Redpanda.Directory = dir
+		return c
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		c := withDir("/var/lib/redpanda/data")
+		require.ErrorIs(t, CheckDataDir(fs, c), os.ErrNotExist)
+
+		require.NoError(t, EnsureDataDir(fs, c))
+		info, err := fs.Stat("/var/lib/redpanda/data")
+		require.NoError(t, err)
+		require.True(t, info.IsDir())
+		require.Equal(t, os.FileMode(dataDirMode), info.Mode().Perm())
+		require.NoError(t, CheckDataDir(fs, c))
+	})
+
+	t.Run("present", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, fs.MkdirAll("/data", 0o700))
+		require.NoError(t, afero.WriteFile(fs, "/data/segment", []byte("x"), 0o644))
+		require.NoError(t, EnsureDataDir(fs, withDir("/data")))
+
+		// The directory is left as is, without the write probe.
+		info, err := fs.Stat("/data")
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+		files, err := afero.ReadDir(fs, "/data")
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+	})
+
+	t.Run("read-only", func(t *testing.T) {
+		base := afero.NewMemMapFs()
+		require.NoError(t, base.MkdirAll("/data", 0o755))
+		fs := afero.NewReadOnlyFs(base)
+		require.ErrorIs(t, CheckDataDir(fs, withDir("/data")), ErrWritePermission)
+		require.ErrorIs(t, EnsureDataDir(fs, withDir("/data")), ErrWritePermission)
+		require.ErrorIs(t, EnsureDataDir(fs, withDir("/missing")), ErrWritePermission)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/data", nil, 0o644))
+		require.Error(t, EnsureDataDir(fs, withDir("/data")))
+		require.Error(t, EnsureDataDir(fs, withDir("")))
+	})
+}
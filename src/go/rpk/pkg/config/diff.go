@@ -0,0 +1,117 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldDiff is a key whose value differs between two configurations.
+type FieldDiff struct {
+	// Key is the dotted key of the value, as Set and Get take it, e.g.
+	// redpanda.seed_servers.0.host.address.
+	Key string
+
+	// Old and New are the values of the key in the first and the second
+	// configuration, nil if the key is absent from that configuration.
+	// Empty objects and lists are values too, an empty
+	// map[string]interface{} and []interface{}.
+	Old, New interface{}
+}
+
+// Diff returns the keys whose values differ from a to b, sorted by key.
+//
+// Both configurations are walked recursively as they are written, unmanaged
+// properties included, down to their scalar values. List elements are
+// compared by index, so that an element added to a list is a new key. The
+// config_file key is ignored, since it is where the file was loaded from
+// rather than part of its contents.
+func Diff(a, b *Config) ([]FieldDiff, error) {
+	fa, err := flatten(a)
+	if err != nil {
+		return nil, err
+	}
+	fb, err := flatten(b)
+	if err != nil {
+		return nil, err
+	}
+	delete(fa, "config_file")
+	delete(fb, "config_file")
+
+	var diffs []FieldDiff
+	for k, va := range fa {
+		if vb := fb[k]; !reflect.DeepEqual(va, vb) {
+			diffs = append(diffs, FieldDiff{Key: k, Old: va, New: vb})
+		}
+	}
+	for k, vb := range fb {
+		if _, ok := fa[k]; !ok && vb != nil {
+			diffs = append(diffs, FieldDiff{Key: k, New: vb})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Key < diffs[j].Key })
+	return diffs, nil
+}
+
+// flatten returns every leaf value of the configuration, keyed by its dotted
+// path. Empty objects and lists are leaves as well, so that they show up in
+// diffs.
+func flatten(c *Config) (map[string]interface{}, error) {
+	var n yaml.Node
+	if err := n.Encode(c); err != nil {
+		return nil, fmt.Errorf("unable to encode config: %v", err)
+	}
+	flat := make(map[string]interface{})
+	if err := flattenNode("", &n, flat); err != nil {
+		return nil, err
+	}
+	return flat, nil
+}
+
+func flattenNode(prefix string, n *yaml.Node, flat map[string]interface{}) error {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		if len(n.Content) == 0 {
+			flat[prefix] = map[string]interface{}{}
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if err := flattenNode(join(n.Content[i].Value), n.Content[i+1], flat); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		if len(n.Content) == 0 {
+			flat[prefix] = []interface{}{}
+		}
+		for i, v := range n.Content {
+			if err := flattenNode(join(strconv.Itoa(i)), v, flat); err != nil {
+				return err
+			}
+		}
+	default:
+		var v interface{}
+		if err := n.Decode(&v); err != nil {
+			return fmt.Errorf("unable to decode %s: %v", prefix, err)
+		}
+		flat[prefix] = v
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		modify func(*Config)
+		exp    []FieldDiff
+	}{
+		{
+			name:   "equal",
+			modify: func(*Config) {},
+		},
+		{
+			name: "scalars",
+			modify: func(c *Config) {
+				c.Redpanda.ID = 3
+				c.Redpanda.DeveloperMode = false
+				c.Redpanda.Rack = "rack-1"
+			},
+			exp: []FieldDiff{
+				{Key: "redpanda.developer_mode", Old: true, New: false},
+				{Key: "redpanda.node_id", Old: 0, New: 3},
+				{Key: "redpanda.rack", New: "rack-1"},
+			},
+		},
+		{
+			name: "map keys",
+			modify: func(c *Config) {
+				c.Redpanda.Other = map[string]interface{}{
+					"added": "x",
+					"tuning": map[string]interface{}{
+						"a": 2,
+					},
+				}
+			},
+			exp: []FieldDiff{
+				{Key: "redpanda.added", New: "x"},
+				{Key: "redpanda.tuning.a", Old: 1, New: 2},
+				{Key: "redpanda.tuning.removed", Old: true},
+			},
+		},
+		{
+			name: "slices",
+			modify: func(c *Config) {
+				c.Redpanda.SeedServers = []SeedServer{
+					{Host: SocketAddress{"10.0.0.1", 33145}},
+					{Host: SocketAddress{"10.0.0.3", 33145}},
+				}
+				c.Rpk.KafkaAPI.Brokers = nil
+			},
+			exp: []FieldDiff{
+				{Key: "redpanda.seed_servers.1.host.address", New: "10.0.0.3"},
+				{Key: "redpanda.seed_servers.1.host.port", New: 33145},
+				{Key: "rpk.kafka_api.brokers.0", Old: "10.0.0.1:9092"},
+			},
+		},
+		{
+			name: "empty list",
+			modify: func(c *Config) {
+				c.Redpanda.SeedServers = []SeedServer{}
+			},
+			exp: []FieldDiff{
+				{Key: "redpanda.seed_servers", New: []interface{}{}},
+				{Key: "redpanda.seed_servers.0.host.address", Old: "10.0.0.1"},
+				{Key: "redpanda.seed_servers.0.host.port", Old: 33145},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			a := Default()
+			a.Redpanda.SeedServers = []SeedServer{{Host: SocketAddress{"10.0.0.1", 33145}}}
+			a.Redpanda.Other = map[string]interface{}{
+				"tuning": map[string]interface{}{
+					"a":       1,
+					"removed": true,
+				},
+			}
+			a.Rpk.KafkaAPI.Brokers = []string{"10.0.0.1:9092"}
+
+			b := Default()
+			b.ConfigFile = "/elsewhere/redpanda.yaml"
+			b.Redpanda.SeedServers = a.Redpanda.SeedServers
+			b.Redpanda.Other = a.Redpanda.Other
+			b.Rpk.KafkaAPI.Brokers = a.Rpk.KafkaAPI.Brokers
+			test.modify(b)
+
+			diffs, err := Diff(a, b)
+			require.NoError(t, err)
+			require.Equal(t, test.exp, diffs)
+		})
+	}
+}
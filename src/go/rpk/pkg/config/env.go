@@ -0,0 +1,246 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	vnet "github.com/redpanda-data/redpanda/src/go/rpk/pkg/net"
+	"gopkg.in/yaml.v3"
+)
+
+// These are the environment variables ApplyEnvOverrides maps onto the
+// redpanda node configuration.
+const (
+	EnvNodeID      = "REDPANDA_ID"
+	EnvRPCAddress  = "REDPANDA_RPC_ADDRESS"
+	EnvSeedServers = "REDPANDA_SEED_SERVERS"
+)
+
+// ApplyEnvOverrides returns a copy of c with the following environment
+// variables applied, when set:
+//
+//   REDPANDA_ID:           redpanda.node_id.
+//   REDPANDA_RPC_ADDRESS:  redpanda.rpc_server, as host[:port]. The port
+//                          defaults to 33145.
+//   REDPANDA_SEED_SERVERS: redpanda.seed_servers, as a comma separated list
+//                          of host[:port], parsed with ParseSeedServers.
+//
+// Empty variables are ignored. The precedence order is: defaults < config
+// file < environment. As c is copied, the environment is not persisted if c
+// is written afterwards.
+func ApplyEnvOverrides(c *Config) (*Config, error) {
+	o := *c
+	if v, ok := lookupEnv(EnvNodeID); ok {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", EnvNodeID, v, err)
+		}
+		o.Redpanda.ID = id
+	}
+	if v, ok := lookupEnv(EnvRPCAddress); ok {
+		seeds, err := ParseSeedServers([]string{v}, Default().Redpanda.RPCServer.Port)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", EnvRPCAddress, v, err)
+		}
+		o.Redpanda.RPCServer = seeds[0].Host
+	}
+	if v, ok := lookupEnv(EnvSeedServers); ok {
+		var addrs []string
+		for _, addr := range strings.Split(v, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				addrs = append(addrs, addr)
+			}
+		}
+		seeds, err := ParseSeedServers(addrs, Default().Redpanda.RPCServer.Port)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", EnvSeedServers, v, err)
+		}
+		o.Redpanda.SeedServers = seeds
+	}
+	return &o, nil
+}
+
+// lookupEnv returns the trimmed value of the environment variable, and whether
+// it is set to a non empty value.
+func lookupEnv(key string) (string, bool) {
+	v := strings.TrimSpace(os.Getenv(key))
+	return v, v != ""
+}
+
+// ParseSeedServers parses a list of seed server addresses, each of which is a
+// host (an IP or hostname) optionally followed by a port, e.g. 10.0.0.1,
+// 10.0.0.1:33146 or [fd00::1]:33146. The port defaults to defaultPort.
+func ParseSeedServers(addrs []string, defaultPort int) ([]SeedServer, error) {
+	var seeds []SeedServer
+
+	for _, a := range addrs {
+		// A bare IPv6 address is not a valid host for
+		// ParseHostMaybeScheme, which requires brackets to tell the
+		// address apart from the port.
+		if ip := net.ParseIP(a); ip != nil {
+			seeds = append(seeds, SeedServer{
+				Host: SocketAddress{
+					Address: ip.String(),
+					Port:    defaultPort,
+				},
+			})
+			continue
+		}
+
+		scheme, hostport, err := vnet.ParseHostMaybeScheme(a)
+		if err != nil {
+			return nil, err
+		}
+		if scheme != "" {
+			return nil, fmt.Errorf("invalid seed server %q, it must not have a scheme", a)
+		}
+
+		host, port := vnet.SplitHostPortDefault(hostport, defaultPort)
+		if port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid seed server %q, port %d is out of the range [1, 65535]", a, port)
+		}
+		// The address is stored without brackets, they are added back
+		// when joined with the port.
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+		seeds = append(seeds, SeedServer{
+			Host: SocketAddress{
+				Address: host,
+				Port:    port,
+			},
+		})
+	}
+	return seeds, nil
+}
+
+// EnvKey translates the name of a dotenv variable to the configuration key it
+// sets: the name is lowercased, and double underscores separate the levels of
+// the key, while single underscores are kept, e.g.
+// REDPANDA__RPC_SERVER__PORT is redpanda.rpc_server.port and
+// REDPANDA__SEED_SERVERS__0__HOST__ADDRESS is
+// redpanda.seed_servers.0.host.address.
+func EnvKey(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "__", ".")
+}
+
+// ParseEnvFile parses dotenv style KEY=VALUE lines into the configuration
+// keys, translated with EnvKey, and the values they are set to. Blank lines
+// and lines starting with # are ignored, as is an "export " prefix. Values
+// can be wrapped in single or double quotes, which are removed; they are
+// otherwise kept as is, so that # is not a comment within a value.
+func ParseEnvFile(b []byte) ([][2]string, error) {
+	var kvs [][2]string
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		kv := strings.SplitN(line, "=", 2)
+		name := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || name == "" {
+			return nil, fmt.Errorf("line %d: %q is not a KEY=VALUE pair", i+1, line)
+		}
+		key := EnvKey(name)
+		if strings.HasPrefix(key, ".") || strings.HasSuffix(key, ".") || strings.Contains(key, "..") {
+			return nil, fmt.Errorf("line %d: %q does not translate to a valid key, got %q", i+1, name, key)
+		}
+		v := strings.TrimSpace(kv[1])
+		if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+			v = v[1 : len(v)-1]
+		}
+		kvs = append(kvs, [2]string{key, v})
+	}
+	return kvs, nil
+}
+
+// EnvVarName returns the name of the environment variable of the dotted key,
+// as EnvVars names them: prefix followed by the key uppercased, with
+// underscores in place of the dots and of any other character that is not a
+// letter nor a digit, e.g. REDPANDA_RPC_SERVER_PORT for
+// redpanda.rpc_server.port and an empty prefix.
+func EnvVarName(prefix, key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	return prefix + name
+}
+
+// EnvVars returns the scalar values of the configuration as environment
+// variables, named with EnvVarName, in the order the keys are written. The
+// elements of the lists are skipped, unless jsonLists is set, in which case
+// each list is a single variable holding the list encoded as JSON. Null
+// values and the config_file key, which is where the file was loaded from,
+// are skipped.
+func EnvVars(c *Config, prefix string, jsonLists bool) ([][2]string, error) {
+	var n yaml.Node
+	if err := n.Encode(c); err != nil {
+		return nil, fmt.Errorf("unable to encode config: %v", err)
+	}
+	var vars [][2]string
+	var walk func(key string, n *yaml.Node) error
+	walk = func(key string, n *yaml.Node) error {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				if err := walk(key, c); err != nil {
+					return err
+				}
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				k := n.Content[i].Value
+				if key == "" && k == "config_file" {
+					continue
+				}
+				if key != "" {
+					k = key + "." + k
+				}
+				if err := walk(k, n.Content[i+1]); err != nil {
+					return err
+				}
+			}
+		case yaml.SequenceNode:
+			if !jsonLists {
+				return nil
+			}
+			var v interface{}
+			if err := n.Decode(&v); err != nil {
+				return fmt.Errorf("unable to decode %s: %v", key, err)
+			}
+			b, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("unable to encode %s as json: %v", key, err)
+			}
+			vars = append(vars, [2]string{EnvVarName(prefix, key), string(b)})
+		case yaml.ScalarNode:
+			if n.Tag != "!!null" {
+				vars = append(vars, [2]string{EnvVarName(prefix, key), n.Value})
+			}
+		}
+		return nil
+	}
+	if err := walk("", &n); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
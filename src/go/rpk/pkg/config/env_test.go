@@ -0,0 +1,220 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyEnvOverrides(t *testing.T) {
+	const file = `redpanda:
+  node_id: 1
+  rpc_server:
+    address: 0.0.0.0
+    port: 33145
+  seed_servers:
+    - host:
+        address: 10.0.0.1
+        port: 33145
+`
+	tests := []struct {
+		name    string
+		noFile  bool
+		env     map[string]string
+		expErr  bool
+		expID   int
+		expRPC  SocketAddress
+		expSeed []SeedServer
+	}{
+		{
+			name:    "file over defaults",
+			expID:   1,
+			expRPC:  SocketAddress{"0.0.0.0", 33145},
+			expSeed: []SeedServer{{Host: SocketAddress{"10.0.0.1", 33145}}},
+		},
+		{
+			name:   "env over defaults",
+			noFile: true,
+			env: map[string]string{
+				EnvNodeID: "4",
+			},
+			expID:  4,
+			expRPC: Default().Redpanda.RPCServer,
+		},
+		{
+			name: "env over file",
+			env: map[string]string{
+				EnvNodeID:      " 5 ",
+				EnvRPCAddress:  "192.168.0.5",
+				EnvSeedServers: "192.168.0.1:33146, redpanda-1.local,[::1]:33147",
+			},
+			expID:  5,
+			expRPC: SocketAddress{"192.168.0.5", 33145},
+			expSeed: []SeedServer{
+				{Host: SocketAddress{"192.168.0.1", 33146}},
+				{Host: SocketAddress{"redpanda-1.local", 33145}},
+				{Host: SocketAddress{"::1", 33147}},
+			},
+		},
+		{
+			name: "empty env is ignored",
+			env: map[string]string{
+				EnvNodeID:      "",
+				EnvSeedServers: " ",
+			},
+			expID:   1,
+			expRPC:  SocketAddress{"0.0.0.0", 33145},
+			expSeed: []SeedServer{{Host: SocketAddress{"10.0.0.1", 33145}}},
+		},
+		{
+			name:   "invalid node ID",
+			env:    map[string]string{EnvNodeID: "one"},
+			expErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for k, v := range test.env {
+				t.Setenv(k, v)
+			}
+			fs := afero.NewMemMapFs()
+			if !test.noFile {
+				require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(file), 0o644))
+			}
+			cfg, err := new(Params).Load(fs)
+			require.NoError(t, err)
+			before := *cfg
+
+			got, err := ApplyEnvOverrides(cfg)
+			if test.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expID, got.Redpanda.ID)
+			require.Equal(t, test.expRPC, got.Redpanda.RPCServer)
+			require.Equal(t, test.expSeed, got.Redpanda.SeedServers)
+			require.Equal(t, before, *cfg, "the loaded config should not be modified")
+		})
+	}
+}
+
+func TestParseSeedServers(t *testing.T) {
+	for _, test := range []struct {
+		addrs  []string
+		exp    []SeedServer
+		expErr bool
+	}{
+		{
+			addrs: []string{"10.0.0.1", "10.0.0.2:33146"},
+			exp: []SeedServer{
+				{Host: SocketAddress{"10.0.0.1", 33145}},
+				{Host: SocketAddress{"10.0.0.2", 33146}},
+			},
+		},
+		{
+			addrs: []string{"fd00::1", "[fd00::2]", "[fd00::3]:33146"},
+			exp: []SeedServer{
+				{Host: SocketAddress{"fd00::1", 33145}},
+				{Host: SocketAddress{"fd00::2", 33145}},
+				{Host: SocketAddress{"fd00::3", 33146}},
+			},
+		},
+		{
+			addrs: []string{"redpanda-0.local", "redpanda-1.local:33146"},
+			exp: []SeedServer{
+				{Host: SocketAddress{"redpanda-0.local", 33145}},
+				{Host: SocketAddress{"redpanda-1.local", 33146}},
+			},
+		},
+		{addrs: []string{"10.0.0.1:"}, expErr: true},
+		{addrs: []string{"10.0.0.1:abc"}, expErr: true},
+		{addrs: []string{"10.0.0.1:0"}, expErr: true},
+		{addrs: []string{"10.0.0.1:65536"}, expErr: true},
+		{addrs: []string{"tcp://10.0.0.1:33146"}, expErr: true},
+	} {
+		seeds, err := ParseSeedServers(test.addrs, 33145)
+		if test.expErr {
+			require.Error(t, err, "%v", test.addrs)
+			continue
+		}
+		require.NoError(t, err, "%v", test.addrs)
+		require.Equal(t, test.exp, seeds)
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	kvs, err := ParseEnvFile([]byte(`# Generated by the secrets tooling.
+REDPANDA__NODE_ID=1
+
+export REDPANDA__RPC_SERVER__ADDRESS = "10.0.0.1"
+  REDPANDA__RACK='rack # 1'
+REDPANDA__SEED_SERVERS__0__HOST__ADDRESS=10.0.0.2
+REDPANDA__DEVELOPER_MODE=
+`))
+	require.NoError(t, err)
+	require.Equal(t, [][2]string{
+		{"redpanda.node_id", "1"},
+		{"redpanda.rpc_server.address", "10.0.0.1"},
+		{"redpanda.rack", "rack # 1"},
+		{"redpanda.seed_servers.0.host.address", "10.0.0.2"},
+		{"redpanda.developer_mode", ""},
+	}, kvs)
+
+	for _, bad := range []string{
+		"REDPANDA__NODE_ID",
+		"=1",
+		"REDPANDA____NODE_ID=1",
+		"__REDPANDA=1",
+	} {
+		_, err := ParseEnvFile([]byte("A=1\n" + bad))
+		require.Error(t, err, bad)
+		require.Contains(t, err.Error(), "line 2", bad)
+	}
+}
+
+func TestEnvVars(t *testing.T) {
+	c := Default()
+	c.ConfigFile = "/etc/redpanda/redpanda.yaml"
+	c.Redpanda.RPCServer = SocketAddress{"10.0.0.1", 33146}
+	c.Redpanda.SeedServers = []SeedServer{{Host: SocketAddress{"10.0.0.2", 33145}}}
+	c.Redpanda.Other = map[string]interface{}{
+		"cloud-storage": map[string]interface{}{"region": "us-east-1"},
+		"unset":         nil,
+	}
+	c.Rpk.KafkaAPI.Brokers = []string{"10.0.0.2:9092"}
+
+	vars, err := EnvVars(c, "", false)
+	require.NoError(t, err)
+	m := make(map[string]string)
+	for _, v := range vars {
+		m[v[0]] = v[1]
+	}
+	require.Equal(t, "10.0.0.1", m["REDPANDA_RPC_SERVER_ADDRESS"])
+	require.Equal(t, "33146", m["REDPANDA_RPC_SERVER_PORT"])
+	require.Equal(t, "true", m["REDPANDA_DEVELOPER_MODE"])
+	require.Equal(t, "us-east-1", m["REDPANDA_CLOUD_STORAGE_REGION"])
+	for _, name := range []string{"CONFIG_FILE", "REDPANDA_UNSET", "REDPANDA_SEED_SERVERS", "REDPANDA_SEED_SERVERS_0_HOST_ADDRESS"} {
+		require.NotContains(t, m, name)
+	}
+
+	vars, err = EnvVars(c, "RP_", true)
+	require.NoError(t, err)
+	m = make(map[string]string)
+	for _, v := range vars {
+		m[v[0]] = v[1]
+	}
+	require.Equal(t, "33146", m["RP_REDPANDA_RPC_SERVER_PORT"])
+	require.Equal(t, `[{"host":{"address":"10.0.0.2","port":33145}}]`, m["RP_REDPANDA_SEED_SERVERS"])
+	require.Equal(t, `["10.0.0.2:9092"]`, m["RP_RPK_KAFKA_API_BROKERS"])
+}
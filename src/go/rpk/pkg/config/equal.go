@@ -0,0 +1,84 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import "reflect"
+
+// Equal returns whether a and b hold the same configuration.
+//
+// The configurations are compared field by field, unmanaged properties
+// included, but nil and empty slices and maps are equal, since they are
+// written the same: a configuration that is Equal to the one read from a
+// file does not need to be written back. As in Diff, the config_file key and
+// what tracks the file the configurations were loaded from are ignored.
+func Equal(a, b *Config) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	ca, cb := *a, *b
+	ca.ConfigFile, cb.ConfigFile = "", ""
+	return equalValue(reflect.ValueOf(ca), reflect.ValueOf(cb))
+}
+
+func equalValue(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a.Kind() {
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			// The unexported fields are metadata, not the
+			// configuration.
+			if a.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			if !equalValue(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return equalValue(a.Elem(), b.Elem())
+
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equalValue(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return false
+		}
+		iter := a.MapRange()
+		for iter.Next() {
+			vb := b.MapIndex(iter.Key())
+			if !vb.IsValid() || !equalValue(iter.Value(), vb) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		return reflect.DeepEqual(a.Interface(), b.Interface())
+	}
+}
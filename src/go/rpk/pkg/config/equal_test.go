@@ -0,0 +1,108 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEqual(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		modify func(*Config)
+		exp    bool
+	}{
+		{
+			name:   "same",
+			modify: func(*Config) {},
+			exp:    true,
+		},
+		{
+			name: "nil and empty slices",
+			modify: func(c *Config) {
+				c.Includes = []string{}
+				c.Rpk.KafkaAPI.Brokers = nil
+			},
+			exp: true,
+		},
+		{
+			name: "nil and empty maps",
+			modify: func(c *Config) {
+				c.Redpanda.Other["tuning"] = map[string]interface{}(nil)
+			},
+			exp: true,
+		},
+		{
+			name: "metadata",
+			modify: func(c *Config) {
+				c.ConfigFile = "/etc/redpanda/other.yaml"
+				c.loadedPath = "/etc/redpanda/other.yaml"
+			},
+			exp: true,
+		},
+		{
+			name: "scalar",
+			modify: func(c *Config) {
+				c.Redpanda.ID = 2
+			},
+		},
+		{
+			name: "slice element",
+			modify: func(c *Config) {
+				c.Redpanda.SeedServers[0].Host.Port = 33146
+			},
+		},
+		{
+			name: "slice length",
+			modify: func(c *Config) {
+				c.Redpanda.SeedServers = c.Redpanda.SeedServers[:0]
+			},
+		},
+		{
+			name: "unmanaged value",
+			modify: func(c *Config) {
+				c.Redpanda.Other["tuning"] = map[string]interface{}{"a": 2}
+			},
+		},
+		{
+			name: "unmanaged null",
+			modify: func(c *Config) {
+				c.Redpanda.Other["tuning"] = nil
+			},
+		},
+		{
+			name: "pointer",
+			modify: func(c *Config) {
+				c.Rpk.KafkaAPI.TLS = &TLS{}
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			base := func() *Config {
+				c := Default()
+				c.Redpanda.SeedServers = []SeedServer{{Host: SocketAddress{"10.0.0.1", 33145}}}
+				c.Redpanda.Other = map[string]interface{}{
+					"tuning": map[string]interface{}{},
+				}
+				c.Rpk.KafkaAPI.Brokers = []string{}
+				return c
+			}
+			a, b := base(), base()
+			test.modify(b)
+			require.Equal(t, test.exp, Equal(a, b))
+			require.Equal(t, test.exp, Equal(b, a))
+		})
+	}
+
+	require.True(t, Equal(nil, nil))
+	require.False(t, Equal(Default(), nil))
+}
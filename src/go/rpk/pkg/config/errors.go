@@ -0,0 +1,70 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import "errors"
+
+// These are the kinds of errors that the config package returns, for callers
+// to check with errors.Is. The returned errors keep their own message, and
+// still wrap the lower level error they originate from, if any.
+var (
+	// ErrConfigNotFound is returned from LocateConfig if no config file
+	// can be found. It is an afero.ErrFileNotFound as well.
+	ErrConfigNotFound = errors.New("config file not found")
+
+	// ErrKeyNotFound is returned from Get and Set if the key does not
+	// exist in the configuration.
+	ErrKeyNotFound = errors.New("key not found")
+
+	// ErrInvalidFormat is returned if a config file or a value cannot be
+	// decoded, or if the requested format is not supported.
+	ErrInvalidFormat = errors.New("invalid format")
+
+	// ErrWritePermission is returned from Write and WriteWithBackup if the
+	// config file cannot be written due to missing permissions, and from
+	// CheckDataDir and EnsureDataDir if the data directory cannot be.
+	ErrWritePermission = errors.New("write permission denied")
+
+	// ErrUnknownKey is returned from Load and LoadFrom if Params.Strict is
+	// set and the config file has keys that rpk does not manage, see
+	// Config.UnknownKeys.
+	ErrUnknownKey = errors.New("unknown config key")
+
+	// ErrHashMismatch is returned from CheckFileHash if the config file
+	// does not have the expected content hash.
+	ErrHashMismatch = errors.New("config file hash mismatch")
+
+	// ErrLockTimeout is returned from LockConfig if the config file lock
+	// cannot be taken in time.
+	ErrLockTimeout = errors.New("config file lock timeout")
+
+	// ErrIncludeCycle is returned from Load if the config files include
+	// each other, see Config.Includes.
+	ErrIncludeCycle = errors.New("config include cycle")
+
+	// ErrPatchTestFailed is returned from ApplyJSONPatch if a test
+	// operation of the patch does not hold.
+	ErrPatchTestFailed = errors.New("patch test failed")
+)
+
+// kindError is an error that reads as err, and that is both of its kind and
+// err.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func withKind(kind, err error) error {
+	return &kindError{kind, err}
+}
+
+func (e *kindError) Error() string        { return e.err.Error() }
+func (e *kindError) Unwrap() error        { return e.err }
+func (e *kindError) Is(target error) bool { return target == e.kind }
@@ -0,0 +1,72 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorKinds(t *testing.T) {
+	t.Run("config not found", func(t *testing.T) {
+		_, err := (&Params{ConfigPath: "/etc/redpanda/missing.yaml"}).LocateConfig(afero.NewMemMapFs())
+		require.True(t, errors.Is(err, ErrConfigNotFound))
+		require.True(t, errors.Is(err, afero.ErrFileNotFound))
+	})
+
+	t.Run("key not found", func(t *testing.T) {
+		c := Default()
+		for _, key := range []string{"redpanda.rpc_server.unknown_key", "redpanda.seed_servers.3.host"} {
+			require.True(t, errors.Is(c.Set(key, "1", ""), ErrKeyNotFound), key)
+			_, err := c.Get(key, "")
+			require.True(t, errors.Is(err, ErrKeyNotFound), key)
+		}
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		c := Default()
+		require.True(t, errors.Is(c.Set("redpanda.node_id", "{", "json"), ErrInvalidFormat))
+		require.True(t, errors.Is(c.Set("redpanda.node_id", "[1", "yaml"), ErrInvalidFormat))
+		require.True(t, errors.Is(c.Set("redpanda.node_id", "1", "toml"), ErrInvalidFormat))
+		_, err := c.Get("redpanda.node_id", "toml")
+		require.True(t, errors.Is(err, ErrInvalidFormat))
+
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte("redpanda: ["), 0o644))
+		_, err = new(Params).Load(fs)
+		require.True(t, errors.Is(err, ErrInvalidFormat))
+	})
+
+	t.Run("write permission", func(t *testing.T) {
+		fs := afero.NewReadOnlyFs(afero.NewMemMapFs())
+		err := Default().Write(fs)
+		require.True(t, errors.Is(err, ErrWritePermission))
+		require.True(t, errors.Is(err, os.ErrPermission))
+		require.Contains(t, err.Error(), "error writing to temporary file")
+	})
+
+	t.Run("hash mismatch", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte("redpanda:\n  node_id: 1\n"), 0o644))
+		c, err := new(Params).Load(fs)
+		require.NoError(t, err)
+		hash, err := c.FileHash(fs)
+		require.NoError(t, err)
+		require.NoError(t, c.CheckFileHash(fs, strings.ToUpper(hash)))
+
+		require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte("redpanda:\n  node_id: 2\n"), 0o644))
+		require.True(t, errors.Is(c.CheckFileHash(fs, hash), ErrHashMismatch))
+	})
+}
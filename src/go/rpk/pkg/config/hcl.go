@@ -0,0 +1,275 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// HCL is an output format only: rpk prints the configuration as HCL, e.g. for
+// Terraform, but does not read it. As TOML, HCL goes through YAML: the
+// configuration is encoded to YAML and the YAML is written as HCL, so that the
+// yaml struct tags apply. Only the subset of the HCL native syntax that a
+// configuration needs is written: attributes holding literal values, lists
+// and objects, and unlabeled blocks.
+//
+// The objects are blocks and the lists of objects are repeated blocks, as in
+// Terraform:
+//
+//	redpanda {
+//	  node_id = 1
+//	  seed_servers {
+//	    host {
+//	      address = "10.0.0.1"
+//	      port    = 33145
+//	    }
+//	  }
+//	}
+//
+// The other lists, and the objects whose keys are not HCL identifiers, are
+// attributes.
+
+// MarshalHCL returns the HCL encoding of v, which must encode to a YAML
+// mapping, such as a Config or the YAML node of one.
+func MarshalHCL(v interface{}) ([]byte, error) {
+	var n yaml.Node
+	if err := n.Encode(v); err != nil {
+		return nil, err
+	}
+	root := &n
+	for root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("unable to hcl encode a %s, must be an object", root.ShortTag())
+	}
+	var buf bytes.Buffer
+	if err := writeHCLBody(&buf, root, ""); err != nil {
+		return nil, fmt.Errorf("unable to hcl encode: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func writeHCLBody(buf *bytes.Buffer, n *yaml.Node, indent string) error {
+	pairs := nodePairs(n)
+	// The = of consecutive attributes are aligned, as hcl fmt does.
+	width := 0
+	for _, p := range pairs {
+		if !isHCLBlock(p[1]) && len(p[0].Value) > width {
+			width = len(p[0].Value)
+		}
+	}
+	for _, p := range pairs {
+		k, v := p[0].Value, p[1]
+		if !isHCLIdent(k) {
+			return fmt.Errorf("key %q is not a valid HCL identifier", k)
+		}
+		switch {
+		case v.Kind == yaml.MappingNode && isHCLBlock(v):
+			if err := writeHCLBlock(buf, k, v, indent); err != nil {
+				return err
+			}
+		case v.Kind == yaml.SequenceNode && isHCLBlock(v):
+			for _, e := range v.Content {
+				if err := writeHCLBlock(buf, k, e, indent); err != nil {
+					return err
+				}
+			}
+		default:
+			fmt.Fprintf(buf, "%s%-*s = ", indent, width, k)
+			if err := writeHCLExpr(buf, v, indent); err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+			buf.WriteByte('\n')
+		}
+	}
+	return nil
+}
+
+// isHCLBlock returns whether n is written as a block, or as repeated blocks:
+// n is an object whose keys are all identifiers, or a non empty list of them.
+func isHCLBlock(n *yaml.Node) bool {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for _, p := range nodePairs(n) {
+			if !isHCLIdent(p[0].Value) {
+				return false
+			}
+		}
+		return true
+	case yaml.SequenceNode:
+		for _, e := range n.Content {
+			if e.Kind != yaml.MappingNode || !isHCLBlock(e) {
+				return false
+			}
+		}
+		return len(n.Content) > 0
+	default:
+		return false
+	}
+}
+
+func writeHCLBlock(buf *bytes.Buffer, k string, n *yaml.Node, indent string) error {
+	if len(n.Content) == 0 {
+		fmt.Fprintf(buf, "%s%s {}\n", indent, k)
+		return nil
+	}
+	fmt.Fprintf(buf, "%s%s {\n", indent, k)
+	if err := writeHCLBody(buf, n, indent+"  "); err != nil {
+		return err
+	}
+	fmt.Fprintf(buf, "%s}\n", indent)
+	return nil
+}
+
+func writeHCLExpr(buf *bytes.Buffer, n *yaml.Node, indent string) error {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return writeHCLScalar(buf, n)
+	case yaml.AliasNode:
+		return writeHCLExpr(buf, n.Alias, indent)
+	case yaml.SequenceNode:
+		if len(n.Content) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		scalars := true
+		for _, e := range n.Content {
+			scalars = scalars && e.Kind == yaml.ScalarNode
+		}
+		if scalars {
+			buf.WriteByte('[')
+			for i, e := range n.Content {
+				if i > 0 {
+					buf.WriteString(", ")
+				}
+				if err := writeHCLScalar(buf, e); err != nil {
+					return err
+				}
+			}
+			buf.WriteByte(']')
+			return nil
+		}
+		buf.WriteString("[\n")
+		for _, e := range n.Content {
+			buf.WriteString(indent + "  ")
+			if err := writeHCLExpr(buf, e, indent+"  "); err != nil {
+				return err
+			}
+			buf.WriteString(",\n")
+		}
+		buf.WriteString(indent + "]")
+		return nil
+	case yaml.MappingNode:
+		if len(n.Content) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for _, p := range nodePairs(n) {
+			k := p[0].Value
+			if !isHCLIdent(k) {
+				k = hclQuote(k)
+			}
+			fmt.Fprintf(buf, "%s  %s = ", indent, k)
+			if err := writeHCLExpr(buf, p[1], indent+"  "); err != nil {
+				return err
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+		return nil
+	default:
+		return fmt.Errorf("unsupported yaml node kind %v", n.Kind)
+	}
+}
+
+func writeHCLScalar(buf *bytes.Buffer, n *yaml.Node) error {
+	var v interface{}
+	if err := n.Decode(&v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case int:
+		buf.WriteString(strconv.Itoa(v))
+	case int64:
+		buf.WriteString(strconv.FormatInt(v, 10))
+	case uint64:
+		buf.WriteString(strconv.FormatUint(v, 10))
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return fmt.Errorf("%v has no HCL representation", v)
+		}
+		buf.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+	case string:
+		buf.WriteString(hclQuote(v))
+	default:
+		// E.g. timestamps, which are strings in HCL.
+		buf.WriteString(hclQuote(n.Value))
+	}
+	return nil
+}
+
+func isHCLIdent(s string) bool {
+	for i, r := range s {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '-' || unicode.IsDigit(r)):
+		default:
+			return false
+		}
+	}
+	switch s {
+	case "", "true", "false", "null":
+		return false
+	}
+	return true
+}
+
+// hclQuote returns s as an HCL quoted string, in which the template
+// sequences ${ and %{ are escaped.
+func hclQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case (r == '$' || r == '%') && strings.HasPrefix(s[i+1:], "{"):
+			b.WriteRune(r)
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
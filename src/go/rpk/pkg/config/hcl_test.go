@@ -0,0 +1,78 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalHCL(t *testing.T) {
+	cfg := Default()
+	cfg.Redpanda.ID = 3
+	cfg.Redpanda.Rack = "r1"
+	cfg.Redpanda.SeedServers = []SeedServer{
+		{Host: SocketAddress{"10.0.0.1", 33145}},
+		{Host: SocketAddress{"10.0.0.2", 33146}},
+	}
+	cfg.Redpanda.KafkaAPI = []NamedSocketAddress{
+		{Name: "internal", Address: "0.0.0.0", Port: 9092},
+		{Name: "external", Address: "192.168.1.1", Port: 9093},
+	}
+	// A single TLS configuration is a list of one block.
+	cfg.Redpanda.KafkaAPITLS = []ServerTLS{{
+		Name:     "external",
+		Enabled:  true,
+		CertFile: "/certs/node.crt",
+		KeyFile:  "/certs/node.key",
+		Other:    map[string]interface{}{"extra": "x"},
+	}}
+	cfg.Redpanda.Other = map[string]interface{}{
+		"auto_create_topics_enabled": true,
+		"log_segment_size":           134217728,
+		"retention_ratio":            0.5,
+		"quoted":                     "a \"${b}\" %{c}\n",
+		"empty":                      map[string]interface{}{},
+		"labels":                     map[string]interface{}{"rack.zone": "us-east-1a"},
+	}
+	cfg.Rpk.TuneNetwork = true
+	cfg.Rpk.KafkaAPI.Brokers = []string{"10.0.0.1:9092", "10.0.0.2:9092"}
+
+	b, err := Render(cfg, FormatHCL)
+	require.NoError(t, err)
+	s := string(b)
+	for _, exp := range []string{
+		"redpanda {\n",
+		"  node_id                    = 3\n",
+		"  seed_servers {\n    host {\n      address = \"10.0.0.1\"\n",
+		"  kafka_api_tls {\n",
+		"    extra     = \"x\"\n",
+		`  quoted                     = "a \"$${b}\" %%{c}\n"` + "\n",
+		"  empty {}\n",
+		"  labels                     = {\n    \"rack.zone\" = \"us-east-1a\"\n  }\n",
+		"  kafka_api {\n    brokers = [\"10.0.0.1:9092\", \"10.0.0.2:9092\"]\n  }\n",
+	} {
+		require.Contains(t, s, exp)
+	}
+}
+
+func TestReadHCL(t *testing.T) {
+	// HCL is only written.
+	_, err := ReadFromBytes([]byte("redpanda {\n  node_id = 1\n}\n"), FormatHCL)
+	require.ErrorIs(t, err, ErrInvalidFormat)
+}
+
+func TestMarshalHCLInvalid(t *testing.T) {
+	_, err := MarshalHCL(map[string]interface{}{"not an identifier": 1})
+	require.Error(t, err)
+	_, err = MarshalHCL([]int{1})
+	require.Error(t, err)
+}
@@ -0,0 +1,179 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// includesKey is the top-level key of the files a config file includes, see
+// Config.Includes.
+const includesKey = "includes"
+
+// resolveIncludes returns the mapping of the config file at path, which holds
+// b, with the files it includes deep merged under it, and the mapping of the
+// included files alone. If the file includes nothing, the returned included
+// mapping is nil. stack holds the absolute paths of the files including this
+// one, to detect cycles.
+func resolveIncludes(fs afero.Fs, path string, b []byte, stack []string) (doc, included *yaml.Node, err error) {
+	var n yaml.Node
+	// The file is decoded again later on, which reports the errors.
+	if err := yaml.Unmarshal(b, &n); err != nil || len(n.Content) == 0 || n.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, nil
+	}
+	root := n.Content[0]
+	incs := mappingValue(root, includesKey)
+	if incs == nil {
+		return root, nil, nil
+	}
+	var paths []string
+	if err := incs.Decode(&paths); err != nil {
+		return nil, nil, withKind(ErrInvalidFormat, fmt.Errorf("%s: %s must be a list of paths: %v", path, includesKey, err))
+	}
+
+	included = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, inc := range paths {
+		if !filepath.IsAbs(inc) {
+			inc = filepath.Join(filepath.Dir(path), inc)
+		}
+		abs, err := filepath.Abs(inc)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, p := range stack {
+			if p == abs {
+				return nil, nil, withKind(ErrIncludeCycle, fmt.Errorf("circular include: %s", strings.Join(append(stack, abs), " -> ")))
+			}
+		}
+		logger.Debugf("Including config file %s from %s", abs, path)
+		ib, err := afero.ReadFile(fs, abs)
+		if err != nil {
+			// Not wrapped, for a missing included file not to read
+			// as a missing config file, which Load accepts.
+			return nil, nil, fmt.Errorf("unable to read %s, included from %s: %v", abs, path, err)
+		}
+		if strings.EqualFold(filepath.Ext(abs), ".toml") {
+			if ib, err = tomlToYAML(ib); err != nil {
+				return nil, nil, fmt.Errorf("unable to decode %s: %w", abs, err)
+			}
+		}
+		if len(bytes.TrimSpace(ib)) == 0 {
+			continue
+		}
+		incDoc, _, err := resolveIncludes(fs, abs, ib, append(stack, abs))
+		if err != nil {
+			return nil, nil, err
+		}
+		if incDoc == nil {
+			return nil, nil, withKind(ErrInvalidFormat, fmt.Errorf("unable to include %s: it must be a yaml mapping", abs))
+		}
+		deleteMappingKey(incDoc, includesKey)
+		overlayNode(included, incDoc)
+	}
+
+	doc = copyNode(included)
+	overlayNode(doc, root)
+	return doc, included, nil
+}
+
+// pruneIncluded removes from the rendered mapping n the keys that have the
+// same value in the included mapping, and that the including file itself,
+// whose mapping is main, does not set: they are read from the included files
+// again rather than being copied into the including file.
+func pruneIncluded(n, included, main *yaml.Node) {
+	pruned := n.Content[:0]
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		iv, mv := mappingValue(included, k.Value), mappingValue(main, k.Value)
+		switch {
+		case iv == nil:
+		case mv == nil && equalNodes(v, iv):
+			continue
+		case v.Kind == yaml.MappingNode && iv.Kind == yaml.MappingNode:
+			pruneIncluded(v, iv, mv)
+			if len(v.Content) == 0 && mv == nil {
+				continue
+			}
+		}
+		pruned = append(pruned, k, v)
+	}
+	n.Content = pruned
+}
+
+// withoutIncluded returns the rendered YAML config b without the values it
+// reads from its included files, see pruneIncluded. orig is the including
+// file as read, if any.
+func withoutIncluded(b []byte, included *yaml.Node, orig []byte) ([]byte, error) {
+	var doc, origDoc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return b, nil
+	}
+	var main *yaml.Node
+	if err := yaml.Unmarshal(orig, &origDoc); err == nil && len(origDoc.Content) > 0 {
+		main = origDoc.Content[0]
+	}
+	pruneIncluded(doc.Content[0], included, main)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(detectIndent(b))
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteResolved writes the configuration with the values of its included
+// files and without its includes, for the readers that do not know about
+// includes, such as redpanda itself, and returns the path of the written
+// file. The resolved configuration is written next to the config file, e.g.
+// to redpanda.resolved.yaml for redpanda.yaml, with the permissions of the
+// config file, and is rewritten on every call.
+//
+// If the configuration includes no file, nothing is written and the config
+// file is returned, as it holds every value already.
+func (c *Config) WriteResolved(fs afero.Fs) (path string, rerr error) {
+	defer func() {
+		if errors.Is(rerr, os.ErrPermission) {
+			rerr = withKind(ErrWritePermission, rerr)
+		}
+	}()
+	cfgPath := c.FileLocation()
+	if len(c.Includes) == 0 {
+		return cfgPath, nil
+	}
+	path = strings.TrimSuffix(cfgPath, filepath.Ext(cfgPath)) + ".resolved.yaml"
+	cp := *c
+	cp.Includes = nil
+	cp.ConfigFile = path
+	b, err := Render(&cp, FormatYAML)
+	if err != nil {
+		return "", fmt.Errorf("unable to render the resolved config: %v", err)
+	}
+	var origPath string
+	if exists, _ := afero.Exists(fs, cfgPath); exists {
+		origPath = cfgPath
+	}
+	return path, writeAtomic(fs, path, b, origPath)
+}
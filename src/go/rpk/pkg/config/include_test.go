@@ -0,0 +1,163 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncludes(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	fs := afero.NewMemMapFs()
+	for name, file := range map[string]string{
+		path: `includes:
+  - shared/tuning.yaml
+  - /etc/redpanda/rack.yaml
+redpanda:
+  node_id: 1
+  developer_mode: false
+`,
+		"/etc/redpanda/shared/tuning.yaml": `includes: [common.yaml]
+redpanda:
+  developer_mode: true
+  rack: shared
+rpk:
+  tune_network: true
+`,
+		"/etc/redpanda/shared/common.yaml": `redpanda:
+  rack: common
+  enable_idempotence: true
+rpk:
+  tune_cpu: true
+`,
+		"/etc/redpanda/rack.yaml": `redpanda:
+  rack: r1
+`,
+	} {
+		require.NoError(t, afero.WriteFile(fs, name, []byte(file), 0o644))
+	}
+
+	cfg, err := new(Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, []string{"shared/tuning.yaml", "/etc/redpanda/rack.yaml"}, cfg.Includes)
+	// The main file wins over its includes, even with a zero value.
+	require.Equal(t, 1, cfg.Redpanda.ID)
+	require.False(t, cfg.Redpanda.DeveloperMode)
+	// Later includes win over earlier ones, which win over what they
+	// include themselves.
+	require.Equal(t, "r1", cfg.Redpanda.Rack)
+	require.Equal(t, true, cfg.Redpanda.Other["enable_idempotence"])
+	require.True(t, cfg.Rpk.TuneNetwork)
+	require.True(t, cfg.Rpk.TuneCPU)
+
+	// The included values are not copied into the main file.
+	cfg.Redpanda.ID = 2
+	require.NoError(t, cfg.Write(fs))
+	b, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	require.Contains(t, string(b), "includes:\n")
+	require.Contains(t, string(b), "node_id: 2\n")
+	require.Contains(t, string(b), "developer_mode: false\n")
+	require.NotContains(t, string(b), "rack:")
+	require.NotContains(t, string(b), "enable_idempotence")
+	require.NotContains(t, string(b), "tune_network")
+
+	// The resolved config holds the included values, for redpanda.
+	resolved, err := cfg.WriteResolved(fs)
+	require.NoError(t, err)
+	require.Equal(t, "/etc/redpanda/redpanda.resolved.yaml", resolved)
+	b, err = afero.ReadFile(fs, resolved)
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "includes:")
+	require.Contains(t, string(b), "node_id: 2\n")
+	require.Contains(t, string(b), "rack: r1\n")
+	require.Contains(t, string(b), "enable_idempotence: true\n")
+	require.Contains(t, string(b), "tune_network: true\n")
+
+	// So that a change of a shared file still applies.
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/rack.yaml", []byte("redpanda:\n  rack: r2\n"), 0o644))
+	cfg, err = new(Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 2, cfg.Redpanda.ID)
+	require.Equal(t, "r2", cfg.Redpanda.Rack)
+	require.True(t, cfg.Rpk.TuneNetwork)
+}
+
+func TestWriteResolvedWithoutIncludes(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cfg, err := new(Params).Load(fs)
+	require.NoError(t, err)
+	resolved, err := cfg.WriteResolved(fs)
+	require.NoError(t, err)
+	require.Equal(t, cfg.FileLocation(), resolved)
+	files, err := afero.Glob(fs, "/etc/redpanda/*")
+	require.NoError(t, err)
+	require.Empty(t, files, "nothing should be written")
+}
+
+func TestIncludesErrors(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	for _, test := range []struct {
+		name   string
+		files  map[string]string
+		kind   error
+		expErr string
+	}{
+		{
+			name:   "self include",
+			files:  map[string]string{path: "includes: [redpanda.yaml]\n"},
+			kind:   ErrIncludeCycle,
+			expErr: "circular include: /etc/redpanda/redpanda.yaml -> /etc/redpanda/redpanda.yaml",
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				path:                   "includes: [a.yaml]\n",
+				"/etc/redpanda/a.yaml": "includes: [b.yaml]\n",
+				"/etc/redpanda/b.yaml": "includes: [a.yaml]\n",
+			},
+			kind:   ErrIncludeCycle,
+			expErr: "circular include: /etc/redpanda/redpanda.yaml -> /etc/redpanda/a.yaml -> /etc/redpanda/b.yaml -> /etc/redpanda/a.yaml",
+		},
+		{
+			name:   "includes is not a list",
+			files:  map[string]string{path: "includes: {a: b}\n"},
+			kind:   ErrInvalidFormat,
+			expErr: "includes must be a list of paths",
+		},
+		{
+			name:   "included file is not a mapping",
+			files:  map[string]string{path: "includes: [a.yaml]\n", "/etc/redpanda/a.yaml": "- a\n"},
+			kind:   ErrInvalidFormat,
+			expErr: "unable to include /etc/redpanda/a.yaml: it must be a yaml mapping",
+		},
+		{
+			name:   "missing included file",
+			files:  map[string]string{path: "includes: [a.yaml]\n"},
+			expErr: "unable to read /etc/redpanda/a.yaml, included from /etc/redpanda/redpanda.yaml",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for name, file := range test.files {
+				require.NoError(t, afero.WriteFile(fs, name, []byte(file), 0o644))
+			}
+			_, err := new(Params).Load(fs)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.expErr)
+			if test.kind != nil {
+				require.ErrorIs(t, err, test.kind)
+			}
+		})
+	}
+}
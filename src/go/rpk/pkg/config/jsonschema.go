@@ -0,0 +1,105 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// JSONSchemaDraft is the JSON Schema version of the document JSONSchema
+// returns.
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchema returns a JSON Schema document of the configuration, reflected
+// from the Config struct so that it never drifts from what rpk reads:
+//
+//  * each key has the JSON type of its field, and the lists their element
+//    type,
+//  * the fields tagged with `required:"true"`, such as the address and port
+//    of socket addresses, are required,
+//  * the fields tagged with `enum:"a,b"` only accept the listed values,
+//  * the sections with unmanaged properties, such as redpanda, accept any
+//    other key, while the others, such as rpk, reject unknown keys.
+//
+// The schema describes the configuration as rpk writes it: the weak decoding
+// of rpk is more lenient, e.g. it accepts "1" for an integer.
+func JSONSchema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(Config{}))
+	schema["$schema"] = JSONSchemaDraft
+	schema["title"] = "Redpanda configuration"
+	return schema
+}
+
+var otherType = reflect.TypeOf(map[string]interface{}(nil))
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object"}
+	case reflect.Struct:
+	default:
+		// Interfaces hold any value.
+		return map[string]interface{}{}
+	}
+
+	props := make(map[string]interface{})
+	required := []string{}
+	additional := false
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("yaml"), ",")
+		if tag[0] == "" && len(tag) > 1 && tag[1] == "inline" && f.Type == otherType {
+			additional = true
+			continue
+		}
+		if tag[0] == "" || tag[0] == "-" {
+			continue
+		}
+		prop := typeSchema(f.Type)
+		if enum := f.Tag.Get("enum"); enum != "" {
+			var values []interface{}
+			for _, v := range strings.Split(enum, ",") {
+				values = append(values, v)
+			}
+			prop["enum"] = values
+		}
+		props[tag[0]] = prop
+		if f.Tag.Get("required") == "true" {
+			required = append(required, tag[0])
+		}
+	}
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": additional,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
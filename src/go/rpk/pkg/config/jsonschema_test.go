@@ -0,0 +1,179 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// validateSchema validates the JSON decoded value v against the subset of
+// JSON Schema that JSONSchema emits, returning the first violation.
+func validateSchema(schema map[string]interface{}, v interface{}, path string) error {
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || e == v
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, v, enum)
+		}
+	}
+	typ, _ := schema["type"].(string)
+	switch typ {
+	case "":
+		return nil
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: %v is not a boolean", path, v)
+		}
+	case "integer":
+		if f, ok := v.(float64); !ok || f != math.Trunc(f) {
+			return fmt.Errorf("%s: %v is not an integer", path, v)
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return fmt.Errorf("%s: %v is not a number", path, v)
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("%s: %v is not a string", path, v)
+		}
+	case "array":
+		elems, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: %v is not an array", path, v)
+		}
+		for i, e := range elems {
+			if err := validateSchema(schema["items"].(map[string]interface{}), e, fmt.Sprintf("%s.%d", path, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: %v is not an object", path, v)
+		}
+		required, _ := schema["required"].([]interface{})
+		for _, r := range required {
+			if _, ok := m[r.(string)]; !ok {
+				return fmt.Errorf("%s: missing required %s", path, r)
+			}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		for k, e := range m {
+			prop, ok := props[k]
+			if !ok {
+				if schema["additionalProperties"] == false {
+					return fmt.Errorf("%s: unknown property %s", path, k)
+				}
+				continue
+			}
+			if err := validateSchema(prop.(map[string]interface{}), e, path+"."+k); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%s: unknown type %s", path, typ)
+	}
+	return nil
+}
+
+func TestJSONSchema(t *testing.T) {
+	// The schema is validated as JSON, as a validator reads it.
+	b, err := json.Marshal(JSONSchema())
+	require.NoError(t, err)
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &schema))
+	require.Equal(t, JSONSchemaDraft, schema["$schema"])
+
+	validate := func(file string) error {
+		var y interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(file), &y))
+		j, err := json.Marshal(y)
+		require.NoError(t, err)
+		var v interface{}
+		require.NoError(t, json.Unmarshal(j, &v))
+		return validateSchema(schema, v, "config")
+	}
+
+	// The default configuration, as rpk writes it, is valid.
+	def, err := Render(Default(), FormatYAML)
+	require.NoError(t, err)
+	require.NoError(t, validate(string(def)))
+
+	for _, test := range []struct {
+		name   string
+		file   string
+		expErr string
+	}{
+		{
+			name: "known good config",
+			file: `redpanda:
+  data_directory: /var/lib/redpanda/data
+  node_id: 1
+  seed_servers:
+    - host:
+        address: 10.0.0.1
+        port: 33145
+  rpc_server:
+    address: 0.0.0.0
+    port: 33145
+  kafka_api:
+    - address: 0.0.0.0
+      port: 9092
+      name: internal
+  enable_idempotence: true
+rpk:
+  kafka_api:
+    brokers: [10.0.0.1:9092]
+    sasl:
+      user: admin
+      type: SCRAM-SHA-256
+  tune_network: true
+  smp: 2
+`,
+		},
+		{
+			name:   "wrong-typed port",
+			file:   "redpanda:\n  rpc_server:\n    address: 0.0.0.0\n    port: abc\n",
+			expErr: "config.redpanda.rpc_server.port: abc is not an integer",
+		},
+		{
+			name:   "missing port",
+			file:   "redpanda:\n  kafka_api:\n    - address: 0.0.0.0\n",
+			expErr: "config.redpanda.kafka_api.0: missing required port",
+		},
+		{
+			name:   "unknown rpk key",
+			file:   "rpk:\n  tune_everything: true\n",
+			expErr: "config.rpk: unknown property tune_everything",
+		},
+		{
+			name:   "unknown SASL mechanism",
+			file:   "rpk:\n  kafka_api:\n    sasl:\n      type: PLAIN\n",
+			expErr: "config.rpk.kafka_api.sasl.type: PLAIN is not one of [SCRAM-SHA-256 SCRAM-SHA-512]",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := validate(test.file)
+			if test.expErr != "" {
+				require.EqualError(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
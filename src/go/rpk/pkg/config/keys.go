@@ -0,0 +1,233 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Key is a dotted key path that Set, Get and Unset accept, the Go type of its
+// value, and its documentation, from the doc tag of its field.
+type Key struct {
+	Path string
+	Type reflect.Type
+	Doc  string
+}
+
+// Keys returns every key of the configuration that rpk manages, in the order
+// of the Config struct fields, that starts with prefix.
+//
+// The fields of list elements are listed under the list key, e.g.
+// redpanda.seed_servers.host.address, which refers to the first element. An
+// index can be inserted after the list key to refer to another element, e.g.
+// redpanda.seed_servers.1.host.address. The unmanaged properties, which can
+// be set under any key of a section that does not otherwise exist, are not
+// listed.
+func Keys(prefix string) []Key {
+	var keys []Key
+	for _, k := range structKeys("", reflect.TypeOf(Config{})) {
+		if strings.HasPrefix(k.Path, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func structKeys(parent string, t reflect.Type) []Key {
+	var keys []Key
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if f.PkgPath != "" || tag == "" || tag == "-" {
+			continue
+		}
+		path := tag
+		if parent != "" {
+			path = parent + "." + tag
+		}
+		keys = append(keys, Key{path, f.Type, f.Tag.Get("doc")})
+
+		elem := f.Type
+		for elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Slice {
+			elem = elem.Elem()
+		}
+		if elem.Kind() == reflect.Struct {
+			keys = append(keys, structKeys(path, elem)...)
+		}
+	}
+	return keys
+}
+
+// LookupKey returns the key that rpk manages at path, which may index list
+// elements, e.g. redpanda.seed_servers.1.host.address is the
+// redpanda.seed_servers.host.address key of Keys.
+func LookupKey(path string) (Key, bool) {
+	var props []string
+	for _, prop := range strings.Split(path, ".") {
+		if _, err := strconv.Atoi(prop); err != nil || len(props) == 0 {
+			props = append(props, prop)
+		}
+	}
+	unindexed := strings.Join(props, ".")
+	for _, k := range Keys(unindexed) {
+		if k.Path == unindexed {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
+
+// IsManaged returns whether key, as passed to Set, refers to a key that rpk
+// manages, or to an element or a field of one. The other keys of the sections
+// with unmanaged properties, such as redpanda, are set as is for redpanda to
+// read, see UnknownKeys.
+func IsManaged(key string) bool {
+	t := reflect.TypeOf(Config{})
+	for _, prop := range strings.Split(key, ".") {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Slice {
+			t = t.Elem()
+			if _, err := strconv.Atoi(prop); err == nil {
+				continue
+			}
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+		}
+		if t.Kind() != reflect.Struct {
+			return false
+		}
+		f, ok := fieldByTag(t, prop)
+		if !ok || prop == "" {
+			return false
+		}
+		t = f.Type
+	}
+	return true
+}
+
+// IndexedKeys is Keys, but it also lists the keys of each existing element of
+// the lists of c, with the element index inserted after the list key, e.g.
+// redpanda.seed_servers.0 and redpanda.seed_servers.0.host.address.
+func (c *Config) IndexedKeys(prefix string) []Key {
+	var keys []Key
+	for _, k := range valueKeys("", reflect.ValueOf(c).Elem()) {
+		if strings.HasPrefix(k.Path, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func valueKeys(parent string, v reflect.Value) []Key {
+	var keys []Key
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if f.PkgPath != "" || tag == "" || tag == "-" {
+			continue
+		}
+		path := tag
+		if parent != "" {
+			path = parent + "." + tag
+		}
+		keys = append(keys, Key{path, f.Type, f.Tag.Get("doc")})
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Ptr && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		switch fv.Kind() {
+		case reflect.Struct:
+			keys = append(keys, valueKeys(path, fv)...)
+		case reflect.Ptr, reflect.Slice:
+			elem := f.Type
+			for elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Slice {
+				elem = elem.Elem()
+			}
+			if elem.Kind() == reflect.Struct {
+				keys = append(keys, structKeys(path, elem)...)
+			}
+			if fv.Kind() != reflect.Slice {
+				continue
+			}
+			for j := 0; j < fv.Len(); j++ {
+				ev := fv.Index(j)
+				epath := path + "." + strconv.Itoa(j)
+				keys = append(keys, Key{epath, ev.Type(), f.Tag.Get("doc")})
+				if ev.Kind() == reflect.Ptr && !ev.IsNil() {
+					ev = ev.Elem()
+				}
+				if ev.Kind() == reflect.Struct {
+					keys = append(keys, valueKeys(epath, ev)...)
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// UnknownKeys returns the keys of the loaded config file that rpk does not
+// manage, in file order, with list elements indexed, e.g.
+// redpanda.kafak_api. They are kept as unmanaged properties and passed
+// through to redpanda, which is what any redpanda property rpk does not know
+// about needs, but they are most often typos.
+func (c *Config) UnknownKeys() []string {
+	if c.fileNode == nil || len(c.fileNode.Content) == 0 {
+		return nil
+	}
+	return unknownKeys(c.fileNode.Content[0], reflect.TypeOf(Config{}), "")
+}
+
+func unknownKeys(n *yaml.Node, t reflect.Type, parent string) []string {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	var keys []string
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i].Value, n.Content[i+1]
+		path := k
+		if parent != "" {
+			path = parent + "." + k
+		}
+		f, ok := fieldByTag(t, k)
+		if !ok || k == "" {
+			keys = append(keys, path)
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		switch {
+		case ft.Kind() == reflect.Struct:
+			keys = append(keys, unknownKeys(v, ft, path)...)
+		case ft.Kind() == reflect.Slice && v.Kind == yaml.SequenceNode:
+			elem := ft.Elem()
+			for elem.Kind() == reflect.Ptr {
+				elem = elem.Elem()
+			}
+			if elem.Kind() != reflect.Struct {
+				continue
+			}
+			for j, e := range v.Content {
+				keys = append(keys, unknownKeys(e, elem, path+"."+strconv.Itoa(j))...)
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,122 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// lockSuffix is appended to the config file path to get the path of its lock
+// file. The config file itself cannot be locked, as Write renames a new file
+// over it.
+const lockSuffix = ".lock"
+
+// processLocks serializes the lock holders within this process, per lock
+// file path, which is all the locking there is for filesystems other than the
+// OS one.
+var processLocks sync.Map // map[string]chan struct{}
+
+// LockConfig takes an exclusive advisory lock on the config file that Load
+// would load, for a Load, modify and Write cycle not to race with other rpk
+// processes doing the same. It waits up to timeout for the lock, or forever
+// if timeout is 0, and returns the function that releases it.
+//
+// The lock is a flock on a sibling file suffixed with ".lock", which is left
+// behind once released, in the directory of the config file, which is created
+// if missing unless NoCreateDirs is set. If the lock cannot be taken before
+// the timeout, the returned error is an ErrLockTimeout.
+func (p *Params) LockConfig(fs afero.Fs, timeout time.Duration) (func(), error) {
+	path := p.configPath()
+	if path == "" {
+		var err error
+		if path, err = p.LocateConfig(fs); err != nil {
+			if !errors.Is(err, afero.ErrFileNotFound) {
+				return nil, err
+			}
+			path = Default().ConfigFile
+		}
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return lockFile(fs, abs+lockSuffix, timeout, !p.NoCreateDirs)
+}
+
+func lockFile(fs afero.Fs, path string, timeout time.Duration, createDir bool) (func(), error) {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		deadline = t.C
+	}
+	timeoutErr := withKind(ErrLockTimeout, fmt.Errorf("unable to lock %s: timed out after %v waiting for another rpk process to release it", path, timeout))
+
+	sem, _ := processLocks.LoadOrStore(path, make(chan struct{}, 1))
+	held := sem.(chan struct{})
+	select {
+	case held <- struct{}{}:
+	case <-deadline:
+		return nil, timeoutErr
+	}
+	release := func() { <-held }
+
+	if _, ok := fs.(*afero.OsFs); !ok {
+		return release, nil
+	}
+	if createDir {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			release()
+			return nil, fmt.Errorf("unable to create the directory of %s: %w", path, err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if errors.Is(err, os.ErrNotExist) {
+		// The config file cannot be written either: the directory
+		// has to be created before locking, e.g. with --create-dirs.
+		release()
+		return nil, fmt.Errorf("unable to lock %s: the directory %s does not exist: %w", path, filepath.Dir(path), err)
+	}
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("unable to open lock file: %w", err)
+	}
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			f.Close()
+			release()
+			return nil, fmt.Errorf("unable to lock %s: %w", path, err)
+		}
+		select {
+		case <-deadline:
+			f.Close()
+			release()
+			return nil, timeoutErr
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+		release()
+	}, nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockConfig(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		fs   afero.Fs
+		path string
+	}{
+		{"memory", afero.NewMemMapFs(), "/etc/redpanda/redpanda.yaml"},
+		{"os", afero.NewOsFs(), filepath.Join(t.TempDir(), "redpanda.yaml")},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p := &Params{ConfigPath: test.path}
+
+			// Each goroutine sets a different key in a read, modify
+			// and write cycle: with the lock, no change is lost.
+			const n = 8
+			var wg sync.WaitGroup
+			errs := make(chan error, n)
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					unlock, err := p.LockConfig(test.fs, 0)
+					if err != nil {
+						errs <- err
+						return
+					}
+					defer unlock()
+					cfg, err := p.Load(test.fs)
+					if err != nil {
+						errs <- err
+						return
+					}
+					if err := cfg.Set(fmt.Sprintf("redpanda.key_%d", i), "true", ""); err != nil {
+						errs <- err
+						return
+					}
+					errs <- cfg.Write(test.fs)
+				}(i)
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				require.NoError(t, err)
+			}
+
+			cfg, err := p.Load(test.fs)
+			require.NoError(t, err)
+			for i := 0; i < n; i++ {
+				require.Equal(t, true, cfg.Redpanda.Other[fmt.Sprintf("key_%d", i)], "key_%d was lost", i)
+			}
+		})
+	}
+}
+
+func TestLockConfigTimeout(t *testing.T) {
+	for _, fs := range []afero.Fs{afero.NewMemMapFs(), afero.NewOsFs()} {
+		p := &Params{ConfigPath: filepath.Join(t.TempDir(), "redpanda.yaml")}
+		unlock, err := p.LockConfig(fs, time.Second)
+		require.NoError(t, err)
+
+		_, err = p.LockConfig(fs, 50*time.Millisecond)
+		require.True(t, errors.Is(err, ErrLockTimeout), "got %v, exp ErrLockTimeout", err)
+
+		unlock()
+		unlock, err = p.LockConfig(fs, 50*time.Millisecond)
+		require.NoError(t, err)
+		unlock()
+	}
+
+	// A flock held by another process, as far as the lock is concerned.
+	path := filepath.Join(t.TempDir(), "redpanda.yaml")
+	f, err := os.OpenFile(path+lockSuffix, os.O_RDWR|os.O_CREATE, 0o644)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, syscall.Flock(int(f.Fd()), syscall.LOCK_EX))
+
+	p := &Params{ConfigPath: path}
+	_, err = p.LockConfig(afero.NewOsFs(), 50*time.Millisecond)
+	require.True(t, errors.Is(err, ErrLockTimeout), "got %v, exp ErrLockTimeout", err)
+
+	require.NoError(t, syscall.Flock(int(f.Fd()), syscall.LOCK_UN))
+	unlock, err := p.LockConfig(afero.NewOsFs(), 50*time.Millisecond)
+	require.NoError(t, err)
+	unlock()
+}
+
+func TestLockConfigMissingDir(t *testing.T) {
+	fs := afero.NewOsFs()
+	dir := filepath.Join(t.TempDir(), "missing")
+	unlock, err := (&Params{ConfigPath: filepath.Join(dir, "redpanda.yaml")}).LockConfig(fs, time.Second)
+	require.NoError(t, err)
+	unlock()
+	exists, err := afero.DirExists(fs, dir)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// With NoCreateDirs, the directory is left missing.
+	dir = filepath.Join(t.TempDir(), "missing")
+	p := &Params{ConfigPath: filepath.Join(dir, "redpanda.yaml"), NoCreateDirs: true}
+	_, err = p.LockConfig(fs, time.Second)
+	require.True(t, errors.Is(err, os.ErrNotExist), "got %v, exp os.ErrNotExist", err)
+	exists, err = afero.DirExists(fs, dir)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// The lock is released on failure: once the directory exists, it is
+	// taken right away.
+	require.NoError(t, fs.MkdirAll(dir, 0o755))
+	unlock, err = p.LockConfig(fs, 50*time.Millisecond)
+	require.NoError(t, err)
+	unlock()
+}
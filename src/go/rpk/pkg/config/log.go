@@ -0,0 +1,61 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"io"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Logger is what the package logs its decisions to: which config file is
+// read, whether the default configuration is used instead, and where and how
+// the configuration is written, at debug level, and the problems it works
+// around, such as unknown keys, at warn level.
+//
+// *logrus.Logger and *logrus.Entry implement Logger.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+}
+
+// logger is the Logger of the package, see SetLogger.
+var logger Logger = logrus.StandardLogger()
+
+// SetLogger sets the logger of the package, returning the previous one. A nil
+// logger discards everything. By default, the package logs to the standard
+// logrus logger.
+//
+// SetLogger is not safe to call concurrently with the rest of the package, it
+// is meant to be called once, before loading any config.
+func SetLogger(l Logger) Logger {
+	prev := logger
+	if l == nil {
+		discard := logrus.New()
+		discard.SetOutput(io.Discard)
+		l = discard
+	}
+	logger = l
+	return prev
+}
+
+// NewLogger returns a logrus logger writing to w the entries at level and
+// above, with level being one of debug, info, warn or error.
+func NewLogger(w io.Writer, level string) (*logrus.Logger, error) {
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return nil, withKind(ErrInvalidFormat, err)
+	}
+	l := logrus.New()
+	l.SetOutput(w)
+	l.SetLevel(lvl)
+	return l, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	for _, test := range []struct {
+		name   string
+		level  string
+		exists bool
+		exp    []string
+	}{
+		{
+			name:   "debug",
+			level:  "debug",
+			exists: true,
+			exp: []string{
+				"Resolved config file " + path,
+				"Loading config file " + path + " as yaml",
+				"Replacing config file " + path + " through temporary file",
+			},
+		},
+		{
+			name:  "debug without config file",
+			level: "debug",
+			exp: []string{
+				"No config file found, using the default configuration, to be written to " + path,
+				"Writing new config file " + path + " through temporary file",
+			},
+		},
+		{name: "info", level: "info", exists: true},
+		{name: "info without config file", level: "info"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := NewLogger(&buf, test.level)
+			require.NoError(t, err)
+			defer SetLogger(SetLogger(l))
+
+			fs := afero.NewMemMapFs()
+			if test.exists {
+				require.NoError(t, afero.WriteFile(fs, path, []byte("redpanda:\n  node_id: 1\n"), 0o644))
+			}
+			p := &Params{ConfigPath: path}
+			cfg, err := p.Load(fs)
+			require.NoError(t, err)
+			require.NoError(t, cfg.Write(fs))
+
+			logs := buf.String()
+			for _, exp := range test.exp {
+				require.Contains(t, logs, exp)
+			}
+			if len(test.exp) == 0 {
+				require.Empty(t, logs)
+			}
+		})
+	}
+
+	t.Run("warnings at the default level", func(t *testing.T) {
+		var buf bytes.Buffer
+		l, err := NewLogger(&buf, "info")
+		require.NoError(t, err)
+		defer SetLogger(SetLogger(l))
+
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, path, []byte("redpanda:\n  unknown_key: 1\n"), 0o644))
+		_, err = (&Params{ConfigPath: path}).Load(fs)
+		require.NoError(t, err)
+		require.Contains(t, buf.String(), "has unknown keys, which are passed through to redpanda as is: redpanda.unknown_key")
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		_, err := NewLogger(new(bytes.Buffer), "loud")
+		require.ErrorIs(t, err, ErrInvalidFormat)
+	})
+
+	t.Run("nil discards", func(t *testing.T) {
+		defer SetLogger(SetLogger(nil))
+		_, err := (&Params{ConfigPath: path}).Load(afero.NewMemMapFs())
+		require.NoError(t, err)
+	})
+}
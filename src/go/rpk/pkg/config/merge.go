@@ -0,0 +1,202 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import "reflect"
+
+// Merge returns a new configuration, with overlay deep merged onto base:
+//
+//   * Non-zero overlay values replace the base ones, while zero values (e.g.
+//     0, "", false or nil) keep the base value.
+//   * Non-nil overlay pointers replace the base values, pointers to structs
+//     being merged recursively.
+//   * Maps, such as the unmanaged properties, are merged recursively: the
+//     keys present in overlay win, even if their value is zero.
+//   * Non-empty overlay slices replace the base slices wholesale. Use
+//     MergeAppend to append them instead.
+//
+// Neither base nor overlay are modified, and the returned configuration
+// shares no memory with them. It is tied to the file base was loaded from.
+func Merge(base, overlay *Config) *Config {
+	return merge(base, overlay, false)
+}
+
+// MergeAppend is Merge, but non-empty overlay slices are appended to the base
+// slices rather than replacing them.
+func MergeAppend(base, overlay *Config) *Config {
+	return merge(base, overlay, true)
+}
+
+// Clone returns a deep copy of the configuration, which shares no slice, map
+// or pointer with it, e.g. to compare a configuration with how it was before
+// a change. As Merge, it is tied to the file c was loaded from, which is only
+// read and thus shared.
+func (c *Config) Clone() *Config {
+	cp := copyValue(reflect.ValueOf(c).Elem()).Addr().Interface().(*Config)
+	// copyValue copies the unexported fields as is.
+	if c.comments != nil {
+		cp.comments = make(map[string]string, len(c.comments))
+		for k, v := range c.comments {
+			cp.comments[k] = v
+		}
+	}
+	cp.invalidRpk = append([]error(nil), c.invalidRpk...)
+	return cp
+}
+
+func merge(base, overlay *Config, appendSlices bool) *Config {
+	merged := new(Config)
+	dst := reflect.ValueOf(merged).Elem()
+	mergeValue(dst, reflect.ValueOf(base).Elem(), false)
+	mergeValue(dst, reflect.ValueOf(overlay).Elem(), appendSlices)
+	merged.file = base.file
+	merged.fileNode = base.fileNode
+	merged.included = base.included
+	merged.loadedPath = base.loadedPath
+	merged.format = base.format
+	return merged
+}
+
+// mergeValue merges src onto dst, which must be settable, copying any map,
+// slice or pointer of src rather than sharing it.
+func mergeValue(dst, src reflect.Value, appendSlices bool) {
+	switch src.Kind() {
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				mergeValue(dst.Field(i), src.Field(i), appendSlices)
+			}
+		}
+
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		if src.Elem().Kind() != reflect.Struct {
+			dst.Set(copyValue(src))
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.New(src.Type().Elem()))
+		}
+		mergeValue(dst.Elem(), src.Elem(), appendSlices)
+
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+		}
+		iter := src.MapRange()
+		for iter.Next() {
+			k, v := iter.Key(), iter.Value()
+			existing := dst.MapIndex(k)
+			if !existing.IsValid() {
+				dst.SetMapIndex(k, copyValue(v))
+				continue
+			}
+			// Map values are not addressable, we merge onto a copy
+			// and store it back.
+			cp := reflect.New(existing.Type()).Elem()
+			cp.Set(existing)
+			mergeMapValue(cp, v, appendSlices)
+			dst.SetMapIndex(k, cp)
+		}
+
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		if src.Len() == 0 {
+			// An empty list only has an effect if there is no list.
+			if dst.IsNil() {
+				dst.Set(reflect.MakeSlice(src.Type(), 0, 0))
+			}
+			return
+		}
+		cp := copyValue(src)
+		if appendSlices && !dst.IsNil() {
+			cp = reflect.AppendSlice(dst, cp)
+		}
+		dst.Set(cp)
+
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		mergeMapValue(dst, src, appendSlices)
+
+	default:
+		if !src.IsZero() {
+			dst.Set(src)
+		}
+	}
+}
+
+// mergeMapValue merges the map value src onto dst: the present src value wins,
+// unless both are maps, which are merged recursively.
+func mergeMapValue(dst, src reflect.Value, appendSlices bool) {
+	d, s := dst, src
+	for d.Kind() == reflect.Interface && !d.IsNil() {
+		d = d.Elem()
+	}
+	for s.Kind() == reflect.Interface && !s.IsNil() {
+		s = s.Elem()
+	}
+	if d.Kind() == reflect.Map && s.Kind() == reflect.Map && d.Type() == s.Type() {
+		merged := copyValue(d)
+		mergeValue(merged, s, appendSlices)
+		dst.Set(merged)
+		return
+	}
+	dst.Set(copyValue(src))
+}
+
+// copyValue returns a deep copy of v.
+func copyValue(v reflect.Value) reflect.Value {
+	cp := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			cp.Set(copyValue(v.Elem()))
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			cp.Set(reflect.New(v.Type().Elem()))
+			cp.Elem().Set(copyValue(v.Elem()))
+		}
+	case reflect.Slice:
+		if !v.IsNil() {
+			cp.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				cp.Index(i).Set(copyValue(v.Index(i)))
+			}
+		}
+	case reflect.Map:
+		if !v.IsNil() {
+			cp.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+			iter := v.MapRange()
+			for iter.Next() {
+				cp.SetMapIndex(iter.Key(), copyValue(iter.Value()))
+			}
+		}
+	case reflect.Struct:
+		cp.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if cp.Field(i).CanSet() {
+				cp.Field(i).Set(copyValue(v.Field(i)))
+			}
+		}
+	default:
+		cp.Set(v)
+	}
+	return cp
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	newBase := func() *Config {
+		c := Default()
+		c.Redpanda.ID = 1
+		c.Redpanda.Rack = "rack-1"
+		c.Redpanda.DeveloperMode = true
+		c.Redpanda.SeedServers = []SeedServer{{Host: SocketAddress{"10.0.0.1", 33145}}}
+		c.Redpanda.Other = map[string]interface{}{
+			"tuning": map[string]interface{}{
+				"a": 1,
+				"b": 2,
+			},
+			"kept": "base",
+		}
+		return c
+	}
+
+	overlay := &Config{
+		Redpanda: RedpandaConfig{
+			ID:          2,
+			SeedServers: []SeedServer{{Host: SocketAddress{"10.0.0.2", 33145}}},
+			Other: map[string]interface{}{
+				"tuning": map[string]interface{}{
+					"b": 3,
+					"c": 4,
+				},
+				"zero": false,
+			},
+		},
+		Rpk: RpkConfig{
+			KafkaAPI: RpkKafkaAPI{Brokers: []string{"10.0.0.2:9092"}},
+		},
+	}
+
+	base := newBase()
+	merged := Merge(base, overlay)
+
+	// Non-zero scalars win, zero values keep the base.
+	require.Equal(t, 2, merged.Redpanda.ID)
+	require.Equal(t, "rack-1", merged.Redpanda.Rack)
+	require.True(t, merged.Redpanda.DeveloperMode)
+	require.Equal(t, base.Redpanda.RPCServer, merged.Redpanda.RPCServer)
+	require.Equal(t, base.Redpanda.KafkaAPI, merged.Redpanda.KafkaAPI)
+	require.Equal(t, base.ConfigFile, merged.ConfigFile)
+
+	// Maps merge recursively, present keys win even if zero.
+	require.Equal(t, map[string]interface{}{
+		"tuning": map[string]interface{}{
+			"a": 1,
+			"b": 3,
+			"c": 4,
+		},
+		"kept": "base",
+		"zero": false,
+	}, merged.Redpanda.Other)
+
+	// Slices are replaced wholesale.
+	require.Equal(t, []SeedServer{{Host: SocketAddress{"10.0.0.2", 33145}}}, merged.Redpanda.SeedServers)
+	require.Equal(t, []string{"10.0.0.2:9092"}, merged.Rpk.KafkaAPI.Brokers)
+
+	// Neither input is modified, nor shared.
+	require.Equal(t, newBase(), base)
+	merged.Redpanda.SeedServers[0].Host.Port = 1
+	merged.Redpanda.Other["tuning"].(map[string]interface{})["a"] = 0
+	require.Equal(t, newBase(), base)
+	require.Equal(t, 3, overlay.Redpanda.Other["tuning"].(map[string]interface{})["b"])
+	require.Equal(t, 33145, overlay.Redpanda.SeedServers[0].Host.Port)
+
+	// With MergeAppend, slices are appended.
+	merged = MergeAppend(newBase(), overlay)
+	require.Equal(t, []SeedServer{
+		{Host: SocketAddress{"10.0.0.1", 33145}},
+		{Host: SocketAddress{"10.0.0.2", 33145}},
+	}, merged.Redpanda.SeedServers)
+}
+
+func TestMergeZeroOverlay(t *testing.T) {
+	base := Default()
+	base.Redpanda.ID = 3
+	base.Redpanda.AdvertisedRPCAPI = &SocketAddress{"10.0.0.1", 33145}
+	base.Redpanda.SeedServers = []SeedServer{{Host: SocketAddress{"10.0.0.1", 33145}}}
+
+	require.Equal(t, base, Merge(base, new(Config)))
+	require.Equal(t, base, Merge(base, &Config{Redpanda: RedpandaConfig{SeedServers: []SeedServer{}}}))
+
+	// Non-nil pointers win, and pointers to structs are merged.
+	merged := Merge(base, &Config{Redpanda: RedpandaConfig{AdvertisedRPCAPI: &SocketAddress{Port: 33146}}})
+	require.Equal(t, &SocketAddress{"10.0.0.1", 33146}, merged.Redpanda.AdvertisedRPCAPI)
+	require.Equal(t, &SocketAddress{"10.0.0.1", 33145}, base.Redpanda.AdvertisedRPCAPI)
+}
+
+func TestClone(t *testing.T) {
+	c := Default()
+	c.Redpanda.AdvertisedRPCAPI = &SocketAddress{"10.0.0.1", 33145}
+	c.Redpanda.SeedServers = []SeedServer{{Host: SocketAddress{"10.0.0.1", 33145}}}
+	c.Redpanda.KafkaAPITLS = []ServerTLS{{Name: "internal", Other: map[string]interface{}{"extra": []interface{}{1}}}}
+	c.Redpanda.Other = map[string]interface{}{"tunables": map[string]interface{}{"a": 1}}
+	c.Rpk.KafkaAPI.Brokers = []string{"10.0.0.1:9092"}
+	c.SetComment("redpanda.node_id", "set by bootstrap")
+
+	cp := c.Clone()
+	require.Equal(t, c, cp)
+
+	// Changing the clone leaves the original untouched.
+	cp.Redpanda.AdvertisedRPCAPI.Port = 33146
+	cp.Redpanda.SeedServers[0].Host.Address = "10.0.0.2"
+	cp.Redpanda.KafkaAPI[0].Port = 9093
+	cp.Redpanda.KafkaAPITLS[0].Other["extra"].([]interface{})[0] = 2
+	cp.Redpanda.Other["tunables"].(map[string]interface{})["a"] = 2
+	cp.Rpk.KafkaAPI.Brokers[0] = "10.0.0.2:9092"
+	cp.SetComment("redpanda.node_id", "changed")
+
+	orig := Default()
+	require.Equal(t, &SocketAddress{"10.0.0.1", 33145}, c.Redpanda.AdvertisedRPCAPI)
+	require.Equal(t, []SeedServer{{Host: SocketAddress{"10.0.0.1", 33145}}}, c.Redpanda.SeedServers)
+	require.Equal(t, orig.Redpanda.KafkaAPI, c.Redpanda.KafkaAPI)
+	require.Equal(t, map[string]interface{}{"extra": []interface{}{1}}, c.Redpanda.KafkaAPITLS[0].Other)
+	require.Equal(t, map[string]interface{}{"tunables": map[string]interface{}{"a": 1}}, c.Redpanda.Other)
+	require.Equal(t, []string{"10.0.0.1:9092"}, c.Rpk.KafkaAPI.Brokers)
+	require.Equal(t, "set by bootstrap", c.comments["redpanda.node_id"])
+}
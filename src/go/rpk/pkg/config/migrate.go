@@ -0,0 +1,254 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// A migration rewrites a legacy layout of the config file to the layout of
+// the schema version it is indexed at in migrations, plus one. It edits the
+// root mapping of the file in place and reports whether it changed anything,
+// a file that does not use the legacy layout being left untouched.
+type migration struct {
+	description string
+	migrate     func(root *yaml.Node) (bool, error)
+}
+
+// migrations are the config file migrations, in schema version order: the
+// migration at index i upgrades a file from version i to version i+1.
+var migrations = [...]migration{
+	{"moved rpk.tls and rpk.sasl to rpk.kafka_api and rpk.admin_api", migrateRpkTLS},
+	{"moved the redpanda.seed_servers addresses under host and dropped their node_id", migrateSeedServers},
+}
+
+// ConfigVersion is the current schema version of the config file, see
+// Config.Version.
+const ConfigVersion = len(migrations)
+
+// Migration is the result of migrating a config file, see Migrate.
+type Migration struct {
+	// Path is the path of the migrated config file.
+	Path string
+	// Applied are the descriptions of the migrations that changed the
+	// file, in order. If empty, the file already uses the current schema.
+	Applied []string
+	// Before and After are the contents of the file before and after the
+	// migration.
+	Before, After []byte
+}
+
+// Migrate rewrites the YAML config file contents b to the current schema, see
+// Params.Migrate. Only the migrations to the versions after the
+// config_version of the file apply, and the migrated file is stamped with
+// ConfigVersion.
+func Migrate(b []byte) (*Migration, error) {
+	m := &Migration{Before: b, After: b}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, withKind(ErrInvalidFormat, fmt.Errorf("unable to yaml decode config: %w", err))
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return m, nil
+	}
+	root := doc.Content[0]
+
+	var version int
+	if v := mappingValue(root, "config_version"); v != nil {
+		if err := v.Decode((*weakInt)(&version)); err != nil {
+			return nil, withKind(ErrInvalidFormat, fmt.Errorf("invalid config_version: %w", err))
+		}
+	}
+	if version < 0 {
+		version = 0
+	}
+	for i := version; i < len(migrations); i++ {
+		changed, err := migrations[i].migrate(root)
+		if err != nil {
+			return nil, fmt.Errorf("unable to migrate config: %w", err)
+		}
+		if changed {
+			m.Applied = append(m.Applied, migrations[i].description)
+		}
+	}
+	if len(m.Applied) == 0 {
+		return m, nil
+	}
+	stamp := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(ConfigVersion)}
+	if mappingValue(root, "config_version") != nil {
+		setMappingValue(root, scalarNode("config_version"), stamp)
+	} else {
+		// At the top, where Config marshals it.
+		root.Content = append([]*yaml.Node{scalarNode("config_version"), stamp}, root.Content...)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(detectIndent(b))
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	m.After = buf.Bytes()
+	return m, nil
+}
+
+// Migrate rewrites the config file that Load would load from a legacy layout
+// to the current schema, preserving its values and comments. Unless dryRun is
+// set, the migrated file is written back in place if any migration applied.
+//
+// TOML config files are supported since the current schema, and are never
+// migrated.
+func (p *Params) Migrate(fs afero.Fs, dryRun bool) (*Migration, error) {
+	path, err := p.LocateConfig(fs)
+	if err != nil {
+		return nil, err
+	}
+	b, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	if format, err := fileFormat(p.ConfigFormat, path); err != nil {
+		return nil, err
+	} else if format == FormatTOML {
+		return &Migration{Path: path, Before: b, After: b}, nil
+	}
+
+	m, err := Migrate(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	m.Path = path
+	if dryRun || len(m.Applied) == 0 {
+		return m, nil
+	}
+	if err := writeAtomic(fs, path, m.After, path); err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			err = withKind(ErrWritePermission, err)
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+// migrateRpkTLS moves the deprecated rpk.tls and rpk.sasl to the
+// rpk.kafka_api and rpk.admin_api sections, as the loading does: rpk.tls
+// applies to both APIs and rpk.sasl to the Kafka API, unless they have their
+// own already.
+func migrateRpkTLS(root *yaml.Node) (bool, error) {
+	rpk := mappingValue(root, "rpk")
+	if rpk == nil {
+		return false, nil
+	}
+	tls, sasl := mappingValue(rpk, "tls"), mappingValue(rpk, "sasl")
+	if tls == nil && sasl == nil {
+		return false, nil
+	}
+	moveTo := func(section, key string, v *yaml.Node) {
+		if v == nil {
+			return
+		}
+		s := mappingValue(rpk, section)
+		if s == nil || s.Kind != yaml.MappingNode {
+			s = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(rpk, scalarNode(section), s)
+		}
+		if mappingValue(s, key) == nil {
+			setMappingValue(s, scalarNode(key), copyNode(v))
+		}
+	}
+	moveTo("kafka_api", "tls", tls)
+	moveTo("admin_api", "tls", tls)
+	moveTo("kafka_api", "sasl", sasl)
+	deleteMappingKey(rpk, "tls")
+	deleteMappingKey(rpk, "sasl")
+	return true, nil
+}
+
+// migrateSeedServers rewrites the legacy seed server layouts: a single seed
+// server rather than a list, the address and port of a seed server at its
+// top level rather than under host, and the seed server node_id, which is
+// unused.
+func migrateSeedServers(root *yaml.Node) (bool, error) {
+	seeds := mappingValue(mappingValue(root, "redpanda"), "seed_servers")
+	if seeds == nil {
+		return false, nil
+	}
+	var changed bool
+	if seeds.Kind == yaml.MappingNode {
+		single := *seeds
+		*seeds = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{&single}}
+		changed = true
+	}
+	for i, seed := range seeds.Content {
+		if seed.Kind != yaml.MappingNode {
+			continue
+		}
+		if mappingValue(seed, "node_id") != nil {
+			deleteMappingKey(seed, "node_id")
+			changed = true
+		}
+		addr, port := mappingValue(seed, "address"), mappingValue(seed, "port")
+		if addr == nil && port == nil {
+			continue
+		}
+		host := mappingValue(seed, "host")
+		if host == nil || host.Kind != yaml.MappingNode {
+			host = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(seed, scalarNode("host"), host)
+		}
+		for _, key := range []string{"address", "port"} {
+			v := mappingValue(seed, key)
+			if v == nil {
+				continue
+			}
+			if existing := mappingValue(host, key); existing != nil && existing.Value != v.Value {
+				return false, fmt.Errorf("redpanda.seed_servers.%d: host.%s %q differs from %s %q, only one must be set", i, key, existing.Value, key, v.Value)
+			}
+			setMappingValue(host, scalarNode(key), v)
+			deleteMappingKey(seed, key)
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+func scalarNode(v string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+}
+
+// copyNode returns a deep copy of n.
+func copyNode(n *yaml.Node) *yaml.Node {
+	cp := *n
+	cp.Content = make([]*yaml.Node, len(n.Content))
+	for i, c := range n.Content {
+		cp.Content[i] = copyNode(c)
+	}
+	return &cp
+}
+
+// deleteMappingKey removes key and its value from the mapping node n.
+func deleteMappingKey(n *yaml.Node, key string) {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			n.Content = append(n.Content[:i], n.Content[i+2:]...)
+			return
+		}
+	}
+}
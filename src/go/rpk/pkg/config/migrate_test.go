@@ -0,0 +1,222 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		in         string
+		exp        string
+		expApplied int
+		expErr     bool
+	}{
+		{
+			name: "current schema",
+			in: `redpanda:
+    seed_servers:
+        - host:
+            address: 10.0.0.1
+            port: 33145
+rpk:
+    kafka_api:
+        tls:
+            cert_file: /cert.pem
+`,
+		},
+		{
+			name: "rpk tls and sasl",
+			in: `rpk:
+    # The TLS of every API.
+    tls:
+        cert_file: /cert.pem
+    sasl:
+        user: admin
+    admin_api:
+        tls:
+            cert_file: /admin.pem
+    tune_network: true
+`,
+			exp: `config_version: 2
+rpk:
+    admin_api:
+        tls:
+            cert_file: /admin.pem
+    tune_network: true
+    kafka_api:
+        tls:
+            cert_file: /cert.pem
+        sasl:
+            user: admin
+`,
+			expApplied: 1,
+		},
+		{
+			name: "seed servers",
+			in: `redpanda:
+  node_id: 1
+  seed_servers:
+    - node_id: 2
+      address: 10.0.0.2
+      port: 33145
+    - host:
+        address: 10.0.0.3 # the third node
+        port: 33145
+      node_id: 3
+`,
+			exp: `config_version: 2
+redpanda:
+  node_id: 1
+  seed_servers:
+    - host:
+        address: 10.0.0.2
+        port: 33145
+    - host:
+        address: 10.0.0.3 # the third node
+        port: 33145
+`,
+			expApplied: 1,
+		},
+		{
+			name: "single seed server and rpk tls",
+			in: `redpanda:
+    seed_servers:
+        address: 10.0.0.2
+        port: 33145
+rpk:
+    tls:
+        truststore_file: /ca.pem
+`,
+			exp: `config_version: 2
+redpanda:
+    seed_servers:
+        - host:
+            address: 10.0.0.2
+            port: 33145
+rpk:
+    kafka_api:
+        tls:
+            truststore_file: /ca.pem
+    admin_api:
+        tls:
+            truststore_file: /ca.pem
+`,
+			expApplied: 2,
+		},
+		{
+			name: "versioned file",
+			in: `config_version: 1
+redpanda:
+    seed_servers:
+        address: 10.0.0.2
+        port: 33145
+rpk:
+    tls:
+        truststore_file: /ca.pem
+`,
+			exp: `config_version: 2
+redpanda:
+    seed_servers:
+        - host:
+            address: 10.0.0.2
+            port: 33145
+rpk:
+    tls:
+        truststore_file: /ca.pem
+`,
+			expApplied: 1,
+		},
+		{
+			name: "current version",
+			in: `config_version: 2
+rpk:
+    tls:
+        truststore_file: /ca.pem
+`,
+		},
+		{
+			name: "conflicting seed server addresses",
+			in: `redpanda:
+    seed_servers:
+        - address: 10.0.0.2
+          host:
+            address: 10.0.0.3
+`,
+			expErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := Migrate([]byte(test.in))
+			if test.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, m.Applied, test.expApplied)
+			exp := test.exp
+			if exp == "" {
+				exp = test.in
+			}
+			require.Equal(t, exp, string(m.After))
+
+			// The migrated file loads to the same configuration.
+			before, after := loadBytes(t, test.in), loadBytes(t, string(m.After))
+			require.Equal(t, before.Redpanda, after.Redpanda)
+			require.Equal(t, before.Rpk.KafkaAPI, after.Rpk.KafkaAPI)
+			require.Equal(t, before.Rpk.AdminAPI, after.Rpk.AdminAPI)
+
+			// Migrations are idempotent.
+			again, err := Migrate(m.After)
+			require.NoError(t, err)
+			require.Empty(t, again.Applied)
+		})
+	}
+}
+
+func loadBytes(t *testing.T, b string) *Config {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(b), 0o644))
+	cfg, err := new(Params).Load(fs)
+	require.NoError(t, err)
+	return cfg
+}
+
+func TestParamsMigrate(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	const in = `rpk:
+    tls:
+        cert_file: /cert.pem
+`
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, path, []byte(in), 0o600))
+
+	m, err := new(Params).Migrate(fs, true)
+	require.NoError(t, err)
+	require.Equal(t, path, m.Path)
+	require.Len(t, m.Applied, 1)
+	b, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	require.Equal(t, in, string(b), "dry run wrote the file")
+
+	m, err = new(Params).Migrate(fs, false)
+	require.NoError(t, err)
+	b, err = afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	require.Equal(t, string(m.After), string(b))
+	stat, err := fs.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, 0o600, int(stat.Mode().Perm()))
+}
@@ -10,13 +10,18 @@
 package config
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -76,10 +81,29 @@ const (
 	// FlagConfig is rpk config flag.
 	FlagConfig = "config"
 
-	// FlagVerbose opts in to verbose logging. This is to be replaced with
-	// a log-level flag later, with `-v` meaning DEBUG for backcompat.
+	// EnvConfig is the config file path to use if --config is not set,
+	// ahead of the SearchPaths.
+	EnvConfig = "REDPANDA_CONFIG"
+
+	// FlagConfigFormat is the format of the config file, overriding the
+	// format of the file extension.
+	FlagConfigFormat = "config-format"
+
+	// FlagStrict turns the warning about the unknown keys of the config
+	// file into an error, see Params.Strict.
+	FlagStrict = "strict"
+
+	// FlagVerbose opts in to verbose logging. --log-level debug implies
+	// it.
 	FlagVerbose = "verbose"
 
+	// FlagLogLevel is the level of the logs to print, see Params.LogLevel.
+	FlagLogLevel = "log-level"
+
+	// FlagCreateDirs opts the commands that define it in to creating the
+	// missing directory of the config file, see Params.NoCreateDirs.
+	FlagCreateDirs = "create-dirs"
+
 	// This entire block is filled with our current flags and environment
 	// variables. These will all eventually be hidden.
 
@@ -140,10 +164,27 @@ type Params struct {
 	// This is unused until step (2) in the refactoring process.
 	ConfigPath string
 
-	// Verbose tracks the -v flag. This will be swapped with --log-level in
-	// the future.
+	// ConfigFormat is any flag-specified config file format, either yaml
+	// or toml. If empty, the format is detected from the file extension.
+	ConfigFormat string
+
+	// Strict makes Load and LoadFrom fail if the config file has unknown
+	// keys, rather than log a warning listing them.
+	Strict bool
+
+	// Verbose tracks the -v flag, and is set if LogLevel is debug.
 	Verbose bool
 
+	// LogLevel tracks the --log-level flag: debug, info, warn or error.
+	LogLevel string
+
+	// NoCreateDirs makes Load and LockConfig leave the directory of a
+	// config file that does not exist yet missing, for the writes to fail
+	// rather than a typo in --config creating stray directories. It is set
+	// for the commands that define the --create-dirs flag, which create
+	// the directory themselves if the flag is set.
+	NoCreateDirs bool
+
 	// FlagOverrides are any flag-specified config overrides.
 	//
 	// This is unused until step (2) in the refactoring process.
@@ -172,12 +213,29 @@ func ParamsFromCommand(cmd *cobra.Command) *Params {
 				p.ConfigPath = f.Value.String()
 				return
 
+			case FlagConfigFormat:
+				p.ConfigFormat = f.Value.String()
+				return
+
 			case FlagVerbose:
 				if b, err := strconv.ParseBool(f.Value.String()); err == nil {
 					p.Verbose = b
 				}
 				return
 
+			case FlagLogLevel:
+				p.LogLevel = f.Value.String()
+				if strings.EqualFold(p.LogLevel, "debug") {
+					p.Verbose = true
+				}
+				return
+
+			case FlagStrict:
+				if b, err := strconv.ParseBool(f.Value.String()); err == nil {
+					p.Strict = b
+				}
+				return
+
 			case FlagBrokers:
 				key = xKafkaBrokers
 				stripBrackets = true
@@ -223,36 +281,209 @@ func ParamsFromCommand(cmd *cobra.Command) *Params {
 			p.FlagOverrides = append(p.FlagOverrides, key+"="+val)
 		})
 	}
+	p.NoCreateDirs = cmd.Flags().Lookup(FlagCreateDirs) != nil
 
 	return &p
 }
 
 // Load returns the param's config file. In order, this
 //
-//  * Finds the config file, per the --config flag or the default search set.
-//  * Decodes the config over the default configuration.
+//  * Finds the config file, per the --config flag, REDPANDA_CONFIG or the
+//    SearchPaths.
+//  * Decodes the config over the default configuration, as TOML if it has a
+//    .toml extension or --config-format is toml, as YAML otherwise.
+//  * Warns about, or with Strict fails on, the keys it does not know about.
 //  * Back-compats any old format into any new format.
 //  * Processes env and flag overrides.
 //  * Sets unset default values.
 //
+// If the --config or REDPANDA_CONFIG file does not exist yet, Load creates its
+// directory, for the config to be written there, unless NoCreateDirs is set.
 func (p *Params) Load(fs afero.Fs) (*Config, error) {
-	cf := "/etc/redpanda/redpanda.yaml"
-	// If we have a config path loaded (through --config flag) the user
-	// expect to load or create the file from this directory.
-	if p.ConfigPath != "" {
-		if exist, _ := afero.Exists(fs, p.ConfigPath); !exist {
-			err := fs.MkdirAll(filepath.Dir(p.ConfigPath), 0o755)
+	// If we have a config path loaded (through --config flag or
+	// REDPANDA_CONFIG) the user expect to load or create the file from
+	// this directory.
+	if path := p.configPath(); path != "" && !p.NoCreateDirs {
+		if exist, _ := afero.Exists(fs, path); !exist {
+			err := fs.MkdirAll(filepath.Dir(path), 0o755)
 			if err != nil {
 				return nil, err
 			}
 		}
-		abs, err := filepath.Abs(p.ConfigPath)
+	}
+	cf, err := p.defaultPath()
+	if err != nil {
+		return nil, err
+	}
+	c := loadDefaults(cf)
+	format, err := fileFormat(p.ConfigFormat, cf)
+	if err != nil {
+		return nil, err
+	}
+	c.format = format
+	if err := p.readConfig(fs, c); err != nil {
+		// Sometimes a config file will not exist (e.g. rpk running on MacOS),
+		// which is OK. In those cases, just return the default config.
+		if !errors.Is(err, afero.ErrFileNotFound) {
+			return nil, err
+		}
+		logger.Debugf("No config file found, using the default configuration, to be written to %s", cf)
+	}
+	if err := p.checkUnknownKeys(c); err != nil {
+		return nil, err
+	}
+	return p.finishLoad(c)
+}
+
+// ResolvePath returns the absolute path of the config file that Load reads
+// and Write writes, and whether it exists, without reading nor creating
+// anything: the first existing file of LocateConfig, or else the --config or
+// REDPANDA_CONFIG path, or else the default /etc/redpanda/redpanda.yaml.
+func (p *Params) ResolvePath(fs afero.Fs) (string, bool, error) {
+	if path, err := p.LocateConfig(fs); err == nil {
+		abs, err := filepath.Abs(path)
+		return abs, true, err
+	}
+	path, err := p.defaultPath()
+	return path, false, err
+}
+
+// defaultPath returns the absolute path of the config file to write if no
+// config file is found: the --config or REDPANDA_CONFIG path, if set.
+func (p *Params) defaultPath() (string, error) {
+	if path := p.configPath(); path != "" {
+		return filepath.Abs(path)
+	}
+	return "/etc/redpanda/redpanda.yaml", nil
+}
+
+// DefaultConfig returns the default configuration, without reading any
+// config file. It is written to the --config path if set, or to the default
+// config file location, in the format of the path extension or --config-format.
+func (p *Params) DefaultConfig() (*Config, error) {
+	c := Default()
+	if path := p.configPath(); path != "" {
+		abs, err := filepath.Abs(path)
 		if err != nil {
 			return nil, err
 		}
-		cf = abs
+		c.ConfigFile = abs
+	}
+	format, err := fileFormat(p.ConfigFormat, c.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	c.format = format
+	return c, nil
+}
+
+// LoadFrom is Load, but it decodes the configuration from r rather than from
+// the config file. The returned configuration is not tied to any file: Write
+// writes it to the default config file location.
+func (p *Params) LoadFrom(r io.Reader) (*Config, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config: %v", err)
 	}
-	c := &Config{
+	format, err := fileFormat(p.ConfigFormat, "")
+	if err != nil {
+		return nil, err
+	}
+	c, err := readFromBytes(b, format, "config")
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkUnknownKeys(c); err != nil {
+		return nil, err
+	}
+	return p.finishLoad(c)
+}
+
+// ReadFromBytes decodes the configuration from b, in the given format: yaml
+// (the default if format is empty), json or toml. It is LoadFrom without any
+// reader nor Params, and thus without the environment overrides nor the
+// unknown keys check: as with Load, the sections in b replace the default
+// ones, and the unset defaults are filled. It is not tied to any file: Write
+// writes it to the
+// default config file location, as TOML if it was decoded from TOML and as
+// YAML otherwise.
+//
+// If b cannot be decoded, the returned error is an ErrInvalidFormat.
+func ReadFromBytes(b []byte, format string) (*Config, error) {
+	switch strings.ToLower(format) {
+	case FormatYAML, "":
+		format = FormatYAML
+	case FormatJSON:
+	case FormatTOML:
+		format = FormatTOML
+	default:
+		return nil, withKind(ErrInvalidFormat, fmt.Errorf("unsupported format %q, must be %s, %s or %s", format, FormatYAML, FormatJSON, FormatTOML))
+	}
+	c, err := readFromBytes(b, format, "config")
+	if err != nil {
+		return nil, err
+	}
+	c.backcompat()
+	c.addUnsetDefaults()
+	return c, nil
+}
+
+// readFromBytes decodes the configuration from b over the defaults, keeping
+// what b holds for the writes to preserve it. JSON is decoded as the YAML it
+// is, for the same weak decoding to apply. name is what b is called in the
+// decoding errors.
+func readFromBytes(b []byte, format, name string) (*Config, error) {
+	c := loadDefaults("/etc/redpanda/redpanda.yaml")
+	c.format = format
+	if format == FormatJSON {
+		c.format = FormatYAML
+	}
+	if format == FormatTOML {
+		var err error
+		if b, err = tomlToYAML(b); err != nil {
+			return nil, fmt.Errorf("unable to decode %s: %w", name, err)
+		}
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return c, nil
+	}
+	if err := yaml.Unmarshal(b, c); err != nil {
+		decoder := FormatYAML
+		if format == FormatJSON {
+			decoder = FormatJSON
+		}
+		return nil, withKind(ErrInvalidFormat, fmt.Errorf("unable to %s decode %s: %w", decoder, name, err))
+	}
+	yaml.Unmarshal(b, &c.file) // cannot error since previous did not
+	c.fileNode = new(yaml.Node)
+	yaml.Unmarshal(b, c.fileNode)
+	c.invalidRpk = checkRpkSection(b)
+	return c, nil
+}
+
+// checkUnknownKeys logs a warning listing the unknown keys of the config file
+// that c was loaded from, or returns an ErrUnknownKey listing them if Strict
+// is set.
+func (p *Params) checkUnknownKeys(c *Config) error {
+	unknown := c.UnknownKeys()
+	if len(unknown) == 0 {
+		return nil
+	}
+	src := "config"
+	if c.loadedPath != "" {
+		src = c.loadedPath
+	}
+	if p.Strict {
+		return withKind(ErrUnknownKey, fmt.Errorf("%s has unknown keys: %s", src, strings.Join(unknown, ", ")))
+	}
+	logger.Warnf("%s has unknown keys, which are passed through to redpanda as is: %s", src, strings.Join(unknown, ", "))
+	return nil
+}
+
+// loadDefaults returns the configuration that the config file is decoded
+// over.
+func loadDefaults(cf string) *Config {
+	return &Config{
 		ConfigFile: cf,
 		Redpanda: RedpandaConfig{
 			Directory: "/var/lib/redpanda/data",
@@ -277,14 +508,9 @@ func (p *Params) Load(fs afero.Fs) (*Config, error) {
 		Pandaproxy:     &Pandaproxy{},
 		SchemaRegistry: &SchemaRegistry{},
 	}
+}
 
-	if err := p.readConfig(fs, c); err != nil {
-		// Sometimes a config file will not exist (e.g. rpk running on MacOS),
-		// which is OK. In those cases, just return the default config.
-		if !errors.Is(err, afero.ErrFileNotFound) {
-			return nil, err
-		}
-	}
+func (p *Params) finishLoad(c *Config) (*Config, error) {
 	c.backcompat()
 	if err := p.processOverrides(c); err != nil {
 		return nil, err
@@ -293,47 +519,214 @@ func (p *Params) Load(fs afero.Fs) (*Config, error) {
 	return c, nil
 }
 
+// Render returns the configuration serialized in the given format, yaml (the
+// default if format is empty), json, toml or hcl, without touching the filesystem.
+// These are the exact bytes Write would write for a configuration that was not
+// loaded from a file.
+func Render(conf *Config, format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case FormatYAML, "":
+		return yaml.Marshal(conf)
+	case FormatJSON:
+		b, err := json.MarshalIndent(conf, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	case FormatTOML:
+		b, err := yaml.Marshal(conf)
+		if err != nil {
+			return nil, err
+		}
+		return yamlToTOML(b)
+	case FormatHCL:
+		return MarshalHCL(conf)
+	default:
+		return nil, withKind(ErrInvalidFormat, fmt.Errorf("unsupported format %q, must be %s, %s, %s or %s", format, FormatYAML, FormatJSON, FormatTOML, FormatHCL))
+	}
+}
+
+// WriteToBytes returns the configuration as Write would write it to a new
+// file, in the given format (see Render), without touching the filesystem. It
+// is the counterpart of ReadFromBytes.
+func WriteToBytes(conf *Config, format string) ([]byte, error) {
+	return Render(conf, format)
+}
+
+// Encode writes the configuration to w, as TOML if it was loaded from a TOML
+// file and as YAML otherwise.
+func (c *Config) Encode(w io.Writer) error {
+	return c.encode(w, c.format)
+}
+
+// EncodeTOML writes the configuration as TOML to w.
+func (c *Config) EncodeTOML(w io.Writer) error {
+	return c.encode(w, FormatTOML)
+}
+
+func (c *Config) encode(w io.Writer, format string) error {
+	b, err := WriteToBytes(c, format)
+	if err != nil {
+		return fmt.Errorf("marshal error in loaded config, err: %s", err)
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// RestoreBackup restores the config file at path from the backup file from,
+// or, if from is empty, from path with the suffix appended, as WriteWithBackup
+// writes it, ".bak" if the suffix is empty. The backup must be a valid config
+// file, in the format of path's extension.
+//
+// The config file being replaced, if any, is backed up in turn to path with
+// the suffix appended, so that the restore can itself be undone, and its
+// backup path is returned. If there is no backup, the returned error is an
+// ErrConfigNotFound.
+func RestoreBackup(fs afero.Fs, path, from, suffix string) (string, error) {
+	if suffix == "" {
+		suffix = ".bak"
+	}
+	if from == "" {
+		from = path + suffix
+	}
+	b, err := afero.ReadFile(fs, from)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", withKind(ErrConfigNotFound, fmt.Errorf("%w: no backup to restore, %s does not exist", afero.ErrFileNotFound, from))
+		}
+		return "", fmt.Errorf("unable to read backup: %w", err)
+	}
+	format := FormatYAML
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		format = FormatTOML
+	}
+	if _, err := ReadFromBytes(b, format); err != nil {
+		return "", fmt.Errorf("backup %s is not a valid config file: %w", from, err)
+	}
+
+	var (
+		current  []byte
+		mode     os.FileMode
+		origPath string
+	)
+	stat, err := fs.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return "", fmt.Errorf("unable to stat existing config file: %v", err)
+	default:
+		if current, err = afero.ReadFile(fs, path); err != nil {
+			return "", fmt.Errorf("unable to read existing config file: %v", err)
+		}
+		mode, origPath = stat.Mode(), path
+	}
+	// The backup is read already, it can be overwritten with the replaced
+	// config file once restored.
+	if err := writeAtomic(fs, path, b, origPath); err != nil {
+		return "", err
+	}
+	if origPath == "" {
+		return "", nil
+	}
+	if err := writeFileSync(fs, path+suffix, current, mode); err != nil {
+		return "", fmt.Errorf("restored %s, but unable to back up the replaced config file: %w", path, err)
+	}
+	return path + suffix, nil
+}
+
 // Write writes loaded configuration parameters to redpanda.yaml.
+//
+// The configuration is first written and synced to a temporary file in the
+// same directory, which is then renamed over the destination. This way, the
+// destination is either fully updated or left untouched. If the filesystem
+// does not support the rename, we fall back to writing the file in place.
+//
+// The config_version of the file is kept as it was loaded: only Migrate
+// stamps it.
 func (c *Config) Write(fs afero.Fs) (rerr error) {
-	cfgPath := c.loadedPath
-	if cfgPath == "" {
-		cfgPath = c.ConfigFile
+	defer func() {
+		if errors.Is(rerr, os.ErrPermission) {
+			rerr = withKind(ErrWritePermission, rerr)
+		}
+	}()
+	b, err := c.marshal(fs)
+	if err != nil {
+		return fmt.Errorf("marshal error in loaded config, err: %s", err)
+	}
+	return writeAtomic(fs, c.FileLocation(), b, c.loadedPath)
+}
+
+// WriteAs is Write, but it writes the configuration to path rather than to
+// the file it was loaded from, which is left untouched, e.g. to render a node
+// configuration from a base one. The written config_file is path. If path
+// already exists, it keeps its permissions and ownership.
+func (c *Config) WriteAs(fs afero.Fs, path string) (rerr error) {
+	defer func() {
+		if errors.Is(rerr, os.ErrPermission) {
+			rerr = withKind(ErrWritePermission, rerr)
+		}
+	}()
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
 	}
-	b, err := yaml.Marshal(c)
+	cp := *c
+	cp.ConfigFile = abs
+	b, err := cp.marshal(fs)
 	if err != nil {
 		return fmt.Errorf("marshal error in loaded config, err: %s", err)
 	}
+	var origPath string
+	if exists, _ := afero.Exists(fs, abs); exists {
+		origPath = abs
+	}
+	return writeAtomic(fs, abs, b, origPath)
+}
 
+// writeAtomic writes b to cfgPath through a temporary file renamed over it,
+// see Write. If origPath is not empty, the written file keeps the permissions
+// and ownership of the file at origPath.
+func writeAtomic(fs afero.Fs, cfgPath string, b []byte, origPath string) (rerr error) {
 	// Create a temp file.
 	layout := "20060102150405" // year-month-day-hour-min-sec
 	bFilename := "redpanda-" + time.Now().Format(layout) + ".yaml"
 	temp := filepath.Join(filepath.Dir(cfgPath), bFilename)
 
-	err = afero.WriteFile(fs, temp, b, 0o644) // default permissions 644
+	if origPath != "" {
+		logger.Debugf("Replacing config file %s through temporary file %s", cfgPath, temp)
+	} else {
+		logger.Debugf("Writing new config file %s through temporary file %s", cfgPath, temp)
+	}
+	err := writeFileSync(fs, temp, b, 0o644) // default permissions 644
 	if err != nil {
-		return fmt.Errorf("error writing to temporary file: %v", err)
+		// The error may have happened mid-write: we remove whatever
+		// partial temp file was left.
+		fs.Remove(temp)
+		return fmt.Errorf("error writing to temporary file: %w", err)
 	}
 	defer func() {
 		if rerr != nil {
 			if removeErr := fs.Remove(temp); removeErr != nil {
-				rerr = fmt.Errorf("%s, unable to remove temp file: %v", rerr, removeErr)
+				rerr = fmt.Errorf("%w, unable to remove temp file: %v", rerr, removeErr)
 			} else {
-				rerr = fmt.Errorf("%s, temp file removed from disk", rerr)
+				rerr = fmt.Errorf("%w, temp file removed from disk", rerr)
 			}
 		}
 	}()
 
 	// If we have a loaded file we keep permission and ownership of the
 	// original config file.
-	if c.loadedPath != "" {
-		stat, err := fs.Stat(c.loadedPath)
+	mode := os.FileMode(0o644)
+	if origPath != "" {
+		stat, err := fs.Stat(origPath)
 		if err != nil {
-			return fmt.Errorf("unable to stat existing file: %v", err)
+			return fmt.Errorf("unable to stat existing file: %w", err)
 		}
+		mode = stat.Mode()
 
-		err = fs.Chmod(temp, stat.Mode())
+		err = fs.Chmod(temp, mode)
 		if err != nil {
-			return fmt.Errorf("unable to chmod temp config file: %v", err)
+			return fmt.Errorf("unable to chmod temp config file: %w", err)
 		}
 
 		// Stat_t is only valid in unix not on Windows.
@@ -342,45 +735,234 @@ func (c *Config) Write(fs afero.Fs) (rerr error) {
 			uid := int(stat.Uid)
 			err = fs.Chown(temp, uid, gid)
 			if err != nil {
-				return fmt.Errorf("unable to chown temp config file: %v", err)
+				return fmt.Errorf("unable to chown temp config file: %w", err)
 			}
 		}
 	}
 
 	err = fs.Rename(temp, cfgPath)
 	if err != nil {
-		return err
+		logger.Debugf("Unable to rename %s over %s, writing the config file in place: %v", temp, cfgPath, err)
+		if werr := writeFileSync(fs, cfgPath, b, mode); werr != nil {
+			return fmt.Errorf("unable to rename temp config file: %v; unable to write config file in place: %w", err, werr)
+		}
+		fs.Remove(temp)
 	}
 
 	return nil
 }
 
-func (p *Params) LocateConfig(fs afero.Fs) (string, error) {
-	paths := []string{p.ConfigPath}
-	if p.ConfigPath == "" {
-		paths = nil
-		if configDir, _ := os.UserConfigDir(); configDir != "" {
-			paths = append(paths, filepath.Join(configDir, "rpk", "rpk.yaml"))
+// marshal marshals the config to YAML, or TOML if that is the format of the
+// config file. If the config was loaded from a YAML file, the comments and key
+// ordering of that file are preserved.
+//
+// The comments set with SetComment are written to YAML files only.
+func (c *Config) marshal(fs afero.Fs) ([]byte, error) {
+	if c.format == FormatTOML {
+		if c.included == nil {
+			return Render(c, c.format)
+		}
+		b, err := Render(c, FormatYAML)
+		if err != nil {
+			return nil, err
 		}
-		paths = append(paths, filepath.FromSlash("/etc/redpanda/redpanda.yaml"))
-		if cd, _ := os.Getwd(); cd != "" {
-			paths = append(paths, filepath.Join(cd, "redpanda.yaml"))
+		var orig []byte
+		if c.loadedPath != "" {
+			if orig, err = afero.ReadFile(fs, c.loadedPath); err == nil {
+				orig, _ = tomlToYAML(orig)
+			}
+		}
+		if b, err = withoutIncluded(b, c.included, orig); err != nil {
+			return nil, err
 		}
-		if home, _ := os.UserHomeDir(); home != "" {
-			paths = append(paths, filepath.Join(home, "redpanda.yaml"))
+		return yamlToTOML(b)
+	}
+	var orig []byte
+	if c.loadedPath != "" {
+		orig, _ = afero.ReadFile(fs, c.loadedPath)
+	}
+	var (
+		b   []byte
+		err error
+	)
+	if orig != nil {
+		b, err = marshalPreserving(c, orig)
+	} else {
+		b, err = Render(c, c.format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.included != nil {
+		if b, err = withoutIncluded(b, c.included, orig); err != nil {
+			return nil, err
 		}
 	}
+	return annotate(b, c.comments)
+}
+
+// WriteWithBackup is Write, but it first copies the current config file to
+// the same path with the given suffix appended, ".bak" if the suffix is empty.
+// If there is no config file yet, there is nothing to back up.
+func (c *Config) WriteWithBackup(fs afero.Fs, suffix string) error {
+	if suffix == "" {
+		suffix = ".bak"
+	}
+	cfgPath := c.FileLocation()
+	stat, err := fs.Stat(cfgPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("unable to stat existing config file: %v", err)
+	default:
+		b, err := afero.ReadFile(fs, cfgPath)
+		if err != nil {
+			return fmt.Errorf("unable to read existing config file: %v", err)
+		}
+		logger.Debugf("Backing up config file %s to %s", cfgPath, cfgPath+suffix)
+		if err := writeFileSync(fs, cfgPath+suffix, b, stat.Mode()); err != nil {
+			err = fmt.Errorf("unable to back up existing config file: %w", err)
+			if errors.Is(err, os.ErrPermission) {
+				err = withKind(ErrWritePermission, err)
+			}
+			return err
+		}
+	}
+	return c.Write(fs)
+}
+
+// LoadedPath returns the path of the file the config was loaded from, or an
+// empty string if no config file was found and the config holds defaults.
+func (c *Config) LoadedPath() string {
+	return c.loadedPath
+}
+
+// FileHash returns the hex encoded SHA-256 of the content of the file the
+// config was loaded from, or of empty content if no config file was found.
+// The file is read again, so that the hash is the one of the file as it is
+// now, e.g. to check that it did not change since the config was loaded.
+func (c *Config) FileHash(fs afero.Fs) (string, error) {
+	var b []byte
+	if c.loadedPath != "" {
+		var err error
+		if b, err = afero.ReadFile(fs, c.loadedPath); err != nil {
+			return "", fmt.Errorf("unable to read %q: %w", c.loadedPath, err)
+		}
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CheckFileHash returns an ErrHashMismatch if the FileHash of the config is
+// not hash, which is compared case insensitively.
+func (c *Config) CheckFileHash(fs afero.Fs, hash string) error {
+	current, err := c.FileHash(fs)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(current, hash) {
+		return withKind(ErrHashMismatch, fmt.Errorf("the hash of %q is %s, not %s: it changed since it was read", c.FileLocation(), current, hash))
+	}
+	return nil
+}
+
+// FileLocation returns the path the config is written to: the file it was
+// loaded from, if any, or the configured config file otherwise.
+func (c *Config) FileLocation() string {
+	if c.loadedPath != "" {
+		return c.loadedPath
+	}
+	return c.ConfigFile
+}
+
+// writeFileSync is afero.WriteFile, but it also syncs the file to disk before
+// closing it.
+func writeFileSync(fs afero.Fs, name string, b []byte, perm os.FileMode) error {
+	f, err := fs.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// configPath returns the --config path or, if unset, the REDPANDA_CONFIG path.
+func (p *Params) configPath() string {
+	if p.ConfigPath != "" {
+		return p.ConfigPath
+	}
+	path, _ := lookupEnv(EnvConfig)
+	return path
+}
+
+// SearchPaths returns the paths where the config file is searched for if
+// neither --config nor REDPANDA_CONFIG is set, in order:
+//
+//   1. $XDG_CONFIG_HOME/rpk/rpk.yaml (the user config directory)
+//   2. /etc/redpanda/redpanda.yaml
+//   3. $PWD/redpanda.yaml
+//   4. $HOME/redpanda.yaml
+//
+// The first existing file is loaded.
+func SearchPaths() []string {
+	var paths []string
+	if configDir, _ := os.UserConfigDir(); configDir != "" {
+		paths = append(paths, filepath.Join(configDir, "rpk", "rpk.yaml"))
+	}
+	paths = append(paths, filepath.FromSlash("/etc/redpanda/redpanda.yaml"))
+	if cd, _ := os.Getwd(); cd != "" {
+		paths = append(paths, filepath.Join(cd, "redpanda.yaml"))
+	}
+	if home, _ := os.UserHomeDir(); home != "" {
+		paths = append(paths, filepath.Join(home, "redpanda.yaml"))
+	}
+	return paths
+}
+
+// LocateConfig returns the path of the config file to load: the --config
+// path, the REDPANDA_CONFIG path, or the first of the SearchPaths that exists.
+func (p *Params) LocateConfig(fs afero.Fs) (string, error) {
+	paths := []string{p.configPath()}
+	if paths[0] == "" {
+		paths = SearchPaths()
+	}
 
 	for _, path := range paths {
 		// Ignore error: we only care whether it exists, other
 		// stat() errors are not interesting.
 		exists, _ := afero.Exists(fs, path)
 		if exists {
+			logger.Debugf("Resolved config file %s", path)
 			return path, nil
 		}
 	}
 
-	return "", fmt.Errorf("%w: unable to find config in searched paths %v", afero.ErrFileNotFound, paths)
+	return "", withKind(ErrConfigNotFound, fmt.Errorf("%w: unable to find config in searched paths %v", afero.ErrFileNotFound, paths))
+}
+
+// WaitForConfig is LocateConfig, but if no config file exists yet it polls for
+// one up to timeout, e.g. for a config file that another process is about to
+// write. If there is still none after the timeout, the returned error is an
+// ErrConfigNotFound.
+func (p *Params) WaitForConfig(fs afero.Fs, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		path, err := p.LocateConfig(fs)
+		if err == nil || !errors.Is(err, ErrConfigNotFound) {
+			return path, err
+		}
+		if time.Now().After(deadline) {
+			return "", withKind(ErrConfigNotFound, fmt.Errorf("%w: still no config file after waiting %v", afero.ErrFileNotFound, timeout))
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
 }
 
 func (p *Params) readConfig(fs afero.Fs, c *Config) error {
@@ -388,20 +970,40 @@ func (p *Params) readConfig(fs afero.Fs, c *Config) error {
 	if err != nil {
 		return err
 	}
-
 	file, err := afero.ReadFile(fs, path)
 	if err != nil {
 		return err
 	}
-
-	if err := yaml.Unmarshal(file, c); err != nil {
-		return fmt.Errorf("unable to yaml decode %s: %v", path, err)
+	format, err := fileFormat(p.ConfigFormat, path)
+	if err != nil {
+		return err
+	}
+	logger.Debugf("Loading config file %s as %s", path, format)
+	if format == FormatTOML {
+		if file, err = tomlToYAML(file); err != nil {
+			return fmt.Errorf("unable to decode %s: %w", path, err)
+		}
 	}
-	yaml.Unmarshal(file, &c.file) // cannot error since previous did not
 	abs, err := filepath.Abs(path)
 	if err != nil {
 		return err
 	}
+	doc, included, err := resolveIncludes(fs, abs, file, []string{abs})
+	if err != nil {
+		return err
+	}
+	if included != nil {
+		if file, err = yaml.Marshal(doc); err != nil {
+			return err
+		}
+	}
+	read, err := readFromBytes(file, FormatYAML, path)
+	if err != nil {
+		return err
+	}
+	*c = *read
+	c.format = format
+	c.included = included
 	c.loadedPath = abs
 	c.ConfigFile = abs
 	return nil
@@ -580,9 +1182,17 @@ func (c *Config) addUnsetDefaults() {
 // Set allow to set a single configuration property by passing a key value pair
 //
 //   Key:    string containing the yaml property tag, e.g: 'rpk.admin_api'.
+//           Numeric properties index into lists, e.g:
+//           'redpanda.seed_servers.1.host'. The index can be one past the
+//           end of the list to append a new element.
 //   Value:  string representation of the value, either single value or partial
 //           representation.
-//   Format: either json or yaml (default: yaml).
+//   Format: either json or yaml (default: yaml). The deprecated single
+//           format is yaml, except for lists of strings, which it sets from
+//           a comma separated value, e.g. 'a,b,c', see SetList.
+//
+// If the key does not exist, the returned error is an ErrKeyNotFound. If the
+// value cannot be decoded, it is an ErrInvalidFormat.
 func (c *Config) Set(key, value, format string) error {
 	if key == "" {
 		return fmt.Errorf("key field must not be empty")
@@ -599,36 +1209,638 @@ func (c *Config) Set(key, value, format string) error {
 		field = other
 	}
 
+	// A yaml flow sequence is still decoded as such.
+	if strings.ToLower(format) == "single" && !isOther && field.Type() == stringSliceType &&
+		!strings.HasPrefix(strings.TrimSpace(value), "[") {
+		field.Set(reflect.ValueOf(splitList(value, ",")))
+		return nil
+	}
+
 	if field.CanAddr() {
-		i := field.Addr().Interface()
 		in := value
-		switch strings.ToLower(format) {
-		// single is deprecated, leaving it here for backward compatibility.
-		case "yaml", "single", "":
-			if isOther {
-				p := props[len(props)-1]
+		if isOther {
+			p := props[len(props)-1]
+			if strings.ToLower(format) == "json" {
+				in = fmt.Sprintf("{%q: %s}", p, value)
+			} else {
 				in = fmt.Sprintf("%s: %s", p, value)
 			}
-			err = yaml.Unmarshal([]byte(in), i)
-			if err != nil {
-				return err
+		}
+		return unmarshalValue(in, format, field.Addr().Interface())
+	}
+	return errors.New("rpk bug, please describe how you encountered this at https://github.com/redpanda-data/redpanda/issues/new?assignees=&labels=kind%2Fbug&template=01_bug_report.md")
+}
+
+var stringSliceType = reflect.TypeOf([]string(nil))
+
+// SetList sets the list of strings at key to the elements of value separated
+// by sep, e.g. to set the rpk brokers from "10.0.0.1:9092,10.0.0.2:9092".
+// Spaces around the elements are trimmed, and an empty value sets an empty
+// list. If the key does not exist, the returned error is an ErrKeyNotFound;
+// if it is not a list of strings, it is an ErrInvalidFormat.
+func (c *Config) SetList(key, value, sep string) error {
+	if key == "" {
+		return fmt.Errorf("key field must not be empty")
+	}
+	if sep == "" {
+		return withKind(ErrInvalidFormat, errors.New("the list separator must not be empty"))
+	}
+	props := strings.Split(key, ".")
+	field, other, err := getField(props, reflect.ValueOf(c).Elem())
+	if err != nil {
+		return err
+	}
+	if (other != reflect.Value{}) {
+		return withKind(ErrInvalidFormat, fmt.Errorf("%q is not a key rpk manages, it cannot be set as a list of strings", key))
+	}
+	if field.Type() != stringSliceType {
+		return withKind(ErrInvalidFormat, fmt.Errorf("%q is of type %v, it cannot be set as a list of strings", key, field.Type()))
+	}
+	field.Set(reflect.ValueOf(splitList(value, sep)))
+	return nil
+}
+
+// splitList splits the value around sep, trimming the elements.
+func splitList(value, sep string) []string {
+	elems := []string{}
+	if strings.TrimSpace(value) == "" {
+		return elems
+	}
+	for _, e := range strings.Split(value, sep) {
+		elems = append(elems, strings.TrimSpace(e))
+	}
+	return elems
+}
+
+// The value types that SetTyped accepts.
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeBool   = "bool"
+	TypeFloat  = "float"
+)
+
+// SetTyped is Set for a scalar value, but rather than decoding the value as
+// YAML, which guesses its type from how it reads, it parses it as the given
+// type: string, int, bool or float. This matters for the unmanaged properties,
+// which have no Go type to guide the decoding, e.g. to set the string "100".
+//
+// If the value cannot be parsed as typ, or if the type of the field at key is
+// not typ, the returned error is an ErrInvalidFormat.
+func (c *Config) SetTyped(key, value, typ string) error {
+	if key == "" {
+		return fmt.Errorf("key field must not be empty")
+	}
+	var (
+		v    interface{}
+		kind reflect.Kind
+		err  error
+	)
+	switch strings.ToLower(typ) {
+	case TypeString:
+		v, kind = value, reflect.String
+	case TypeInt:
+		var i int64
+		i, err = strconv.ParseInt(value, 10, 64)
+		v, kind = int(i), reflect.Int
+	case TypeBool:
+		v, err = strconv.ParseBool(value)
+		kind = reflect.Bool
+	case TypeFloat:
+		v, err = strconv.ParseFloat(value, 64)
+		kind = reflect.Float64
+	default:
+		return withKind(ErrInvalidFormat, fmt.Errorf("unsupported type %q, must be %s, %s, %s or %s", typ, TypeString, TypeInt, TypeBool, TypeFloat))
+	}
+	if err != nil {
+		return withKind(ErrInvalidFormat, fmt.Errorf("unable to parse %q as %s: %v", value, typ, err))
+	}
+
+	props := strings.Split(key, ".")
+	field, other, err := getField(props, reflect.ValueOf(c).Elem())
+	if err != nil {
+		return err
+	}
+	if (other != reflect.Value{}) {
+		if other.IsNil() {
+			other.Set(reflect.MakeMap(other.Type()))
+		}
+		other.SetMapIndex(reflect.ValueOf(props[len(props)-1]), reflect.ValueOf(v))
+		return nil
+	}
+
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		field = field.Elem()
+	}
+	rv := reflect.ValueOf(v)
+	switch fk := field.Kind(); {
+	case fk == reflect.Interface:
+		field.Set(rv)
+	case kind == reflect.Int && fk >= reflect.Int && fk <= reflect.Int64:
+		if field.OverflowInt(rv.Int()) {
+			return withKind(ErrInvalidFormat, fmt.Errorf("%s overflows %q, of type %v", value, key, field.Type()))
+		}
+		field.SetInt(rv.Int())
+	case fk == kind, kind == reflect.Float64 && fk == reflect.Float32:
+		field.Set(rv.Convert(field.Type()))
+	default:
+		return withKind(ErrInvalidFormat, fmt.Errorf("%q is of type %v, it cannot be set to a %s", key, field.Type(), typ))
+	}
+	return nil
+}
+
+// Append appends a single value to the list at key, decoding the value as
+// in Set, e.g. to add a seed server:
+//
+//   c.Append("redpanda.seed_servers", "{host: {address: 10.0.0.1, port: 33145}}", "yaml")
+//
+// It fails if key is not a list.
+func (c *Config) Append(key, value, format string) error {
+	return c.appendValue(key, value, format, false)
+}
+
+// AppendUnique is Append, unless the list already holds an element deeply
+// equal to the value, in which case the list is left unchanged. It is the
+// idempotent Append, e.g. to add a seed server on every provisioning run.
+func (c *Config) AppendUnique(key, value, format string) error {
+	return c.appendValue(key, value, format, true)
+}
+
+func (c *Config) appendValue(key, value, format string, unique bool) error {
+	if key == "" {
+		return fmt.Errorf("key field must not be empty")
+	}
+	props := strings.Split(key, ".")
+	field, other, err := getField(props, reflect.ValueOf(c).Elem())
+	if err != nil {
+		return err
+	}
+
+	// Unmanaged lists are decoded into []interface{} within Other.
+	if (other != reflect.Value{}) {
+		k := reflect.ValueOf(props[len(props)-1])
+		list := other.MapIndex(k)
+		if !list.IsValid() || list.IsNil() || list.Elem().Kind() != reflect.Slice {
+			return fmt.Errorf("%q is not a list", key)
+		}
+		var v interface{}
+		if err := unmarshalValue(value, format, &v); err != nil {
+			return err
+		}
+		if unique && containsValue(list.Elem(), v) {
+			return nil
+		}
+		other.SetMapIndex(k, reflect.Append(list.Elem(), reflect.ValueOf(&v).Elem()))
+		return nil
+	}
+
+	if field.Kind() != reflect.Slice {
+		return fmt.Errorf("%q is not a list", key)
+	}
+	v := reflect.New(field.Type().Elem())
+	if err := unmarshalValue(value, format, v.Interface()); err != nil {
+		return err
+	}
+	if unique && containsValue(field, v.Elem().Interface()) {
+		return nil
+	}
+	field.Set(reflect.Append(field, v.Elem()))
+	return nil
+}
+
+// containsValue returns whether the list holds an element deeply equal to v.
+func containsValue(list reflect.Value, v interface{}) bool {
+	for i := 0; i < list.Len(); i++ {
+		if reflect.DeepEqual(list.Index(i).Interface(), v) {
+			return true
+		}
+	}
+	return false
+}
+
+// unmarshalValue decodes in, in the given Set format, into v.
+func unmarshalValue(in, format string, v interface{}) error {
+	var err error
+	switch strings.ToLower(format) {
+	// single is deprecated, leaving it here for backward compatibility.
+	case "yaml", "single", "":
+		err = yaml.Unmarshal([]byte(in), v)
+	case "json":
+		err = json.Unmarshal([]byte(in), v)
+	default:
+		return withKind(ErrInvalidFormat, fmt.Errorf("unsupported format %s", format))
+	}
+	if err != nil {
+		return withKind(ErrInvalidFormat, err)
+	}
+	return nil
+}
+
+// Get returns a single configuration property, the counterpart of Set.
+//
+//   Key:    string containing the yaml property tag, e.g: 'rpk.admin_api'.
+//           Numeric properties index into lists, e.g:
+//           'redpanda.seed_servers.0.host'.
+//   Format: either single, json or yaml (default: yaml). Single returns
+//           scalar values as is and falls back to yaml for objects.
+//
+// The returned value has no trailing newline. If the key does not exist, the
+// returned error is an ErrKeyNotFound.
+func (c *Config) Get(key, format string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("key field must not be empty")
+	}
+	v, err := lookupField(strings.Split(key, "."), reflect.ValueOf(c).Elem())
+	if err != nil {
+		return "", err
+	}
+	i := v.Interface()
+
+	switch strings.ToLower(format) {
+	case "single":
+		switch v.Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice:
+		default:
+			return fmt.Sprint(i), nil
+		}
+		fallthrough
+	case "yaml", "":
+		b, err := yaml.Marshal(i)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(b), "\n"), nil
+	case "json":
+		b, err := json.Marshal(i)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		return "", withKind(ErrInvalidFormat, fmt.Errorf("unsupported format %s", format))
+	}
+}
+
+// GetRaw is like Get, but returns scalar values bare whatever the format, as
+// the single format does: strings are never quoted, e.g. for scripts to use
+// the value as is. Objects and lists are rendered according to format. The
+// values of unmanaged keys are told apart the same way, by what they hold,
+// and a null value, which has no bare form, is an error.
+func (c *Config) GetRaw(key, format string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("key field must not be empty")
+	}
+	v, err := lookupField(strings.Split(key, "."), reflect.ValueOf(c).Elem())
+	if err != nil {
+		return "", err
+	}
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return c.Get(key, format)
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v.Interface()), nil
+	case reflect.Invalid, reflect.Ptr, reflect.Interface:
+		return "", fmt.Errorf("%q is null, which has no raw value", key)
+	default:
+		return "", fmt.Errorf("%q is a %s, which has no raw value", key, v.Type())
+	}
+}
+
+// Glob returns the keys of the configuration that match pattern, sorted. A
+// "*" property matches any field, unmanaged property, map key or list index
+// at its level, and the other properties that apply to a list match each of
+// its elements, e.g. redpanda.*.port matches redpanda.rpc_server.port and
+// redpanda.kafka_api.0.port. Lists are always indexed in the returned keys,
+// and nil values, as well as the empty values that are omitted when writing
+// the config, are not matched.
+func (c *Config) Glob(pattern string) ([]string, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("key field must not be empty")
+	}
+	keys := globKeys(strings.Split(pattern, "."), reflect.ValueOf(c).Elem(), nil)
+	if len(keys) == 0 {
+		return nil, withKind(ErrKeyNotFound, fmt.Errorf("no key matches %q", pattern))
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func globKeys(props []string, p reflect.Value, path []string) []string {
+	for p.Kind() == reflect.Ptr || p.Kind() == reflect.Interface {
+		if p.IsNil() {
+			return nil
+		}
+		p = p.Elem()
+	}
+	if len(props) == 0 {
+		return []string{strings.Join(path, ".")}
+	}
+	prop := props[0]
+	// sub returns the path with one more property, without sharing the
+	// backing array with the paths of the sibling matches.
+	sub := func(k string) []string {
+		return append(path[:len(path):len(path)], k)
+	}
+
+	var keys []string
+	switch p.Kind() {
+	case reflect.Slice:
+		if idx, err := strconv.Atoi(prop); err == nil {
+			if idx < 0 || idx >= p.Len() {
+				return nil
+			}
+			return globKeys(props[1:], p.Index(idx), sub(prop))
+		}
+		rest := props
+		if prop == "*" {
+			rest = props[1:]
+		}
+		for i := 0; i < p.Len(); i++ {
+			keys = append(keys, globKeys(rest, p.Index(i), sub(strconv.Itoa(i)))...)
+		}
+
+	case reflect.Map:
+		if prop != "*" {
+			if v := p.MapIndex(reflect.ValueOf(prop)); v.IsValid() {
+				return globKeys(props[1:], v, sub(prop))
 			}
 			return nil
-		case "json":
-			if isOther {
-				p := props[len(props)-1]
-				in = fmt.Sprintf("{%q: %s}", p, value)
+		}
+		iter := p.MapRange()
+		for iter.Next() {
+			keys = append(keys, globKeys(props[1:], iter.Value(), sub(fmt.Sprint(iter.Key().Interface())))...)
+		}
+
+	case reflect.Struct:
+		t := p.Type()
+		for i := 0; i < p.NumField(); i++ {
+			tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")
+			ft := tag[0]
+			if ft == "" || ft == "-" || !t.Field(i).IsExported() {
+				continue
 			}
-			err = json.Unmarshal([]byte(in), i)
-			if err != nil {
-				return err
+			// Like nil values, the omitted empty values are not in
+			// the written config.
+			omitted := len(tag) > 1 && tag[1] == "omitempty" && p.Field(i).IsZero()
+			if (prop == "*" || ft == prop) && !omitted {
+				keys = append(keys, globKeys(props[1:], p.Field(i), sub(ft))...)
+			}
+		}
+		if len(keys) > 0 && prop != "*" {
+			return keys
+		}
+		if other := p.FieldByName("Other"); other.IsValid() {
+			keys = append(keys, globKeys(props, other, path)...)
+		}
+	}
+	return keys
+}
+
+// IsPinned reports whether the config file c was loaded from explicitly sets
+// key, to a value other than its default. Keys that are absent from the file,
+// and thus defaulted, are not pinned, and neither is any key if c was not
+// loaded from a file.
+func (c *Config) IsPinned(key string) bool {
+	if c.fileNode == nil || len(c.fileNode.Content) == 0 {
+		return false
+	}
+	n := c.fileNode.Content[0]
+	for _, prop := range strings.Split(key, ".") {
+		// As in Set, a non numeric property of a list refers to its
+		// first element.
+		if n.Kind == yaml.SequenceNode {
+			if idx, err := strconv.Atoi(prop); err == nil {
+				if idx < 0 || idx >= len(n.Content) {
+					return false
+				}
+				n = n.Content[idx]
+				continue
+			}
+			if len(n.Content) == 0 {
+				return false
+			}
+			n = n.Content[0]
+		}
+		if n = mappingValue(n, prop); n == nil {
+			return false
+		}
+	}
+
+	// The unmanaged properties have no default.
+	def, err := lookupField(strings.Split(key, "."), reflect.ValueOf(Default()).Elem())
+	if err != nil {
+		return true
+	}
+	v := reflect.New(def.Type())
+	if err := n.Decode(v.Interface()); err != nil {
+		return true
+	}
+	return !reflect.DeepEqual(v.Elem().Interface(), def.Interface())
+}
+
+// lookupField walks p following props, matching struct fields by their yaml
+// tag. Unlike getField, it never allocates: nil pointers resolve to their
+// zero value and unknown properties are an error.
+func lookupField(props []string, p reflect.Value) (reflect.Value, error) {
+	for p.Kind() == reflect.Ptr || p.Kind() == reflect.Interface {
+		if p.IsNil() {
+			if p.Kind() == reflect.Interface {
+				if len(props) > 0 {
+					return reflect.Value{}, fmt.Errorf("%w: unable to find field %q", ErrKeyNotFound, props[0])
+				}
+				return p, nil
+			}
+			p = reflect.Zero(p.Type().Elem())
+			continue
+		}
+		p = p.Elem()
+	}
+	if len(props) == 0 {
+		return p, nil
+	}
+	prop := props[0]
+
+	switch p.Kind() {
+	case reflect.Slice:
+		// Numeric properties index into the slice, anything else refers
+		// to the first element, as it does in getField.
+		if idx, err := strconv.Atoi(prop); err == nil {
+			if idx < 0 || idx >= p.Len() {
+				return reflect.Value{}, fmt.Errorf("%w: index %d out of range, found %d elements", ErrKeyNotFound, idx, p.Len())
+			}
+			return lookupField(props[1:], p.Index(idx))
+		}
+		if p.Len() == 0 {
+			return reflect.Value{}, fmt.Errorf("%w: unable to find field %q", ErrKeyNotFound, prop)
+		}
+		return lookupField(props, p.Index(0))
+
+	case reflect.Map:
+		v := p.MapIndex(reflect.ValueOf(prop))
+		if !v.IsValid() {
+			return reflect.Value{}, fmt.Errorf("%w: unable to find field %q", ErrKeyNotFound, prop)
+		}
+		return lookupField(props[1:], v)
+
+	case reflect.Struct:
+		t := p.Type()
+		for i := 0; i < p.NumField(); i++ {
+			ft := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+			if ft == prop && t.Field(i).IsExported() {
+				return lookupField(props[1:], p.Field(i))
 			}
+		}
+		if other := p.FieldByName("Other"); other.IsValid() {
+			return lookupField(props, other)
+		}
+		return reflect.Value{}, fmt.Errorf("%w: unable to find field %q", ErrKeyNotFound, prop)
+	}
+	return reflect.Value{}, fmt.Errorf("%w: unable to get field %q of type %v", ErrKeyNotFound, prop, p.Type())
+}
+
+// Unset removes a single configuration property, the counterpart of Set.
+//
+// Properties managed by rpk are reset to their default value, as in Default,
+// or to their zero value if they have none, e.g. the fields of a list element
+// that the default list does not have. Unmanaged properties are deleted from
+// their map, and slice elements (e.g. 'redpanda.seed_servers.1') are removed,
+// compacting the slice. Unsetting a property that does not exist is a no-op.
+func (c *Config) Unset(key string) error {
+	if key == "" {
+		return fmt.Errorf("key field must not be empty")
+	}
+	unsetField(strings.Split(key, "."), reflect.ValueOf(c).Elem(), reflect.ValueOf(Default()).Elem())
+	return nil
+}
+
+// PruneEmpty removes the value at key if it is an empty map, and then each of
+// its parent maps that is left empty, e.g. after unsetting the last key of a
+// nested map. Only the maps of the keys that rpk does not manage are removed:
+// the walk stops at the first managed key, as modeled fields are never
+// removed, or at the first parent that is not empty.
+func (c *Config) PruneEmpty(key string) error {
+	if key == "" {
+		return fmt.Errorf("key field must not be empty")
+	}
+	props := strings.Split(key, ".")
+	for n := len(props); n > 0; n-- {
+		k := strings.Join(props[:n], ".")
+		if IsManaged(k) {
 			return nil
-		default:
-			return fmt.Errorf("unsupported format %s", format)
 		}
+		v, err := lookupField(props[:n], reflect.ValueOf(c).Elem())
+		if errors.Is(err, ErrKeyNotFound) {
+			// The key itself was removed, e.g. by Unset.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if v.Kind() != reflect.Map || v.Len() > 0 {
+			return nil
+		}
+		unsetField(props[:n], reflect.ValueOf(c).Elem(), reflect.Value{})
+	}
+	return nil
+}
+
+// unsetField deeply searches in p for the value that reflect property props
+// and removes it; p must be settable. def is the default value of p, which a
+// removed struct field is reset to, or the zero Value if p has no default.
+func unsetField(props []string, p, def reflect.Value) {
+	switch p.Kind() {
+	case reflect.Ptr:
+		if !p.IsNil() {
+			if def.IsValid() && !def.IsNil() {
+				def = def.Elem()
+			} else {
+				def = reflect.Value{}
+			}
+			unsetField(props, p.Elem(), def)
+		}
+
+	case reflect.Interface:
+		// Values inside interfaces are not addressable, we modify a copy
+		// and store it back.
+		if p.IsNil() {
+			return
+		}
+		cp := reflect.New(p.Elem().Type()).Elem()
+		cp.Set(p.Elem())
+		unsetField(props, cp, reflect.Value{})
+		p.Set(cp)
+
+	case reflect.Struct:
+		field, other, err := getFieldByTag(props[0], p)
+		if err != nil {
+			return
+		}
+		if (other != reflect.Value{}) {
+			unsetField(props, other, reflect.Value{})
+			return
+		}
+		var defField reflect.Value
+		if def.IsValid() {
+			defField, _, _ = getFieldByTag(props[0], def)
+		}
+		if len(props) == 1 {
+			if defField.IsValid() {
+				field.Set(defField)
+			} else {
+				field.Set(reflect.Zero(field.Type()))
+			}
+			return
+		}
+		unsetField(props[1:], field, defField)
+
+	case reflect.Map:
+		if p.Type().Key().Kind() != reflect.String {
+			return
+		}
+		k := reflect.ValueOf(props[0]).Convert(p.Type().Key())
+		v := p.MapIndex(k)
+		if !v.IsValid() {
+			return
+		}
+		if len(props) == 1 {
+			p.SetMapIndex(k, reflect.Value{})
+			return
+		}
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(v)
+		unsetField(props[1:], cp, reflect.Value{})
+		p.SetMapIndex(k, cp)
+
+	case reflect.Slice:
+		idx, err := strconv.Atoi(props[0])
+		if err != nil {
+			// Same as getField, a non-index property refers to the
+			// first element.
+			idx = 0
+			props = append([]string{"0"}, props...)
+		}
+		if idx < 0 || idx >= p.Len() {
+			return
+		}
+		if len(props) == 1 {
+			p.Set(reflect.AppendSlice(p.Slice(0, idx), p.Slice(idx+1, p.Len())))
+			return
+		}
+		var defElem reflect.Value
+		if def.IsValid() && idx < def.Len() {
+			defElem = def.Index(idx)
+		}
+		unsetField(props[1:], p.Index(idx), defElem)
 	}
-	return errors.New("rpk bug, please describe how you encountered this at https://github.com/redpanda-data/redpanda/issues/new?assignees=&labels=kind%2Fbug&template=01_bug_report.md")
 }
 
 // getField deeply search in p for the value that reflect property props.
@@ -637,6 +1849,15 @@ func getField(props []string, p reflect.Value) (reflect.Value, reflect.Value, er
 		return p, reflect.Value{}, nil
 	}
 	if p.Kind() == reflect.Slice {
+		if idx, err := strconv.Atoi(props[0]); err == nil {
+			if idx < 0 || idx > p.Len() {
+				return reflect.Value{}, reflect.Value{}, withKind(ErrKeyNotFound, fmt.Errorf("index %d out of range, found %d elements: only existing elements can be set, or index %d to append one", idx, p.Len(), p.Len()))
+			}
+			if idx == p.Len() {
+				p.Set(reflect.Append(p, reflect.Indirect(reflect.New(p.Type().Elem()))))
+			}
+			return getField(props[1:], p.Index(idx))
+		}
 		if p.Len() == 0 {
 			p.Set(reflect.Append(p, reflect.Indirect(reflect.New(p.Type().Elem()))))
 		}
@@ -703,5 +1924,5 @@ func getFieldByTag(tag string, p reflect.Value) (reflect.Value, reflect.Value, e
 		return reflect.Value{}, p.FieldByName("Other"), nil
 	}
 
-	return reflect.Value{}, reflect.Value{}, fmt.Errorf("unable to find field %q", tag)
+	return reflect.Value{}, reflect.Value{}, withKind(ErrKeyNotFound, fmt.Errorf("unable to find field %q", tag))
 }
@@ -1,10 +1,17 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
 )
 
 func TestParams_Write(t *testing.T) {
@@ -130,3 +137,339 @@ rpk:
 		})
 	}
 }
+
+// failingFs fails every write to files other than the config file after
+// writing half of the contents, and optionally fails every rename.
+type failingFs struct {
+	afero.Fs
+	failRename bool
+}
+
+type halfWriteFile struct{ afero.File }
+
+func (f halfWriteFile) Write(b []byte) (int, error) {
+	n, _ := f.File.Write(b[:len(b)/2])
+	return n, errors.New("no space left on device")
+}
+
+func (fs failingFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	f, err := fs.Fs.OpenFile(name, flag, perm)
+	if err != nil || name == "/etc/redpanda/redpanda.yaml" || fs.failRename {
+		return f, err
+	}
+	return halfWriteFile{f}, nil
+}
+
+func (fs failingFs) Rename(string, string) error {
+	if fs.failRename {
+		return errors.New("rename not supported")
+	}
+	return nil
+}
+
+func TestWriteAtomic(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	orig := `redpanda:
+    node_id: 1
+`
+	for _, test := range []struct {
+		name       string
+		failRename bool
+		expErr     bool
+		exp        string
+	}{
+		{
+			name:   "a failed write leaves the original file intact",
+			expErr: true,
+			exp:    "node_id: 1",
+		},
+		{
+			name:       "a failed rename falls back to writing in place",
+			failRename: true,
+			exp:        "node_id: 2",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			mem := afero.NewMemMapFs()
+			if err := afero.WriteFile(mem, path, []byte(orig), 0o600); err != nil {
+				t.Fatalf("unable to write initial config: %v", err)
+			}
+			fs := failingFs{mem, test.failRename}
+
+			cfg, err := new(Params).Load(fs)
+			if err != nil {
+				t.Fatalf("unable to load config: %v", err)
+			}
+			cfg.Redpanda.ID = 2
+
+			err = cfg.Write(fs)
+			if gotErr := err != nil; gotErr != test.expErr {
+				t.Fatalf("got err? %v, exp err? %v; error: %v", gotErr, test.expErr, err)
+			}
+
+			b, err := afero.ReadFile(mem, path)
+			if err != nil {
+				t.Fatalf("unable to read config: %v", err)
+			}
+			if !strings.Contains(string(b), test.exp) {
+				t.Errorf("got config:\n%s\nexp it to contain %q", b, test.exp)
+			}
+			stat, err := mem.Stat(path)
+			if err != nil {
+				t.Fatalf("unable to stat config: %v", err)
+			}
+			if stat.Mode() != 0o600 {
+				t.Errorf("got mode %v, exp %v", stat.Mode(), os.FileMode(0o600))
+			}
+
+			files, err := afero.ReadDir(mem, "/etc/redpanda")
+			if err != nil {
+				t.Fatalf("unable to read config dir: %v", err)
+			}
+			if len(files) != 1 {
+				t.Errorf("got %d files in the config dir, exp only the config file", len(files))
+			}
+		})
+	}
+}
+
+func TestLocateConfig(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+	t.Setenv("XDG_CONFIG_HOME", "/home/user/.config")
+	cd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		xdg  = "/home/user/.config/rpk/rpk.yaml"
+		etc  = "/etc/redpanda/redpanda.yaml"
+		pwd  = filepath.Join(cd, "redpanda.yaml")
+		home = "/home/user/redpanda.yaml"
+		env  = "/opt/redpanda/redpanda.yaml"
+		flag = "/tmp/redpanda.yaml"
+	)
+
+	if got, exp := SearchPaths(), []string{xdg, etc, pwd, home}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got search paths %v, exp %v", got, exp)
+	}
+
+	for _, test := range []struct {
+		name   string
+		files  []string
+		env    string
+		flag   string
+		exp    string
+		expErr bool
+	}{
+		{name: "home", files: []string{home}, exp: home},
+		{name: "pwd over home", files: []string{home, pwd}, exp: pwd},
+		{name: "etc over pwd", files: []string{pwd, etc}, exp: etc},
+		{name: "user config over etc", files: []string{etc, xdg}, exp: xdg},
+		{name: "env over search paths", files: []string{xdg, etc, env}, env: env, exp: env},
+		{name: "missing env file is not searched", files: []string{etc}, env: env, expErr: true},
+		{name: "flag over env", files: []string{env, flag}, env: env, flag: flag, exp: flag},
+		{name: "no config", expErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(EnvConfig, test.env)
+			fs := afero.NewMemMapFs()
+			for _, f := range test.files {
+				if err := afero.WriteFile(fs, f, []byte("redpanda:\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := (&Params{ConfigPath: test.flag}).LocateConfig(fs)
+			if test.expErr {
+				if !errors.Is(err, ErrConfigNotFound) {
+					t.Errorf("got err %v, exp ErrConfigNotFound", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.exp {
+				t.Errorf("got %s, exp %s", got, test.exp)
+			}
+		})
+	}
+}
+
+func TestLoadEnvConfig(t *testing.T) {
+	const path = "/opt/redpanda/redpanda.yaml"
+	t.Setenv(EnvConfig, path)
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte("redpanda:\n  node_id: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// With no file at REDPANDA_CONFIG, it is where the config is written.
+	cfg, err := new(Params).Load(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Redpanda.ID != 0 || cfg.FileLocation() != path {
+		t.Fatalf("got node ID %d at %s, exp the default config at %s", cfg.Redpanda.ID, cfg.FileLocation(), path)
+	}
+	cfg.Redpanda.ID = 2
+	if err := cfg.Write(fs); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = new(Params).Load(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Redpanda.ID != 2 {
+		t.Errorf("got node ID %d, exp 2", cfg.Redpanda.ID)
+	}
+}
+
+func TestRender(t *testing.T) {
+	conf := Default()
+	conf.Redpanda.ID = 2
+	conf.Redpanda.SeedServers = []SeedServer{
+		{Host: SocketAddress{"10.0.0.1", 33145}},
+		{Host: SocketAddress{"10.0.0.2", 33145}},
+	}
+	conf.Redpanda.Other = map[string]interface{}{"enable_idempotence": "true"}
+	conf.Rpk.TuneNetwork = true
+
+	for _, test := range []struct {
+		format string
+		decode func([]byte, *Config) error
+	}{
+		{"", func(b []byte, c *Config) error { return yaml.Unmarshal(b, c) }},
+		{FormatYAML, func(b []byte, c *Config) error { return yaml.Unmarshal(b, c) }},
+		{FormatJSON, func(b []byte, c *Config) error { return json.Unmarshal(b, c) }},
+		{FormatTOML, func(b []byte, c *Config) error {
+			y, err := tomlToYAML(b)
+			if err != nil {
+				return err
+			}
+			return yaml.Unmarshal(y, c)
+		}},
+	} {
+		t.Run(test.format, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			b, err := Render(conf, test.format)
+			if err != nil {
+				t.Fatalf("unable to render: %v", err)
+			}
+			if files, _ := afero.ReadDir(fs, "/"); len(files) != 0 {
+				t.Errorf("got %d files written, exp none", len(files))
+			}
+			var got Config
+			if err := test.decode(b, &got); err != nil {
+				t.Fatalf("unable to decode rendered config:\n%s\nerr: %v", b, err)
+			}
+			if !reflect.DeepEqual(conf, &got) {
+				t.Errorf("got rendered config:\n%s\nwhich decodes to %+v, exp %+v", b, got, *conf)
+			}
+		})
+	}
+
+	if _, err := Render(conf, "xml"); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("got err %v, exp ErrInvalidFormat", err)
+	}
+}
+
+func TestReadFromBytes(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		format string
+		in     string
+		expErr string
+	}{
+		{name: "yaml", format: FormatYAML, in: "redpanda:\n  node_id: 2\n  rack: r1\n"},
+		{name: "default format", in: "redpanda:\n  node_id: 2\n  rack: r1\n"},
+		{name: "json", format: FormatJSON, in: `{"redpanda": {"node_id": 2, "rack": "r1"}}`},
+		{name: "toml", format: FormatTOML, in: "[redpanda]\nnode_id = 2\nrack = \"r1\"\n"},
+		{name: "malformed yaml", format: FormatYAML, in: "redpanda: [", expErr: "unable to yaml decode config"},
+		{name: "malformed json", format: FormatJSON, in: `{"redpanda": {"node_id": }`, expErr: "unable to json decode config"},
+		{name: "unsupported format", format: "xml", in: "<redpanda/>", expErr: "unsupported format"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cfg, err := ReadFromBytes([]byte(test.in), test.format)
+			if test.expErr != "" {
+				if !errors.Is(err, ErrInvalidFormat) || !strings.Contains(fmt.Sprint(err), test.expErr) {
+					t.Fatalf("got err %v, exp an ErrInvalidFormat containing %q", err, test.expErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to read config: %v", err)
+			}
+			if cfg.Redpanda.ID != 2 || cfg.Redpanda.Rack != "r1" {
+				t.Errorf("got node ID %d and rack %q, exp 2 and r1", cfg.Redpanda.ID, cfg.Redpanda.Rack)
+			}
+			// The unset defaults are filled, as Load does.
+			if exp := []string{"127.0.0.1:9644"}; !reflect.DeepEqual(cfg.Rpk.AdminAPI.Addresses, exp) {
+				t.Errorf("got admin addresses %v, exp the default %v", cfg.Rpk.AdminAPI.Addresses, exp)
+			}
+
+			// And the configuration round-trips through WriteToBytes,
+			// in the format Write would write it in.
+			b, err := WriteToBytes(cfg, cfg.format)
+			if err != nil {
+				t.Fatalf("unable to write config: %v", err)
+			}
+			again, err := ReadFromBytes(b, cfg.format)
+			if err != nil {
+				t.Fatalf("unable to read written config:\n%s\nerr: %v", b, err)
+			}
+			if again.Version != 0 {
+				t.Errorf("got version %d once written and read, exp the unversioned config to stay unversioned", again.Version)
+			}
+			if again.Redpanda.ID != 2 || again.Redpanda.Rack != "r1" {
+				t.Errorf("got node ID %d and rack %q once written and read, exp 2 and r1", again.Redpanda.ID, again.Redpanda.Rack)
+			}
+		})
+	}
+}
+
+func TestConfigVersion(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	for _, test := range []struct {
+		name string
+		in   string
+		exp  int
+	}{
+		{name: "unversioned", in: "redpanda:\n    node_id: 1\n"},
+		{name: "versioned", in: "config_version: 1\nredpanda:\n    node_id: 1\n", exp: 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if err := afero.WriteFile(fs, path, []byte(test.in), 0o644); err != nil {
+				t.Fatalf("unable to write initial config: %v", err)
+			}
+			cfg, err := new(Params).Load(fs)
+			if err != nil {
+				t.Fatalf("unable to load config: %v", err)
+			}
+			if cfg.Version != test.exp {
+				t.Errorf("got version %d, exp %d", cfg.Version, test.exp)
+			}
+
+			// Write keeps the version as it was loaded, only migrate
+			// stamps it.
+			cfg.Redpanda.ID = 2
+			if err := cfg.Write(fs); err != nil {
+				t.Fatalf("unable to write config: %v", err)
+			}
+			b, err := afero.ReadFile(fs, path)
+			if err != nil {
+				t.Fatalf("unable to read config: %v", err)
+			}
+			if has := strings.Contains(string(b), "config_version:"); has != (test.exp != 0) {
+				t.Errorf("got config:\n%s\nexp config_version to be written only if the file had it", b)
+			}
+			cfg, err = new(Params).Load(fs)
+			if err != nil {
+				t.Fatalf("unable to load config: %v", err)
+			}
+			if cfg.Version != test.exp {
+				t.Errorf("got version %d once written, exp %d", cfg.Version, test.exp)
+			}
+		})
+	}
+}
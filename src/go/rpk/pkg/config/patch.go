@@ -0,0 +1,401 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ApplyMergePatch applies the RFC 7386 JSON Merge Patch patch to the
+// configuration, whose keys are the config file keys: the members of the
+// patch objects are merged recursively into the configuration objects, the
+// null members delete their key, and any other value, lists included,
+// replaces the value at its key. The patch can be written in YAML as well.
+//
+// The patch is applied as a whole: if the patch or the patched configuration
+// cannot be decoded, the configuration is left untouched and the returned
+// error is an ErrInvalidFormat.
+func (c *Config) ApplyMergePatch(patch []byte) error {
+	var p interface{}
+	if err := yaml.Unmarshal(patch, &p); err != nil {
+		return withKind(ErrInvalidFormat, fmt.Errorf("unable to decode the merge patch: %v", err))
+	}
+	doc, err := c.document()
+	if err != nil {
+		return err
+	}
+	return c.setDocument(mergePatch(doc, p))
+}
+
+// mergePatch returns target patched with patch, per the MergePatch function
+// of RFC 7386. target may be modified.
+func mergePatch(target, patch interface{}) interface{} {
+	pm, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	tm, ok := target.(map[string]interface{})
+	if !ok {
+		tm = make(map[string]interface{})
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(tm, k)
+			continue
+		}
+		tm[k] = mergePatch(tm[k], v)
+	}
+	return tm
+}
+
+// document returns the configuration as the generic YAML document it is
+// written as, for the patches to apply to.
+func (c *Config) document() (interface{}, error) {
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode config: %v", err)
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("unable to decode config: %v", err)
+	}
+	return doc, nil
+}
+
+// setDocument replaces the values of the configuration with the generic YAML
+// document doc, zeroing the keys that doc lacks. The configuration stays tied
+// to the file it was loaded from.
+func (c *Config) setDocument(doc interface{}) error {
+	if _, ok := doc.(map[string]interface{}); !ok && doc != nil {
+		return withKind(ErrInvalidFormat, fmt.Errorf("the patched configuration must be an object, got %T", doc))
+	}
+	b, err := yaml.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("unable to encode the patched config: %v", err)
+	}
+	var patched Config
+	if doc != nil {
+		if err := yaml.Unmarshal(b, &patched); err != nil {
+			return withKind(ErrInvalidFormat, fmt.Errorf("unable to decode the patched config: %v", err))
+		}
+	}
+	dst, src := reflect.ValueOf(c).Elem(), reflect.ValueOf(&patched).Elem()
+	for i := 0; i < dst.NumField(); i++ {
+		if dst.Type().Field(i).PkgPath == "" {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+	return nil
+}
+
+// ApplyJSONPatch applies the RFC 6902 JSON Patch patch to the configuration,
+// whose keys are the config file keys. The patch is a list of operations,
+// applied in order, whose path and from members are either JSON pointers,
+// e.g. /redpanda/seed_servers/0/host/address, or dotted keys as Set takes
+// them, e.g. redpanda.seed_servers.0.host.address. The add, remove, replace,
+// move, copy and test operations are supported; the patch can be written in
+// YAML as well.
+//
+// The patch is applied as a whole: if any operation fails, the configuration
+// is left untouched. The returned error is an ErrPatchTestFailed if a test
+// operation does not hold, an ErrKeyNotFound if an operation refers to a key
+// that does not exist, and an ErrInvalidFormat if the patch or the patched
+// configuration cannot be decoded.
+func (c *Config) ApplyJSONPatch(patch []byte) error {
+	ops, err := parseJSONPatch(patch)
+	if err != nil {
+		return err
+	}
+	doc, err := c.document()
+	if err != nil {
+		return err
+	}
+	for i, op := range ops {
+		if doc, err = op.apply(doc); err != nil {
+			return fmt.Errorf("operation %d (%s %s): %w", i, op.op, op.rawPath, err)
+		}
+	}
+	return c.setDocument(doc)
+}
+
+// jsonPatchOp is an operation of a JSON Patch.
+type jsonPatchOp struct {
+	op      string
+	rawPath string
+	path    []string
+	from    []string
+	value   interface{}
+}
+
+func parseJSONPatch(patch []byte) ([]jsonPatchOp, error) {
+	var raw []map[string]interface{}
+	if err := yaml.Unmarshal(patch, &raw); err != nil {
+		return nil, withKind(ErrInvalidFormat, fmt.Errorf("unable to decode the JSON patch, which must be a list of operations: %v", err))
+	}
+	ops := make([]jsonPatchOp, 0, len(raw))
+	for i, m := range raw {
+		invalid := func(format string, args ...interface{}) error {
+			return withKind(ErrInvalidFormat, fmt.Errorf("operation %d: %s", i, fmt.Sprintf(format, args...)))
+		}
+		op, _ := m["op"].(string)
+		rawPath, ok := m["path"].(string)
+		if !ok {
+			return nil, invalid("missing string path")
+		}
+		o := jsonPatchOp{op: op, rawPath: rawPath, path: patchPath(rawPath)}
+		switch op {
+		case "add", "replace", "test":
+			v, ok := m["value"]
+			if !ok {
+				return nil, invalid("%s requires a value", op)
+			}
+			o.value = v
+		case "remove":
+		case "move", "copy":
+			from, ok := m["from"].(string)
+			if !ok {
+				return nil, invalid("%s requires a string from", op)
+			}
+			o.from = patchPath(from)
+		default:
+			return nil, invalid("unsupported op %q, must be add, remove, replace, move, copy or test", op)
+		}
+		ops = append(ops, o)
+	}
+	return ops, nil
+}
+
+// patchPath returns the keys of the JSON pointer or dotted key path.
+func patchPath(path string) []string {
+	switch {
+	case path == "":
+		return nil
+	case strings.HasPrefix(path, "/"):
+		keys := strings.Split(path[1:], "/")
+		unescape := strings.NewReplacer("~1", "/", "~0", "~")
+		for i, k := range keys {
+			keys[i] = unescape.Replace(k)
+		}
+		return keys
+	default:
+		return strings.Split(path, ".")
+	}
+}
+
+// apply returns doc with the operation applied. doc may be modified.
+func (o jsonPatchOp) apply(doc interface{}) (interface{}, error) {
+	switch o.op {
+	case "add":
+		return patchAdd(doc, o.path, o.value)
+	case "remove":
+		return patchRemove(doc, o.path)
+	case "replace":
+		doc, err := patchRemove(doc, o.path)
+		if err != nil {
+			return nil, err
+		}
+		return patchAdd(doc, o.path, o.value)
+	case "move":
+		v, err := patchGet(doc, o.from)
+		if err != nil {
+			return nil, err
+		}
+		if len(o.path) > len(o.from) && reflect.DeepEqual(o.path[:len(o.from)], o.from) {
+			return nil, errors.New("cannot move a value into itself")
+		}
+		if doc, err = patchRemove(doc, o.from); err != nil {
+			return nil, err
+		}
+		return patchAdd(doc, o.path, v)
+	case "copy":
+		v, err := patchGet(doc, o.from)
+		if err != nil {
+			return nil, err
+		}
+		return patchAdd(doc, o.path, copyPatchValue(v))
+	default: // test
+		v, err := patchGet(doc, o.path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(normalizePatchValue(v), normalizePatchValue(o.value)) {
+			return nil, withKind(ErrPatchTestFailed, fmt.Errorf("the value is %v, not %v", v, o.value))
+		}
+		return doc, nil
+	}
+}
+
+func patchGet(doc interface{}, path []string) (interface{}, error) {
+	for i, k := range path {
+		v, err := patchChild(doc, k)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", strings.Join(path[:i+1], "."), err)
+		}
+		doc = v
+	}
+	return doc, nil
+}
+
+func patchAdd(doc interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	return patchParent(doc, path, func(parent interface{}, k string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[k] = v
+			return p, nil
+		case []interface{}:
+			if k == "-" {
+				return append(p, v), nil
+			}
+			i, err := patchIndex(k, len(p)+1)
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = v
+			return p, nil
+		default:
+			return nil, fmt.Errorf("cannot add %q to a scalar value", k)
+		}
+	})
+}
+
+func patchRemove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	return patchParent(doc, path, func(parent interface{}, k string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := p[k]; !ok {
+				return nil, withKind(ErrKeyNotFound, fmt.Errorf("%q not found", k))
+			}
+			delete(p, k)
+			return p, nil
+		case []interface{}:
+			i, err := patchIndex(k, len(p))
+			if err != nil {
+				return nil, err
+			}
+			return append(p[:i], p[i+1:]...), nil
+		default:
+			return nil, withKind(ErrKeyNotFound, fmt.Errorf("%q not found in a scalar value", k))
+		}
+	})
+}
+
+// patchParent returns doc with the parent of the value at path replaced by
+// what fn returns for it and the last key of path.
+func patchParent(doc interface{}, path []string, fn func(parent interface{}, k string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	child, err := patchChild(doc, path[0])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path[0], err)
+	}
+	if child, err = patchParent(child, path[1:], fn); err != nil {
+		return nil, err
+	}
+	switch p := doc.(type) {
+	case map[string]interface{}:
+		p[path[0]] = child
+	case []interface{}:
+		i, _ := patchIndex(path[0], len(p))
+		p[i] = child
+	}
+	return doc, nil
+}
+
+func patchChild(doc interface{}, k string) (interface{}, error) {
+	switch p := doc.(type) {
+	case map[string]interface{}:
+		v, ok := p[k]
+		if !ok {
+			return nil, withKind(ErrKeyNotFound, errors.New("key not found"))
+		}
+		return v, nil
+	case []interface{}:
+		i, err := patchIndex(k, len(p))
+		if err != nil {
+			return nil, err
+		}
+		return p[i], nil
+	default:
+		return nil, withKind(ErrKeyNotFound, errors.New("not an object nor a list"))
+	}
+}
+
+// patchIndex returns the list index k, which must be below n.
+func patchIndex(k string, n int) (int, error) {
+	i, err := strconv.Atoi(k)
+	if err != nil || i < 0 || strconv.Itoa(i) != k {
+		return 0, withKind(ErrKeyNotFound, fmt.Errorf("%q is not a list index", k))
+	}
+	if i >= n {
+		return 0, withKind(ErrKeyNotFound, fmt.Errorf("index %d out of range", i))
+	}
+	return i, nil
+}
+
+func copyPatchValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = copyPatchValue(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = copyPatchValue(e)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// normalizePatchValue returns v with its numbers as float64s, for the test
+// operations to compare numbers by value, as RFC 6902 requires.
+func normalizePatchValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = normalizePatchValue(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = normalizePatchValue(e)
+		}
+		return s
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		patch  string
+		exp    func(*Config)
+		expErr bool
+	}{
+		{
+			name:  "empty patch",
+			patch: `{}`,
+			exp:   func(*Config) {},
+		},
+		{
+			name:  "member replacement",
+			patch: `{"redpanda": {"node_id": 3, "rack": "rack-1"}, "rpk": {"kafka_api": {"brokers": ["10.0.0.2:9092"]}}}`,
+			exp: func(c *Config) {
+				c.Redpanda.ID = 3
+				c.Redpanda.Rack = "rack-1"
+				c.Rpk.KafkaAPI.Brokers = []string{"10.0.0.2:9092"}
+			},
+		},
+		{
+			name:  "lists are replaced",
+			patch: `{"redpanda": {"seed_servers": [{"host": {"address": "10.0.0.3"}}]}}`,
+			exp: func(c *Config) {
+				c.Redpanda.SeedServers = []SeedServer{{Host: SocketAddress{Address: "10.0.0.3"}}}
+			},
+		},
+		{
+			name:  "nested merge",
+			patch: `{"redpanda": {"rpc_server": {"port": 33146}, "tuning": {"b": 2}}}`,
+			exp: func(c *Config) {
+				c.Redpanda.RPCServer.Port = 33146
+				c.Redpanda.Other["tuning"] = map[string]interface{}{"a": 1, "b": 2}
+			},
+		},
+		{
+			name:  "null deletes keys",
+			patch: `{"redpanda": {"seed_servers": null, "tuning": {"a": null}}, "rpk": {"kafka_api": null}}`,
+			exp: func(c *Config) {
+				c.Redpanda.SeedServers = nil
+				c.Redpanda.Other["tuning"] = map[string]interface{}{}
+				c.Rpk.KafkaAPI = RpkKafkaAPI{}
+			},
+		},
+		{
+			name:  "null of an absent key",
+			patch: `{"redpanda": {"absent": null}}`,
+			exp:   func(*Config) {},
+		},
+		{
+			name:  "yaml patch",
+			patch: "redpanda:\n  developer_mode: false\n",
+			exp: func(c *Config) {
+				c.Redpanda.DeveloperMode = false
+			},
+		},
+		{
+			name:   "invalid patch",
+			patch:  `{"redpanda": `,
+			expErr: true,
+		},
+		{
+			name:   "non object patch",
+			patch:  `[1, 2]`,
+			expErr: true,
+		},
+		{
+			name:   "invalid value",
+			patch:  `{"redpanda": {"node_id": "three"}}`,
+			expErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			base := func() *Config {
+				c := Default()
+				c.Redpanda.SeedServers = []SeedServer{
+					{Host: SocketAddress{"10.0.0.1", 33145}},
+					{Host: SocketAddress{"10.0.0.2", 33145}},
+				}
+				c.Redpanda.Other = map[string]interface{}{
+					"tuning": map[string]interface{}{"a": 1},
+				}
+				c.Rpk.KafkaAPI.Brokers = []string{"10.0.0.1:9092"}
+				return c
+			}
+			c := base()
+			c.loadedPath = "/etc/redpanda/redpanda.yaml"
+
+			err := c.ApplyMergePatch([]byte(test.patch))
+			if test.expErr {
+				require.Error(t, err)
+				require.ErrorIs(t, err, ErrInvalidFormat)
+				require.Equal(t, base().Redpanda, c.Redpanda)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "/etc/redpanda/redpanda.yaml", c.loadedPath)
+
+			exp := base()
+			test.exp(exp)
+			diffs, err := Diff(exp, c)
+			require.NoError(t, err)
+			require.Empty(t, diffs)
+		})
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		patch   string
+		exp     func(*Config)
+		expKind error
+	}{
+		{
+			name:  "empty patch",
+			patch: `[]`,
+			exp:   func(*Config) {},
+		},
+		{
+			name: "add",
+			patch: `[
+  {"op": "add", "path": "/redpanda/rack", "value": "rack-1"},
+  {"op": "add", "path": "/redpanda/seed_servers/1", "value": {"host": {"address": "10.0.0.3", "port": 33145}}},
+  {"op": "add", "path": "rpk.kafka_api.brokers.-", "value": "10.0.0.2:9092"},
+  {"op": "add", "path": "/redpanda/tuning/b", "value": 2}
+]`,
+			exp: func(c *Config) {
+				c.Redpanda.Rack = "rack-1"
+				c.Redpanda.SeedServers = []SeedServer{
+					{Host: SocketAddress{"10.0.0.1", 33145}},
+					{Host: SocketAddress{"10.0.0.3", 33145}},
+					{Host: SocketAddress{"10.0.0.2", 33145}},
+				}
+				c.Rpk.KafkaAPI.Brokers = []string{"10.0.0.1:9092", "10.0.0.2:9092"}
+				c.Redpanda.Other["tuning"] = map[string]interface{}{"a": 1, "b": 2}
+			},
+		},
+		{
+			name: "remove",
+			patch: `[
+  {"op": "remove", "path": "/redpanda/seed_servers/0"},
+  {"op": "remove", "path": "redpanda.tuning.a"}
+]`,
+			exp: func(c *Config) {
+				c.Redpanda.SeedServers = []SeedServer{{Host: SocketAddress{"10.0.0.2", 33145}}}
+				c.Redpanda.Other["tuning"] = map[string]interface{}{}
+			},
+		},
+		{
+			name: "replace",
+			patch: `[
+  {"op": "replace", "path": "/redpanda/node_id", "value": 3},
+  {"op": "replace", "path": "/redpanda/seed_servers/1/host/address", "value": "10.0.0.3"}
+]`,
+			exp: func(c *Config) {
+				c.Redpanda.ID = 3
+				c.Redpanda.SeedServers[1].Host.Address = "10.0.0.3"
+			},
+		},
+		{
+			name: "move and copy",
+			patch: `[
+  {"op": "move", "from": "/redpanda/tuning", "path": "/redpanda/tunables"},
+  {"op": "copy", "from": "/redpanda/seed_servers/0", "path": "/redpanda/seed_servers/-"},
+  {"op": "replace", "path": "/redpanda/seed_servers/2/host/port", "value": 33146}
+]`,
+			exp: func(c *Config) {
+				c.Redpanda.Other = map[string]interface{}{"tunables": map[string]interface{}{"a": 1}}
+				c.Redpanda.SeedServers = append(c.Redpanda.SeedServers, SeedServer{Host: SocketAddress{"10.0.0.1", 33146}})
+			},
+		},
+		{
+			name: "test",
+			patch: `[
+  {"op": "test", "path": "/redpanda/node_id", "value": 0},
+  {"op": "test", "path": "/redpanda/seed_servers/1", "value": {"host": {"address": "10.0.0.2", "port": 33145.0}}},
+  {"op": "replace", "path": "/redpanda/node_id", "value": 1}
+]`,
+			exp: func(c *Config) {
+				c.Redpanda.ID = 1
+			},
+		},
+		{
+			name: "failed test aborts",
+			patch: `[
+  {"op": "replace", "path": "/redpanda/node_id", "value": 1},
+  {"op": "test", "path": "/redpanda/developer_mode", "value": false}
+]`,
+			expKind: ErrPatchTestFailed,
+		},
+		{
+			name:    "remove missing key",
+			patch:   `[{"op": "remove", "path": "/redpanda/absent"}]`,
+			expKind: ErrKeyNotFound,
+		},
+		{
+			name:    "replace out of range",
+			patch:   `[{"op": "replace", "path": "/redpanda/seed_servers/2", "value": {}}]`,
+			expKind: ErrKeyNotFound,
+		},
+		{
+			name:    "unsupported op",
+			patch:   `[{"op": "merge", "path": "/redpanda"}]`,
+			expKind: ErrInvalidFormat,
+		},
+		{
+			name:    "missing value",
+			patch:   `[{"op": "add", "path": "/redpanda/rack"}]`,
+			expKind: ErrInvalidFormat,
+		},
+		{
+			name:    "not a list",
+			patch:   `{"op": "add", "path": "/redpanda/rack", "value": "r"}`,
+			expKind: ErrInvalidFormat,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			base := func() *Config {
+				c := Default()
+				c.Redpanda.SeedServers = []SeedServer{
+					{Host: SocketAddress{"10.0.0.1", 33145}},
+					{Host: SocketAddress{"10.0.0.2", 33145}},
+				}
+				c.Redpanda.Other = map[string]interface{}{
+					"tuning": map[string]interface{}{"a": 1},
+				}
+				c.Rpk.KafkaAPI.Brokers = []string{"10.0.0.1:9092"}
+				return c
+			}
+			c := base()
+
+			err := c.ApplyJSONPatch([]byte(test.patch))
+			if test.expKind != nil {
+				require.ErrorIs(t, err, test.expKind)
+				diffs, err := Diff(base(), c)
+				require.NoError(t, err)
+				require.Empty(t, diffs, "the configuration was modified")
+				return
+			}
+			require.NoError(t, err)
+
+			exp := base()
+			test.exp(exp)
+			diffs, err := Diff(exp, c)
+			require.NoError(t, err)
+			require.Empty(t, diffs)
+		})
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"bytes"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// marshalPreserving marshals v, reusing the layout of the existing YAML
+// document orig: for every key that is still present, its comments, position
+// and, if the value did not change, its style are kept. New keys are appended
+// at the end of the mapping they belong to, and keys that are no longer
+// present are dropped.
+//
+// If orig is not a YAML document, this is yaml.Marshal.
+func marshalPreserving(v interface{}, orig []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(orig, &doc); err != nil || doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return yaml.Marshal(v)
+	}
+	var updated yaml.Node
+	if err := updated.Encode(v); err != nil {
+		return nil, err
+	}
+	mergeNode(doc.Content[0], &updated)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(detectIndent(orig))
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SetComment sets the comment that Write writes above key, a path of mapping
+// keys such as redpanda.seed_servers, replacing the comment that the file has
+// there, if any. Comments are only written to YAML config files, and are not
+// part of the configuration: a key that is not written has no comment.
+func (c *Config) SetComment(key, comment string) {
+	if c.comments == nil {
+		c.comments = make(map[string]string)
+	}
+	c.comments[key] = comment
+}
+
+// annotate sets the comments above their key in the YAML document b.
+func annotate(b []byte, comments map[string]string) ([]byte, error) {
+	if len(comments) == 0 {
+		return b, nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil || len(doc.Content) == 0 {
+		return b, err
+	}
+	for key, comment := range comments {
+		n := doc.Content[0]
+		props := strings.Split(key, ".")
+		for _, prop := range props[:len(props)-1] {
+			if n = mappingValue(n, prop); n == nil {
+				break
+			}
+		}
+		if n == nil || n.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == props[len(props)-1] {
+				n.Content[i].HeadComment = comment
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(detectIndent(b))
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeNode updates dst in place to hold the values of src, keeping dst's
+// comments and ordering.
+func mergeNode(dst, src *yaml.Node) {
+	switch {
+	case dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode:
+		srcValues := make(map[string]*yaml.Node, len(src.Content)/2)
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			srcValues[src.Content[i].Value] = src.Content[i+1]
+		}
+		merged := make([]*yaml.Node, 0, len(src.Content))
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			k, v := dst.Content[i], dst.Content[i+1]
+			sv, ok := srcValues[k.Value]
+			if !ok {
+				continue
+			}
+			delete(srcValues, k.Value)
+			mergeNode(v, sv)
+			merged = append(merged, k, v)
+		}
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			if _, ok := srcValues[src.Content[i].Value]; ok {
+				merged = append(merged, src.Content[i], src.Content[i+1])
+			}
+		}
+		if len(dst.Content) == 0 {
+			dst.Style = src.Style
+		}
+		dst.Content = merged
+
+	case dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode:
+		if len(dst.Content) == 0 {
+			dst.Style = src.Style
+		}
+		for i, sv := range src.Content {
+			if i < len(dst.Content) {
+				mergeNode(dst.Content[i], sv)
+			} else {
+				dst.Content = append(dst.Content, sv)
+			}
+		}
+		dst.Content = dst.Content[:len(src.Content)]
+
+	case dst.Kind == yaml.ScalarNode && src.Kind == yaml.ScalarNode:
+		if dst.Value == src.Value && dst.ShortTag() == src.ShortTag() {
+			return
+		}
+		dst.Value, dst.Tag, dst.Style = src.Value, src.Tag, src.Style
+
+	default:
+		head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+		*dst = *src
+		dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+	}
+}
+
+// fillDefaults sets every key of the def mapping that is absent from the file
+// mapping into dst, recursing into the mappings that dst already has.
+// Keys that have a non zero value in dst despite being absent from the file,
+// e.g. because of an env or flag override, are kept.
+func fillDefaults(dst, def, file *yaml.Node) {
+	if dst.Kind != yaml.MappingNode || def.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(def.Content); i += 2 {
+		k, dv := def.Content[i], def.Content[i+1]
+		v := mappingValue(dst, k.Value)
+		fv := mappingValue(file, k.Value)
+		switch {
+		case fv == nil && (v == nil || isZeroNode(v)):
+			setMappingValue(dst, k, dv)
+		case v != nil:
+			fillDefaults(v, dv, fv)
+		}
+	}
+}
+
+// overlayNode merges src onto dst in place: the keys of a src mapping are
+// merged recursively into a dst mapping, and any other src value replaces
+// dst.
+func overlayNode(dst, src *yaml.Node) {
+	if dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		*dst = *src
+		return
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		k, sv := src.Content[i], src.Content[i+1]
+		if v := mappingValue(dst, k.Value); v != nil {
+			overlayNode(v, sv)
+			continue
+		}
+		dst.Content = append(dst.Content, k, sv)
+	}
+}
+
+// pruneDefaults removes from the mapping n every key that has the same value
+// in the def mapping, recursing into the mappings of both. Mappings that end
+// up empty are removed as well.
+func pruneDefaults(n, def *yaml.Node) {
+	pruned := n.Content[:0]
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		dv := mappingValue(def, k.Value)
+		switch {
+		case dv == nil:
+		case equalNodes(v, dv):
+			continue
+		case v.Kind == yaml.MappingNode && dv.Kind == yaml.MappingNode:
+			pruneDefaults(v, dv)
+			if len(v.Content) == 0 {
+				continue
+			}
+		}
+		pruned = append(pruned, k, v)
+	}
+	n.Content = pruned
+}
+
+// equalNodes returns whether a and b hold the same value, regardless of style,
+// comments and the order of mapping keys.
+func equalNodes(a, b *yaml.Node) bool {
+	if a.Kind != b.Kind || len(a.Content) != len(b.Content) {
+		return false
+	}
+	switch a.Kind {
+	case yaml.ScalarNode:
+		return a.Value == b.Value && a.ShortTag() == b.ShortTag()
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(a.Content); i += 2 {
+			bv := mappingValue(b, a.Content[i].Value)
+			if bv == nil || !equalNodes(a.Content[i+1], bv) {
+				return false
+			}
+		}
+		return true
+	default:
+		for i := range a.Content {
+			if !equalNodes(a.Content[i], b.Content[i]) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// isZeroNode returns whether n is the encoding of a zero value.
+func isZeroNode(n *yaml.Node) bool {
+	switch n.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		return len(n.Content) == 0
+	case yaml.ScalarNode:
+		switch n.Value {
+		case "", "0", "false", "null", "~":
+			return true
+		}
+	}
+	return false
+}
+
+// mappingValue returns the value of key in the mapping node n, or nil if n is
+// not a mapping or does not contain key.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue sets key to v in the mapping node n, appending the key if it
+// is not in n yet.
+func setMappingValue(n, key, v *yaml.Node) {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key.Value {
+			n.Content[i+1] = v
+			return
+		}
+	}
+	n.Content = append(n.Content, key, v)
+}
+
+// detectIndent returns the indentation of the first indented line of the
+// YAML document, defaulting to the encoder's default of 4 spaces.
+func detectIndent(doc []byte) int {
+	for _, line := range strings.Split(string(doc), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || trimmed == line || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if n := len(line) - len(trimmed); n >= 2 && n <= 8 {
+			return n
+		}
+		break
+	}
+	return 4
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePreservesComments(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+
+	// We first write a full config, so that the struct serialization does
+	// not add any key, and then comment it by hand.
+	fs := afero.NewMemMapFs()
+	cfg, err := new(Params).Load(fs)
+	require.NoError(t, err)
+	cfg.Redpanda.ID = 1
+	require.NoError(t, cfg.Write(fs))
+	b, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+
+	commented := "# Managed by the ops team.\n" + strings.Replace(
+		string(b),
+		"    node_id: 1\n",
+		"    # Must be unique within the cluster.\n    node_id: 1 # do not reuse\n",
+		1,
+	)
+	commented = strings.Replace(commented, "    developer_mode: true\n", "    developer_mode: true # local only\n", 1)
+	require.Contains(t, commented, "# local only")
+	require.NoError(t, afero.WriteFile(fs, path, []byte(commented), 0o644))
+
+	cfg, err = new(Params).Load(fs)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Set("redpanda.node_id", "2", ""))
+	require.NoError(t, cfg.Write(fs))
+
+	b, err = afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	exp := strings.Replace(commented, "node_id: 1 #", "node_id: 2 #", 1)
+	require.Equal(t, exp, string(b))
+}
+
+func TestWriteAppendsNewKeys(t *testing.T) {
+	const path = "/etc/redpanda/redpanda.yaml"
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, path, []byte(`# Top level comment.
+redpanda:
+  # Where the data lives.
+  data_directory: /data # on the big disk
+  seed_servers: [] # single node
+`), 0o644))
+
+	cfg, err := new(Params).Load(fs)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Set("redpanda.node_id", "3", ""))
+	require.NoError(t, cfg.Write(fs))
+
+	b, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(b), `# Top level comment.
+redpanda:
+  # Where the data lives.
+  data_directory: /data # on the big disk
+  seed_servers: [] # single node
+  node_id: 3
+`), "unexpected config:\n%s", b)
+}
+
+func TestSetComment(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(`redpanda:
+  # Old comment.
+  seed_servers: []
+  node_id: 1
+`), 0o644))
+	cfg, err := new(Params).Load(fs)
+	require.NoError(t, err)
+	cfg.SetComment("redpanda.seed_servers", "New comment.")
+	cfg.SetComment("redpanda.missing", "Not written.")
+	cfg.SetComment("missing.key", "Not written.")
+	require.NoError(t, cfg.Write(fs))
+
+	b, err := afero.ReadFile(fs, "/etc/redpanda/redpanda.yaml")
+	require.NoError(t, err)
+	require.Contains(t, string(b), "  # New comment.\n  seed_servers: []\n")
+	require.NotContains(t, string(b), "Old comment.")
+	require.NotContains(t, string(b), "Not written.")
+
+	reloaded, err := new(Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, cfg.Redpanda.SeedServers, reloaded.Redpanda.SeedServers)
+	require.Equal(t, cfg.Redpanda.ID, reloaded.Redpanda.ID)
+}
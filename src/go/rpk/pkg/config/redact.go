@@ -0,0 +1,78 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedValue replaces the values of the sensitive fields in Redacted.
+const RedactedValue = "***"
+
+// Redacted returns a copy of the configuration in which the value of every
+// non empty sensitive field is replaced with RedactedValue, e.g. to share it
+// without leaking credentials. Sensitive fields are the string fields tagged
+// with `redact:"true"`.
+func (c *Config) Redacted() (*Config, error) {
+	var n yaml.Node
+	if err := n.Encode(c); err != nil {
+		return nil, err
+	}
+	redactNode(&n, reflect.TypeOf(Config{}))
+
+	redacted := new(Config)
+	if err := n.Decode(redacted); err != nil {
+		return nil, fmt.Errorf("unable to decode redacted config: %v", err)
+	}
+	redacted.file = c.file
+	redacted.fileNode = c.fileNode
+	redacted.included = c.included
+	redacted.loadedPath = c.loadedPath
+	redacted.format = c.format
+	return redacted, nil
+}
+
+// redactNode redacts the sensitive fields of the mapping n, which is the
+// encoding of the struct type t.
+func redactNode(n *yaml.Node, t reflect.Type) {
+	if n.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		f, ok := fieldByTag(t, n.Content[i].Value)
+		if !ok {
+			continue
+		}
+		v := n.Content[i+1]
+		if f.Tag.Get("redact") == "true" {
+			if v.Kind == yaml.ScalarNode && v.Tag != "!!null" && v.Value != "" {
+				*v = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: RedactedValue}
+			}
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr || ft.Kind() == reflect.Slice {
+			ft = ft.Elem()
+		}
+		if ft.Kind() != reflect.Struct {
+			continue
+		}
+		if v.Kind == yaml.SequenceNode {
+			for _, e := range v.Content {
+				redactNode(e, ft)
+			}
+		} else {
+			redactNode(v, ft)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedacted(t *testing.T) {
+	scram := "scram-secret"
+	c := Default()
+	c.LicenseKey = "license"
+	c.Organization = "org"
+	c.Rpk.KafkaAPI.SASL = &SASL{User: "admin", Password: "secret", Mechanism: "SCRAM-SHA-256"}
+	c.Rpk.KafkaAPI.TLS = &TLS{KeyFile: "/etc/redpanda/tls.key"}
+	c.PandaproxyClient = &KafkaClient{
+		Brokers:       []SocketAddress{{"10.0.0.1", 9092}},
+		SCRAMUsername: &c.Organization,
+		SCRAMPassword: &scram,
+	}
+
+	r, err := c.Redacted()
+	require.NoError(t, err)
+
+	require.Equal(t, RedactedValue, r.LicenseKey)
+	require.Equal(t, RedactedValue, r.Rpk.KafkaAPI.SASL.Password)
+	require.Equal(t, RedactedValue, *r.PandaproxyClient.SCRAMPassword)
+
+	require.Equal(t, "org", r.Organization)
+	require.Equal(t, "admin", r.Rpk.KafkaAPI.SASL.User)
+	require.Equal(t, "SCRAM-SHA-256", r.Rpk.KafkaAPI.SASL.Mechanism)
+	require.Equal(t, "/etc/redpanda/tls.key", r.Rpk.KafkaAPI.TLS.KeyFile)
+	require.Equal(t, "org", *r.PandaproxyClient.SCRAMUsername)
+	require.Equal(t, c.PandaproxyClient.Brokers, r.PandaproxyClient.Brokers)
+	require.Equal(t, c.Redpanda, r.Redpanda)
+
+	// The original is left as is, and empty values are not masked.
+	require.Equal(t, "secret", c.Rpk.KafkaAPI.SASL.Password)
+	r, err = Default().Redacted()
+	require.NoError(t, err)
+	require.Empty(t, r.LicenseKey)
+}
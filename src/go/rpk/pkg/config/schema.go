@@ -15,17 +15,41 @@ import (
 
 	"github.com/spf13/afero"
 	"github.com/twmb/tlscfg"
+	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
 	file       *Config
 	loadedPath string
-
-	NodeUUID             string          `yaml:"node_uuid,omitempty" json:"node_uuid"`
-	Organization         string          `yaml:"organization,omitempty" json:"organization"`
-	LicenseKey           string          `yaml:"license_key,omitempty" json:"license_key"`
-	ClusterID            string          `yaml:"cluster_id,omitempty" json:"cluster_id"`
-	ConfigFile           string          `yaml:"config_file" json:"config_file"`
+	// format is the format the config file is written in, see fileFormat.
+	format string
+	// invalidRpk holds the unknown and mistyped properties of the rpk
+	// section of the file, which the weak decoding ignores or accepts but
+	// Validate reports.
+	invalidRpk []error
+	// fileNode is the config file as read, to tell the keys that the file
+	// sets from the defaulted ones, see IsPinned.
+	fileNode *yaml.Node
+	// comments are the comments to write above keys, see SetComment.
+	comments map[string]string
+	// included is the mapping of the files the config file includes, for
+	// Write not to copy their values into it, see Includes.
+	included *yaml.Node
+
+	// Version is the schema version of the config file, which Migrate stamps
+	// with ConfigVersion. Files without it are version 0.
+	Version int `yaml:"config_version,omitempty" json:"config_version,omitempty" doc:"Schema version of the config file, stamped by rpk redpanda config migrate"`
+	// Includes are the config files that Load deep merges, in order, under
+	// the config file, which values win. Relative paths are relative to
+	// the directory of the including file, and included files can include
+	// other files. As redpanda does not know about includes, rpk redpanda
+	// start hands it the config written by WriteResolved.
+	Includes             []string        `yaml:"includes,omitempty" json:"includes,omitempty" doc:"Config files to deep merge under this one, relative to its directory"`
+	NodeUUID             string          `yaml:"node_uuid,omitempty" json:"node_uuid" doc:"Unique ID of the node, generated by rpk redpanda config init"`
+	Organization         string          `yaml:"organization,omitempty" json:"organization" doc:"Name of the organization, sent with the usage stats"`
+	LicenseKey           string          `yaml:"license_key,omitempty" json:"license_key" redact:"true" doc:"Redpanda enterprise license key"`
+	ClusterID            string          `yaml:"cluster_id,omitempty" json:"cluster_id" doc:"ID of the cluster, sent with the usage stats"`
+	ConfigFile           string          `yaml:"config_file" json:"config_file" doc:"Path of this config file, set by rpk"`
 	Redpanda             RedpandaConfig  `yaml:"redpanda" json:"redpanda"`
 	Rpk                  RpkConfig       `yaml:"rpk" json:"rpk"`
 	Pandaproxy           *Pandaproxy     `yaml:"pandaproxy,omitempty" json:"pandaproxy,omitempty"`
@@ -44,23 +68,23 @@ func (c *Config) File() *Config {
 }
 
 type RedpandaConfig struct {
-	Directory                  string                 `yaml:"data_directory" json:"data_directory"`
-	ID                         int                    `yaml:"node_id" json:"node_id"`
-	Rack                       string                 `yaml:"rack,omitempty" json:"rack"`
-	SeedServers                []SeedServer           `yaml:"seed_servers" json:"seed_servers"`
-	RPCServer                  SocketAddress          `yaml:"rpc_server" json:"rpc_server"`
-	RPCServerTLS               []ServerTLS            `yaml:"rpc_server_tls,omitempty" json:"rpc_server_tls"`
-	KafkaAPI                   []NamedSocketAddress   `yaml:"kafka_api" json:"kafka_api"`
-	KafkaAPITLS                []ServerTLS            `yaml:"kafka_api_tls,omitempty" json:"kafka_api_tls"`
-	AdminAPI                   []NamedSocketAddress   `yaml:"admin" json:"admin"`
-	AdminAPITLS                []ServerTLS            `yaml:"admin_api_tls,omitempty" json:"admin_api_tls"`
+	Directory                  string                 `yaml:"data_directory" json:"data_directory" doc:"Directory where redpanda stores its data"`
+	ID                         int                    `yaml:"node_id" json:"node_id" doc:"Unique ID of the node in the cluster, 0 or more"`
+	Rack                       string                 `yaml:"rack,omitempty" json:"rack" doc:"Rack of the node, for rack-aware replica placement"`
+	SeedServers                []SeedServer           `yaml:"seed_servers" json:"seed_servers" doc:"RPC addresses of the nodes to join the cluster through; empty to bootstrap a new cluster"`
+	RPCServer                  SocketAddress          `yaml:"rpc_server" json:"rpc_server" doc:"Address the internal RPC server listens on"`
+	RPCServerTLS               []ServerTLS            `yaml:"rpc_server_tls,omitempty" json:"rpc_server_tls" doc:"TLS configuration of the RPC server"`
+	KafkaAPI                   []NamedSocketAddress   `yaml:"kafka_api" json:"kafka_api" doc:"Addresses the Kafka API listens on"`
+	KafkaAPITLS                []ServerTLS            `yaml:"kafka_api_tls,omitempty" json:"kafka_api_tls" doc:"TLS configuration of the Kafka API listeners, matched by name"`
+	AdminAPI                   []NamedSocketAddress   `yaml:"admin" json:"admin" doc:"Addresses the admin API listens on"`
+	AdminAPITLS                []ServerTLS            `yaml:"admin_api_tls,omitempty" json:"admin_api_tls" doc:"TLS configuration of the admin API listeners, matched by name"`
 	CoprocSupervisorServer     SocketAddress          `yaml:"coproc_supervisor_server,omitempty" json:"coproc_supervisor_server"`
 	AdminAPIDocDir             string                 `yaml:"admin_api_doc_dir,omitempty" json:"admin_api_doc_dir"`
 	DashboardDir               string                 `yaml:"dashboard_dir,omitempty" json:"dashboard_dir"`
 	CloudStorageCacheDirectory string                 `yaml:"cloud_storage_cache_directory,omitempty" json:"cloud_storage_cache_directory"`
-	AdvertisedRPCAPI           *SocketAddress         `yaml:"advertised_rpc_api,omitempty" json:"advertised_rpc_api,omitempty"`
-	AdvertisedKafkaAPI         []NamedSocketAddress   `yaml:"advertised_kafka_api,omitempty" json:"advertised_kafka_api,omitempty"`
-	DeveloperMode              bool                   `yaml:"developer_mode" json:"developer_mode"`
+	AdvertisedRPCAPI           *SocketAddress         `yaml:"advertised_rpc_api,omitempty" json:"advertised_rpc_api,omitempty" doc:"RPC address advertised to the other nodes, if not rpc_server"`
+	AdvertisedKafkaAPI         []NamedSocketAddress   `yaml:"advertised_kafka_api,omitempty" json:"advertised_kafka_api,omitempty" doc:"Kafka API addresses advertised to clients, if not kafka_api"`
+	DeveloperMode              bool                   `yaml:"developer_mode" json:"developer_mode" doc:"Skip the production checks and tuning, for development only"`
 	Other                      map[string]interface{} `yaml:",inline"`
 }
 
@@ -80,31 +104,31 @@ type SchemaRegistry struct {
 type KafkaClient struct {
 	Brokers       []SocketAddress        `yaml:"brokers,omitempty" json:"brokers,omitempty"`
 	BrokerTLS     ServerTLS              `yaml:"broker_tls,omitempty" json:"broker_tls,omitempty"`
-	SASLMechanism *string                `yaml:"sasl_mechanism,omitempty" json:"sasl_mechanism,omitempty"`
+	SASLMechanism *string                `yaml:"sasl_mechanism,omitempty" json:"sasl_mechanism,omitempty" enum:"SCRAM-SHA-256,SCRAM-SHA-512"`
 	SCRAMUsername *string                `yaml:"scram_username,omitempty" json:"scram_username,omitempty"`
-	SCRAMPassword *string                `yaml:"scram_password,omitempty" json:"scram_password,omitempty"`
+	SCRAMPassword *string                `yaml:"scram_password,omitempty" json:"scram_password,omitempty" redact:"true"`
 	Other         map[string]interface{} `yaml:",inline"`
 }
 
 type SeedServer struct {
-	Host SocketAddress `yaml:"host" json:"host"`
+	Host SocketAddress `yaml:"host" json:"host" doc:"RPC address of the seed server"`
 }
 
 type SocketAddress struct {
-	Address string `yaml:"address" json:"address"`
-	Port    int    `yaml:"port" json:"port"`
+	Address string `yaml:"address" json:"address" required:"true" doc:"Host name or IP address"`
+	Port    int    `yaml:"port" json:"port" required:"true" doc:"TCP port, 1-65535"`
 }
 
 type NamedSocketAddress struct {
-	Address string `yaml:"address" json:"address"`
-	Port    int    `yaml:"port" json:"port"`
-	Name    string `yaml:"name,omitempty" json:"name,omitempty"`
+	Address string `yaml:"address" json:"address" required:"true" doc:"Host name or IP address"`
+	Port    int    `yaml:"port" json:"port" required:"true" doc:"TCP port, 1-65535"`
+	Name    string `yaml:"name,omitempty" json:"name,omitempty" doc:"Name of the listener, to match TLS configurations and advertised addresses"`
 }
 
 type TLS struct {
-	KeyFile        string `yaml:"key_file,omitempty" json:"key_file"`
-	CertFile       string `yaml:"cert_file,omitempty" json:"cert_file"`
-	TruststoreFile string `yaml:"truststore_file,omitempty" json:"truststore_file"`
+	KeyFile        string `yaml:"key_file,omitempty" json:"key_file" doc:"Path of the PEM private key"`
+	CertFile       string `yaml:"cert_file,omitempty" json:"cert_file" doc:"Path of the PEM certificate"`
+	TruststoreFile string `yaml:"truststore_file,omitempty" json:"truststore_file" doc:"Path of the PEM CA certificate to verify peers with"`
 }
 
 func (t *TLS) Config(fs afero.Fs) (*tls.Config, error) {
@@ -129,12 +153,12 @@ func (t *TLS) Config(fs afero.Fs) (*tls.Config, error) {
 }
 
 type ServerTLS struct {
-	Name              string                 `yaml:"name,omitempty" json:"name"`
-	KeyFile           string                 `yaml:"key_file,omitempty" json:"key_file"`
-	CertFile          string                 `yaml:"cert_file,omitempty" json:"cert_file"`
-	TruststoreFile    string                 `yaml:"truststore_file,omitempty" json:"truststore_file"`
-	Enabled           bool                   `yaml:"enabled,omitempty" json:"enabled"`
-	RequireClientAuth bool                   `yaml:"require_client_auth,omitempty" json:"require_client_auth"`
+	Name              string                 `yaml:"name,omitempty" json:"name" doc:"Name of the listener this applies to"`
+	KeyFile           string                 `yaml:"key_file,omitempty" json:"key_file" doc:"Path of the PEM private key"`
+	CertFile          string                 `yaml:"cert_file,omitempty" json:"cert_file" doc:"Path of the PEM certificate"`
+	TruststoreFile    string                 `yaml:"truststore_file,omitempty" json:"truststore_file" doc:"Path of the PEM CA certificate to verify clients with"`
+	Enabled           bool                   `yaml:"enabled,omitempty" json:"enabled" doc:"Whether TLS is enabled on the listener"`
+	RequireClientAuth bool                   `yaml:"require_client_auth,omitempty" json:"require_client_auth" doc:"Whether clients must present a certificate (mTLS)"`
 	Other             map[string]interface{} `yaml:",inline" `
 }
 
@@ -144,47 +168,47 @@ type RpkConfig struct {
 	// Deprecated 2021-07-1
 	SASL *SASL `yaml:"sasl,omitempty" json:"sasl,omitempty"`
 
-	KafkaAPI                 RpkKafkaAPI `yaml:"kafka_api,omitempty" json:"kafka_api"`
-	AdminAPI                 RpkAdminAPI `yaml:"admin_api,omitempty" json:"admin_api"`
-	AdditionalStartFlags     []string    `yaml:"additional_start_flags,omitempty"  json:"additional_start_flags"`
-	EnableUsageStats         bool        `yaml:"enable_usage_stats" json:"enable_usage_stats"`
-	TuneNetwork              bool        `yaml:"tune_network" json:"tune_network"`
+	KafkaAPI                 RpkKafkaAPI `yaml:"kafka_api,omitempty" json:"kafka_api" doc:"How rpk connects to the Kafka API"`
+	AdminAPI                 RpkAdminAPI `yaml:"admin_api,omitempty" json:"admin_api" doc:"How rpk connects to the admin API"`
+	AdditionalStartFlags     []string    `yaml:"additional_start_flags,omitempty"  json:"additional_start_flags" doc:"Extra flags rpk redpanda start passes to redpanda"`
+	EnableUsageStats         bool        `yaml:"enable_usage_stats" json:"enable_usage_stats" doc:"Send anonymous usage stats to Redpanda Data"`
+	TuneNetwork              bool        `yaml:"tune_network" json:"tune_network" doc:"Tune the NIC queues and IRQ affinity on start"`
 	TuneDiskScheduler        bool        `yaml:"tune_disk_scheduler" json:"tune_disk_scheduler"`
 	TuneNomerges             bool        `yaml:"tune_disk_nomerges" json:"tune_disk_nomerges"`
 	TuneDiskWriteCache       bool        `yaml:"tune_disk_write_cache" json:"tune_disk_write_cache"`
 	TuneDiskIrq              bool        `yaml:"tune_disk_irq" json:"tune_disk_irq"`
 	TuneFstrim               bool        `yaml:"tune_fstrim" json:"tune_fstrim"`
-	TuneCPU                  bool        `yaml:"tune_cpu" json:"tune_cpu"`
+	TuneCPU                  bool        `yaml:"tune_cpu" json:"tune_cpu" doc:"Tune the CPU governor and power states on start"`
 	TuneAioEvents            bool        `yaml:"tune_aio_events" json:"tune_aio_events"`
 	TuneClocksource          bool        `yaml:"tune_clocksource" json:"tune_clocksource"`
 	TuneSwappiness           bool        `yaml:"tune_swappiness" json:"tune_swappiness"`
 	TuneTransparentHugePages bool        `yaml:"tune_transparent_hugepages" json:"tune_transparent_hugepages"`
 	EnableMemoryLocking      bool        `yaml:"enable_memory_locking" json:"enable_memory_locking"`
 	TuneCoredump             bool        `yaml:"tune_coredump" json:"tune_coredump"`
-	CoredumpDir              string      `yaml:"coredump_dir,omitempty" json:"coredump_dir"`
+	CoredumpDir              string      `yaml:"coredump_dir,omitempty" json:"coredump_dir" doc:"Directory coredumps are written to if tune_coredump is set"`
 	TuneBallastFile          bool        `yaml:"tune_ballast_file" json:"tune_ballast_file"`
 	BallastFilePath          string      `yaml:"ballast_file_path,omitempty" json:"ballast_file_path"`
 	BallastFileSize          string      `yaml:"ballast_file_size,omitempty" json:"ballast_file_size"`
 	WellKnownIo              string      `yaml:"well_known_io,omitempty" json:"well_known_io"`
-	Overprovisioned          bool        `yaml:"overprovisioned" json:"overprovisioned"`
-	SMP                      *int        `yaml:"smp,omitempty" json:"smp,omitempty"`
+	Overprovisioned          bool        `yaml:"overprovisioned" json:"overprovisioned" doc:"Run redpanda on shared CPUs, e.g. in containers"`
+	SMP                      *int        `yaml:"smp,omitempty" json:"smp,omitempty" doc:"Number of cores redpanda runs on, 1 or more"`
 }
 
 type RpkKafkaAPI struct {
-	Brokers []string `yaml:"brokers,omitempty" json:"brokers"`
-	TLS     *TLS     `yaml:"tls,omitempty" json:"tls"`
-	SASL    *SASL    `yaml:"sasl,omitempty" json:"sasl,omitempty"`
+	Brokers []string `yaml:"brokers,omitempty" json:"brokers" doc:"Kafka API addresses rpk connects to, host:port"`
+	TLS     *TLS     `yaml:"tls,omitempty" json:"tls" doc:"TLS configuration of the rpk Kafka client"`
+	SASL    *SASL    `yaml:"sasl,omitempty" json:"sasl,omitempty" doc:"SASL credentials of the rpk Kafka client"`
 }
 
 type RpkAdminAPI struct {
-	Addresses []string `yaml:"addresses,omitempty" json:"addresses"`
-	TLS       *TLS     `yaml:"tls,omitempty" json:"tls"`
+	Addresses []string `yaml:"addresses,omitempty" json:"addresses" doc:"Admin API addresses rpk connects to, host:port"`
+	TLS       *TLS     `yaml:"tls,omitempty" json:"tls" doc:"TLS configuration of the rpk admin API client"`
 }
 
 type SASL struct {
-	User      string `yaml:"user,omitempty" json:"user,omitempty"`
-	Password  string `yaml:"password,omitempty" json:"password,omitempty"`
-	Mechanism string `yaml:"type,omitempty" json:"type,omitempty"`
+	User      string `yaml:"user,omitempty" json:"user,omitempty" doc:"SASL user name"`
+	Password  string `yaml:"password,omitempty" json:"password,omitempty" redact:"true" doc:"SASL password"`
+	Mechanism string `yaml:"type,omitempty" json:"type,omitempty" enum:"SCRAM-SHA-256,SCRAM-SHA-512" doc:"SASL mechanism, SCRAM-SHA-256 or SCRAM-SHA-512"`
 }
 
 func (c *Config) PIDFile() string {
@@ -0,0 +1,141 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+)
+
+// ErrDuplicateSeedServer is returned when a seed server host is listed more
+// than once.
+var ErrDuplicateSeedServer = errors.New("duplicate seed server")
+
+// SetSeedServers replaces the seed servers of conf, failing if a host is
+// listed more than once, and returns the updated conf.
+//
+// Seed servers are identified by their host: their node_id is deprecated and
+// unused, so their ID is their index in the list.
+func SetSeedServers(conf *Config, seeds []SeedServer) (*Config, error) {
+	set := make([]SeedServer, 0, len(seeds))
+	for _, s := range seeds {
+		if seedIndex(set, s.Host) >= 0 {
+			return nil, duplicateSeedErr(s.Host)
+		}
+		set = append(set, s)
+	}
+	conf.Redpanda.SeedServers = set
+	return conf, nil
+}
+
+// AddSeedServer appends host to the seed servers of conf, returning its ID,
+// which is its index in the list. It fails if host already is a seed server.
+func AddSeedServer(conf *Config, host SocketAddress) (int, error) {
+	if seedIndex(conf.Redpanda.SeedServers, host) >= 0 {
+		return 0, duplicateSeedErr(host)
+	}
+	conf.Redpanda.SeedServers = append(conf.Redpanda.SeedServers, SeedServer{Host: host})
+	return len(conf.Redpanda.SeedServers) - 1, nil
+}
+
+// seedIndex returns the index of the seed server with the given host, or -1.
+func seedIndex(seeds []SeedServer, host SocketAddress) int {
+	for i, s := range seeds {
+		if s.Host == host {
+			return i
+		}
+	}
+	return -1
+}
+
+func duplicateSeedErr(host SocketAddress) error {
+	return fmt.Errorf("%w %s", ErrDuplicateSeedServer, net.JoinHostPort(host.Address, strconv.Itoa(host.Port)))
+}
+
+// AssignSeedIDs returns the seeds, without their duplicate hosts, ordered so
+// that the seeds listed in current keep their ID, which is their index in the
+// list. The seeds that are not in current, or whose ID in current is past the
+// end of the returned list, take the free IDs, lowest first, in the order they
+// are listed in seeds. The IDs thus stay contiguous from 0, and assigning the
+// IDs again with the result as current is a no-op. Neither current nor seeds
+// are modified.
+func AssignSeedIDs(current, seeds []SeedServer) []SeedServer {
+	ids := make(map[SocketAddress]int, len(current))
+	for i, s := range current {
+		if _, ok := ids[s.Host]; !ok {
+			ids[s.Host] = i
+		}
+	}
+	unique := make([]SeedServer, 0, len(seeds))
+	for _, s := range seeds {
+		if seedIndex(unique, s.Host) < 0 {
+			unique = append(unique, s)
+		}
+	}
+
+	assigned := make([]SeedServer, len(unique))
+	taken := make([]bool, len(unique))
+	var rest []SeedServer
+	for _, s := range unique {
+		if id, ok := ids[s.Host]; ok && id < len(assigned) {
+			assigned[id] = s
+			taken[id] = true
+			continue
+		}
+		rest = append(rest, s)
+	}
+	for id := range assigned {
+		if !taken[id] {
+			assigned[id], rest = rest[0], rest[1:]
+		}
+	}
+	return assigned
+}
+
+// NormalizeSeeds returns the seeds without their exact duplicate hosts, sorted
+// by host: IP addresses first, in numeric order, then hostnames, in
+// lexicographic order, the ports breaking ties. As the ID of a seed server is
+// its index in the list, the IDs of the normalized seeds are contiguous from 0
+// whatever the order of the input, e.g. across nodes that appended the same
+// seeds in a different order, but they can differ from the IDs of the input;
+// see AssignSeedIDs to keep them. The input is left as is.
+func NormalizeSeeds(seeds []SeedServer) []SeedServer {
+	normalized := make([]SeedServer, 0, len(seeds))
+	for _, s := range seeds {
+		if seedIndex(normalized, s.Host) < 0 {
+			normalized = append(normalized, s)
+		}
+	}
+	sort.SliceStable(normalized, func(i, j int) bool {
+		return lessHost(normalized[i].Host, normalized[j].Host)
+	})
+	return normalized
+}
+
+func lessHost(a, b SocketAddress) bool {
+	if a.Address != b.Address {
+		ipA, ipB := net.ParseIP(a.Address), net.ParseIP(b.Address)
+		switch {
+		case ipA != nil && ipB != nil:
+			if c := bytes.Compare(ipA.To16(), ipB.To16()); c != 0 {
+				return c < 0
+			}
+		case ipA != nil || ipB != nil:
+			return ipA != nil
+		default:
+			return a.Address < b.Address
+		}
+	}
+	return a.Port < b.Port
+}
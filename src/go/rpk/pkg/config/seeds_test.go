@@ -0,0 +1,141 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetSeedServers(t *testing.T) {
+	a := SeedServer{Host: SocketAddress{"10.0.0.1", 33145}}
+	b := SeedServer{Host: SocketAddress{"10.0.0.2", 33145}}
+
+	conf := Default()
+	got, err := SetSeedServers(conf, []SeedServer{a, b})
+	require.NoError(t, err)
+	require.Equal(t, []SeedServer{a, b}, got.Redpanda.SeedServers)
+
+	got, err = SetSeedServers(conf, nil)
+	require.NoError(t, err)
+	require.Equal(t, []SeedServer{}, got.Redpanda.SeedServers)
+
+	conf.Redpanda.SeedServers = []SeedServer{b}
+	_, err = SetSeedServers(conf, []SeedServer{a, b, a})
+	require.True(t, errors.Is(err, ErrDuplicateSeedServer))
+	require.EqualError(t, err, "duplicate seed server 10.0.0.1:33145")
+	require.Equal(t, []SeedServer{b}, conf.Redpanda.SeedServers, "seeds should not be modified on failure")
+}
+
+func TestAddSeedServer(t *testing.T) {
+	conf := Default()
+	for i, host := range []SocketAddress{
+		{"10.0.0.1", 33145},
+		{"10.0.0.2", 33145},
+		// Same address, different port.
+		{"10.0.0.2", 33146},
+		{"redpanda-3.local", 33145},
+	} {
+		id, err := AddSeedServer(conf, host)
+		require.NoError(t, err)
+		require.Equal(t, i, id)
+	}
+	require.Len(t, conf.Redpanda.SeedServers, 4)
+
+	_, err := AddSeedServer(conf, SocketAddress{"10.0.0.2", 33146})
+	require.True(t, errors.Is(err, ErrDuplicateSeedServer))
+	require.Len(t, conf.Redpanda.SeedServers, 4)
+}
+
+func TestNormalizeSeeds(t *testing.T) {
+	seed := func(addr string, port int) SeedServer {
+		return SeedServer{Host: SocketAddress{addr, port}}
+	}
+	for _, test := range []struct {
+		name  string
+		seeds []SeedServer
+		exp   []SeedServer
+	}{
+		{"empty", nil, []SeedServer{}},
+		{
+			"duplicates removed",
+			[]SeedServer{seed("10.0.0.1", 33145), seed("10.0.0.2", 33145), seed("10.0.0.1", 33145)},
+			[]SeedServer{seed("10.0.0.1", 33145), seed("10.0.0.2", 33145)},
+		},
+		{
+			"same address on other ports kept",
+			[]SeedServer{seed("10.0.0.1", 33146), seed("10.0.0.1", 33145)},
+			[]SeedServer{seed("10.0.0.1", 33145), seed("10.0.0.1", 33146)},
+		},
+		{
+			"IPs in numeric order, before hostnames",
+			[]SeedServer{
+				seed("rp-b.local", 33145),
+				seed("10.0.0.10", 33145),
+				seed("rp-a.local", 33145),
+				seed("10.0.0.2", 33145),
+				seed("::1", 33145),
+			},
+			[]SeedServer{
+				seed("::1", 33145),
+				seed("10.0.0.2", 33145),
+				seed("10.0.0.10", 33145),
+				seed("rp-a.local", 33145),
+				seed("rp-b.local", 33145),
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := NormalizeSeeds(test.seeds)
+			require.Equal(t, test.exp, got)
+			require.Equal(t, got, NormalizeSeeds(got), "normalizing should be idempotent")
+		})
+	}
+
+	// The order of the input does not matter.
+	a := []SeedServer{seed("10.0.0.3", 33145), seed("10.0.0.1", 33145), seed("10.0.0.2", 33145)}
+	b := []SeedServer{seed("10.0.0.2", 33145), seed("10.0.0.3", 33145), seed("10.0.0.1", 33145), seed("10.0.0.2", 33145)}
+	require.Equal(t, NormalizeSeeds(a), NormalizeSeeds(b))
+	require.Equal(t, seed("10.0.0.3", 33145), a[0], "the input should not be modified")
+}
+
+func TestAssignSeedIDs(t *testing.T) {
+	seed := func(addr string) SeedServer {
+		return SeedServer{Host: SocketAddress{addr, 33145}}
+	}
+	a, b, c, d, e := seed("10.0.0.1"), seed("10.0.0.2"), seed("10.0.0.3"), seed("10.0.0.4"), seed("10.0.0.5")
+	for _, test := range []struct {
+		name    string
+		current []SeedServer
+		seeds   []SeedServer
+		exp     []SeedServer
+	}{
+		{"empty", nil, nil, []SeedServer{}},
+		{"no current seeds", nil, []SeedServer{b, a}, []SeedServer{b, a}},
+		{"reordered seeds keep their IDs", []SeedServer{a, b, c}, []SeedServer{c, a, b}, []SeedServer{a, b, c}},
+		{"new seeds are appended", []SeedServer{a, b}, []SeedServer{d, b, a, c}, []SeedServer{a, b, d, c}},
+		{"new seeds take the free IDs", []SeedServer{a, b, c}, []SeedServer{c, d, a}, []SeedServer{a, d, c}},
+		{"IDs past the end are reassigned", []SeedServer{a, b, c, d}, []SeedServer{d, e}, []SeedServer{d, e}},
+		{"duplicates removed", []SeedServer{a, b}, []SeedServer{b, a, b, c, c}, []SeedServer{a, b, c}},
+		{"duplicate current seeds keep their first ID", []SeedServer{a, a, b}, []SeedServer{b, a}, []SeedServer{a, b}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			current := append([]SeedServer(nil), test.current...)
+			seeds := append([]SeedServer(nil), test.seeds...)
+			got := AssignSeedIDs(test.current, test.seeds)
+			require.Equal(t, test.exp, got)
+			require.Equal(t, got, AssignSeedIDs(got, test.seeds), "assigning should be idempotent")
+			require.Equal(t, current, test.current, "current was modified")
+			require.Equal(t, seeds, test.seeds, "seeds were modified")
+		})
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SortKeys sorts the keys of the mappings of n, the YAML node of a Config
+// such as Overrides returns, recursively. The keys of the maps, such as the
+// unmanaged properties, are sorted bytewise, as encoding/json sorts them,
+// while the fields of the structs keep their declared order, followed by the
+// unmanaged properties of the struct.
+func SortKeys(n *yaml.Node) {
+	if n.Kind == yaml.DocumentNode {
+		for _, c := range n.Content {
+			SortKeys(c)
+		}
+		return
+	}
+	sortNode(n, reflect.TypeOf(Config{}))
+}
+
+// RenderSorted is Render, but with the keys sorted as SortKeys sorts them.
+// The JSON and TOML encoders sort the keys of the maps already.
+func RenderSorted(conf *Config, format string) ([]byte, error) {
+	f := strings.ToLower(format)
+	if f != FormatYAML && f != FormatHCL && f != "" {
+		return Render(conf, format)
+	}
+	var n yaml.Node
+	if err := n.Encode(conf); err != nil {
+		return nil, err
+	}
+	SortKeys(&n)
+	if f == FormatHCL {
+		return MarshalHCL(&n)
+	}
+	return yaml.Marshal(&n)
+}
+
+// sortNode sorts the mappings of n, the YAML node of a value of type t. A
+// nil t is a value of any type, whose mappings are all maps.
+func sortNode(n *yaml.Node, t reflect.Type) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != nil && t.Kind() == reflect.Interface {
+		t = nil
+	}
+	switch n.Kind {
+	case yaml.SequenceNode:
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		for _, c := range n.Content {
+			sortNode(c, elem)
+		}
+	case yaml.MappingNode:
+		if t != nil && t.Kind() == reflect.Struct {
+			sortStructNode(n, t)
+			return
+		}
+		var elem reflect.Type
+		if t != nil && t.Kind() == reflect.Map {
+			elem = t.Elem()
+		}
+		sortMapNode(n, elem)
+	}
+}
+
+// sortMapNode sorts the keys of the mapping n bytewise, and then its values,
+// of type elem.
+func sortMapNode(n *yaml.Node, elem reflect.Type) {
+	pairs := nodePairs(n)
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i][0].Value < pairs[j][0].Value })
+	setNodePairs(n, pairs)
+	for _, p := range pairs {
+		sortNode(p[1], elem)
+	}
+}
+
+// sortStructNode orders the keys of the mapping n of the struct type t as
+// the fields are declared, followed by the keys of the inline map, bytewise.
+func sortStructNode(n *yaml.Node, t reflect.Type) {
+	fields := make(map[string]int)
+	types := make(map[string]reflect.Type)
+	var inline reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("yaml"), ",")
+		if tag[0] == "" && len(tag) > 1 && tag[1] == "inline" && f.Type.Kind() == reflect.Map {
+			inline = f.Type.Elem()
+			continue
+		}
+		name := tag[0]
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = i
+		types[name] = f.Type
+	}
+	pairs := nodePairs(n)
+	sort.SliceStable(pairs, func(i, j int) bool {
+		ki, kj := pairs[i][0].Value, pairs[j][0].Value
+		fi, iok := fields[ki]
+		fj, jok := fields[kj]
+		switch {
+		case iok && jok:
+			return fi < fj
+		case iok || jok:
+			return iok
+		default:
+			return ki < kj
+		}
+	})
+	setNodePairs(n, pairs)
+	for _, p := range pairs {
+		ft, ok := types[p[0].Value]
+		if !ok {
+			ft = inline
+		}
+		sortNode(p[1], ft)
+	}
+}
+
+func nodePairs(n *yaml.Node) [][2]*yaml.Node {
+	pairs := make([][2]*yaml.Node, 0, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{n.Content[i], n.Content[i+1]})
+	}
+	return pairs
+}
+
+func setNodePairs(n *yaml.Node, pairs [][2]*yaml.Node) {
+	n.Content = n.Content[:0]
+	for _, p := range pairs {
+		n.Content = append(n.Content, p[0], p[1])
+	}
+}
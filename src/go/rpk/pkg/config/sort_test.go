@@ -0,0 +1,61 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderSorted(t *testing.T) {
+	newConfig := func() *Config {
+		c := Default()
+		c.Redpanda.ID = 1
+		c.Redpanda.Other = map[string]interface{}{
+			"node9":  1,
+			"node10": 2,
+			"b":      map[string]interface{}{"z": 1, "a": 2},
+			"A":      []interface{}{map[string]interface{}{"y": 1, "x": 2}},
+		}
+		return c
+	}
+
+	first, err := RenderSorted(newConfig(), FormatYAML)
+	require.NoError(t, err)
+	second, err := RenderSorted(newConfig(), FormatYAML)
+	require.NoError(t, err)
+	require.Equal(t, string(first), string(second))
+
+	// The declared fields come first, in order, then the unmanaged
+	// properties, bytewise.
+	out := string(first)
+	order := []string{"\n    data_directory:", "\n    node_id:", "\n    A:", "\n        - x:", "\n          \"y\":", "\n    b:", "\n        a:", "\n        z:", "\n    node10:", "\n    node9:"}
+	last := -1
+	for _, key := range order {
+		i := strings.Index(out, key)
+		require.Greater(t, i, last, "%q is out of order in:\n%s", key, out)
+		last = i
+	}
+
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal(first, &cfg))
+	require.Equal(t, 1, cfg.Redpanda.ID)
+	require.Equal(t, 2, cfg.Redpanda.Other["node10"])
+
+	// The other formats are sorted by their encoder.
+	json, err := RenderSorted(newConfig(), FormatJSON)
+	require.NoError(t, err)
+	exp, err := Render(newConfig(), FormatJSON)
+	require.NoError(t, err)
+	require.Equal(t, string(exp), string(json))
+}
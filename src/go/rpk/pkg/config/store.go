@@ -0,0 +1,101 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"io"
+
+	"github.com/spf13/afero"
+)
+
+// ConfigStore reads and writes a configuration, wherever it is stored. The
+// commands and helpers that update the configuration go through a store, so
+// that the configuration can be kept elsewhere than in a file, e.g. in a
+// key-value store of a service embedding rpk.
+type ConfigStore interface {
+	// Read returns the stored configuration, or the default configuration
+	// if none is stored yet.
+	Read() (*Config, error)
+	// Write stores the configuration.
+	Write(*Config) error
+}
+
+// FsStore is the ConfigStore of the config file on a filesystem: it reads
+// the configuration with Params.Load and writes it with Config.Write.
+type FsStore struct {
+	fs afero.Fs
+	p  *Params
+}
+
+// NewFsStore returns the store of the config file that p locates on fs.
+func NewFsStore(fs afero.Fs, p *Params) *FsStore {
+	return &FsStore{fs: fs, p: p}
+}
+
+// Read loads the configuration, see Params.Load.
+func (s *FsStore) Read() (*Config, error) {
+	return s.p.Load(s.fs)
+}
+
+// Write writes the configuration to the file it was loaded from, see
+// Config.Write.
+func (s *FsStore) Write(c *Config) error {
+	return c.Write(s.fs)
+}
+
+// Fs returns the filesystem of the store.
+func (s *FsStore) Fs() afero.Fs {
+	return s.fs
+}
+
+// StreamStore is the ConfigStore of a configuration read from a reader and
+// written to a writer, e.g. stdin and stdout.
+type StreamStore struct {
+	p *Params
+	r io.Reader
+	w io.Writer
+}
+
+// NewStreamStore returns a store reading the configuration from r and
+// writing it to w, in the --config-format of p.
+func NewStreamStore(p *Params, r io.Reader, w io.Writer) *StreamStore {
+	return &StreamStore{p: p, r: r, w: w}
+}
+
+// Read decodes the configuration from the reader, see Params.LoadFrom.
+func (s *StreamStore) Read() (*Config, error) {
+	return s.p.LoadFrom(s.r)
+}
+
+// Write encodes the configuration to the writer, see Config.Encode.
+func (s *StreamStore) Write(c *Config) error {
+	return c.Encode(s.w)
+}
+
+// Update reads the configuration from the store, applies fn to it and writes
+// it back. Nothing is written if fn fails.
+func Update(s ConfigStore, fn func(*Config) error) error {
+	c, err := s.Read()
+	if err != nil {
+		return err
+	}
+	if err := fn(c); err != nil {
+		return err
+	}
+	return s.Write(c)
+}
+
+// Set sets the key to the value in the configuration of the store, see
+// Config.Set.
+func Set(s ConfigStore, key, value, format string) error {
+	return Update(s, func(c *Config) error {
+		return c.Set(key, value, format)
+	})
+}
@@ -0,0 +1,93 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// memStore stores the configuration in memory, as a service storing it in
+// a key-value store would.
+type memStore struct {
+	b      []byte
+	writes int
+}
+
+func (s *memStore) Read() (*Config, error) {
+	return new(Params).LoadFrom(bytes.NewReader(s.b))
+}
+
+func (s *memStore) Write(c *Config) error {
+	b, err := Render(c, FormatYAML)
+	if err != nil {
+		return err
+	}
+	s.b = b
+	s.writes++
+	return nil
+}
+
+func TestStoreSet(t *testing.T) {
+	s := new(memStore)
+	require.NoError(t, Set(s, "redpanda.node_id", "3", "yaml"))
+	require.NoError(t, Set(s, "redpanda.rack", "r1", "yaml"))
+	require.Equal(t, 2, s.writes)
+
+	cfg, err := s.Read()
+	require.NoError(t, err)
+	require.Equal(t, 3, cfg.Redpanda.ID)
+	require.Equal(t, "r1", cfg.Redpanda.Rack)
+
+	err = Set(s, "redpanda.node_id", "three", "yaml")
+	require.Error(t, err)
+	require.Equal(t, 2, s.writes, "a failed set must not write")
+}
+
+func TestStoreUpdate(t *testing.T) {
+	s := &memStore{b: []byte("redpanda:\n  node_id: 1\n")}
+	require.NoError(t, Update(s, func(c *Config) error {
+		_, err := SetSeedServers(c, []SeedServer{{Host: SocketAddress{"10.0.0.1", 33145}}})
+		return err
+	}))
+	cfg, err := s.Read()
+	require.NoError(t, err)
+	require.Equal(t, 1, cfg.Redpanda.ID)
+	require.Equal(t, []SeedServer{{Host: SocketAddress{"10.0.0.1", 33145}}}, cfg.Redpanda.SeedServers)
+
+	errFail := errors.New("fail")
+	require.ErrorIs(t, Update(s, func(*Config) error { return errFail }), errFail)
+	require.Equal(t, 1, s.writes)
+}
+
+func TestFsStore(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	s := NewFsStore(fs, &Params{ConfigPath: "/etc/redpanda/redpanda.yaml"})
+	require.NoError(t, Set(s, "redpanda.node_id", "2", "yaml"))
+
+	cfg, err := new(Params).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 2, cfg.Redpanda.ID)
+}
+
+func TestStreamStore(t *testing.T) {
+	var out bytes.Buffer
+	s := NewStreamStore(new(Params), bytes.NewBufferString("redpanda:\n  node_id: 1\n"), &out)
+	require.NoError(t, Set(s, "redpanda.rack", "r1", "yaml"))
+
+	cfg, err := new(Params).LoadFrom(&out)
+	require.NoError(t, err)
+	require.Equal(t, 1, cfg.Redpanda.ID)
+	require.Equal(t, "r1", cfg.Redpanda.Rack)
+}
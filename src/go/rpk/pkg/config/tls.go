@@ -0,0 +1,92 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import "fmt"
+
+// The listeners whose TLS SetListenerTLS and RemoveListenerTLS configure.
+const (
+	ListenerKafka = "kafka"
+	ListenerAdmin = "admin"
+	ListenerRPC   = "rpc"
+)
+
+// listenerTLS returns the TLS configurations of the listener, which are
+// matched to the listeners by name.
+func listenerTLS(c *Config, listener string) (*[]ServerTLS, error) {
+	switch listener {
+	case ListenerKafka:
+		return &c.Redpanda.KafkaAPITLS, nil
+	case ListenerAdmin:
+		return &c.Redpanda.AdminAPITLS, nil
+	case ListenerRPC:
+		return &c.Redpanda.RPCServerTLS, nil
+	default:
+		return nil, fmt.Errorf("unknown listener %q, must be %s, %s or %s", listener, ListenerKafka, ListenerAdmin, ListenerRPC)
+	}
+}
+
+// ListenerName returns the name of the first address of the listener, which
+// its TLS configuration is matched by, or "" if it has none. The RPC server
+// has a single, unnamed, address.
+func ListenerName(c *Config, listener string) (string, error) {
+	var addrs []NamedSocketAddress
+	switch listener {
+	case ListenerKafka:
+		addrs = c.Redpanda.KafkaAPI
+	case ListenerAdmin:
+		addrs = c.Redpanda.AdminAPI
+	case ListenerRPC:
+		return "", nil
+	default:
+		_, err := listenerTLS(c, listener)
+		return "", err
+	}
+	if len(addrs) == 0 {
+		return "", nil
+	}
+	return addrs[0].Name, nil
+}
+
+// SetListenerTLS sets t as the TLS configuration of the listener named
+// t.Name, replacing the current one if there is one for that name, or adding
+// it otherwise. The other keys of a replaced configuration, that rpk does not
+// manage, are kept.
+func SetListenerTLS(c *Config, listener string, t ServerTLS) error {
+	tlss, err := listenerTLS(c, listener)
+	if err != nil {
+		return err
+	}
+	for i, cur := range *tlss {
+		if cur.Name == t.Name {
+			t.Other = cur.Other
+			(*tlss)[i] = t
+			return nil
+		}
+	}
+	*tlss = append(*tlss, t)
+	return nil
+}
+
+// RemoveListenerTLS removes the TLS configuration of the listener with the
+// given name, and returns whether there was one.
+func RemoveListenerTLS(c *Config, listener, name string) (bool, error) {
+	tlss, err := listenerTLS(c, listener)
+	if err != nil {
+		return false, err
+	}
+	for i, cur := range *tlss {
+		if cur.Name == name {
+			*tlss = append((*tlss)[:i:i], (*tlss)[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
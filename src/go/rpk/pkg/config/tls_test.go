@@ -0,0 +1,73 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetListenerTLS(t *testing.T) {
+	c := Default()
+	external := ServerTLS{Name: "external", Enabled: true, CertFile: "/certs/ext.crt", KeyFile: "/certs/ext.key"}
+	require.NoError(t, SetListenerTLS(c, ListenerKafka, external))
+	require.Equal(t, []ServerTLS{external}, c.Redpanda.KafkaAPITLS)
+
+	// Another name is added, the same name is replaced and keeps its
+	// unmanaged keys.
+	internal := ServerTLS{Name: "internal", Enabled: true, CertFile: "/certs/int.crt", KeyFile: "/certs/int.key"}
+	require.NoError(t, SetListenerTLS(c, ListenerKafka, internal))
+	c.Redpanda.KafkaAPITLS[0].Other = map[string]interface{}{"extra": 1}
+	replaced := external
+	replaced.TruststoreFile = "/certs/ca.crt"
+	replaced.RequireClientAuth = true
+	require.NoError(t, SetListenerTLS(c, ListenerKafka, replaced))
+	replaced.Other = map[string]interface{}{"extra": 1}
+	require.Equal(t, []ServerTLS{replaced, internal}, c.Redpanda.KafkaAPITLS)
+
+	require.NoError(t, SetListenerTLS(c, ListenerAdmin, internal))
+	require.Equal(t, []ServerTLS{internal}, c.Redpanda.AdminAPITLS)
+	require.NoError(t, SetListenerTLS(c, ListenerRPC, ServerTLS{Enabled: true}))
+	require.Equal(t, []ServerTLS{{Enabled: true}}, c.Redpanda.RPCServerTLS)
+
+	require.Error(t, SetListenerTLS(c, "pandaproxy", internal))
+
+	removed, err := RemoveListenerTLS(c, ListenerKafka, "external")
+	require.NoError(t, err)
+	require.True(t, removed)
+	require.Equal(t, []ServerTLS{internal}, c.Redpanda.KafkaAPITLS)
+	removed, err = RemoveListenerTLS(c, ListenerKafka, "external")
+	require.NoError(t, err)
+	require.False(t, removed)
+}
+
+func TestListenerName(t *testing.T) {
+	c := Default()
+	c.Redpanda.KafkaAPI[0].Name = "internal"
+	for _, test := range []struct {
+		listener string
+		exp      string
+		expErr   bool
+	}{
+		{listener: ListenerKafka, exp: "internal"},
+		{listener: ListenerAdmin, exp: ""},
+		{listener: ListenerRPC, exp: ""},
+		{listener: "pandaproxy", expErr: true},
+	} {
+		name, err := ListenerName(c, test.listener)
+		if test.expErr {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, test.exp, name)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// The formats a config file can be written in, FormatJSON, which Render and
+// ReadFromBytes also support, and FormatHCL, which only Render supports.
+const (
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+	FormatJSON = "json"
+	FormatHCL  = "hcl"
+)
+
+// fileFormat returns the format of the config file at path: format if it is
+// set, or the format matching the file extension, defaulting to YAML.
+func fileFormat(format, path string) (string, error) {
+	switch strings.ToLower(format) {
+	case FormatYAML:
+		return FormatYAML, nil
+	case FormatTOML:
+		return FormatTOML, nil
+	case "":
+	default:
+		return "", withKind(ErrInvalidFormat, fmt.Errorf("unsupported config format %q, must be %s or %s", format, FormatYAML, FormatTOML))
+	}
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		return FormatTOML, nil
+	}
+	return FormatYAML, nil
+}
+
+// The config is decoded and encoded as YAML, so that TOML files go through
+// the same (weak) decoding and share the yaml struct tags. TOML files are
+// converted to and from YAML through a generic map.
+
+func tomlToYAML(b []byte) ([]byte, error) {
+	var m map[string]interface{}
+	if _, err := toml.Decode(string(b), &m); err != nil {
+		return nil, withKind(ErrInvalidFormat, fmt.Errorf("unable to toml decode: %w", err))
+	}
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return yaml.Marshal(m)
+}
+
+func yamlToTOML(b []byte) ([]byte, error) {
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(m); err != nil {
+		return nil, fmt.Errorf("unable to toml encode: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOMLRoundTrip(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	p := &Params{ConfigPath: "/etc/redpanda/redpanda.toml"}
+	cfg, err := p.Load(fs)
+	require.NoError(t, err)
+
+	cfg.Redpanda.ID = 3
+	cfg.Redpanda.Rack = "r1"
+	cfg.Redpanda.SeedServers = []SeedServer{
+		{Host: SocketAddress{"10.0.0.1", 33145}},
+		{Host: SocketAddress{"10.0.0.2", 33146}},
+	}
+	cfg.Rpk.TuneNetwork = true
+	require.NoError(t, cfg.Write(fs))
+
+	b, err := afero.ReadFile(fs, "/etc/redpanda/redpanda.toml")
+	require.NoError(t, err)
+	require.Contains(t, string(b), "[redpanda]")
+	require.Contains(t, string(b), "[[redpanda.seed_servers]]")
+
+	read, err := p.Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, cfg.Redpanda, read.Redpanda)
+	require.Equal(t, cfg.Rpk, read.Rpk)
+	require.Equal(t, cfg.ConfigFile, read.ConfigFile)
+	require.Equal(t, cfg.Pandaproxy, read.Pandaproxy)
+	require.Equal(t, cfg.SchemaRegistry, read.SchemaRegistry)
+
+	// The file is written back as TOML.
+	read.Redpanda.ID = 4
+	require.NoError(t, read.Write(fs))
+	read, err = p.Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, 4, read.Redpanda.ID)
+}
+
+func TestFileFormat(t *testing.T) {
+	for _, test := range []struct {
+		format, path string
+		exp          string
+		expErr       bool
+	}{
+		{"", "/etc/redpanda/redpanda.yaml", FormatYAML, false},
+		{"", "/etc/redpanda/redpanda.TOML", FormatTOML, false},
+		{"", "/etc/redpanda/redpanda", FormatYAML, false},
+		{"toml", "/etc/redpanda/redpanda.yaml", FormatTOML, false},
+		{"YAML", "/etc/redpanda/redpanda.toml", FormatYAML, false},
+		{"json", "/etc/redpanda/redpanda.yaml", "", true},
+	} {
+		got, err := fileFormat(test.format, test.path)
+		if test.expErr {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, test.exp, got, "%s %s", test.format, test.path)
+	}
+}
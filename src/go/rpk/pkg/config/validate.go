@@ -0,0 +1,291 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	vnet "github.com/redpanda-data/redpanda/src/go/rpk/pkg/net"
+	"gopkg.in/yaml.v3"
+)
+
+// Validator is a check of the configuration that returns every problem found,
+// see RegisterValidator.
+type Validator func(*Config) []error
+
+var (
+	validatorsMu sync.Mutex
+	validators   = make(map[string]Validator)
+)
+
+// RegisterValidator registers fn under name, for Validate to run it after the
+// built-in checks, e.g. for a build embedding rpk to enforce the invariants of
+// its organization. The errors fn returns are prefixed with name. It panics if
+// fn is nil or if a validator is already registered under name.
+func RegisterValidator(name string, fn func(*Config) []error) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	if fn == nil {
+		panic("config: RegisterValidator fn is nil")
+	}
+	if _, dup := validators[name]; dup {
+		panic("config: RegisterValidator called twice for validator " + name)
+	}
+	validators[name] = fn
+}
+
+// runValidators returns the errors of the registered validators, which run
+// sorted by name.
+func runValidators(c *Config) []error {
+	validatorsMu.Lock()
+	names := make([]string, 0, len(validators))
+	for name := range validators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fns := make([]Validator, len(names))
+	for i, name := range names {
+		fns[i] = validators[name]
+	}
+	validatorsMu.Unlock()
+
+	var errs []error
+	for i, fn := range fns {
+		for _, err := range fn(c) {
+			errs = append(errs, fmt.Errorf("%s: %w", names[i], err))
+		}
+	}
+	return errs
+}
+
+// Validate checks the configuration for correctness and returns every
+// problem found. It is stricter than Check: addresses must be valid IPs or
+// hostnames, ports must be within 1-65535, seed servers must be unique and
+// the RPC, Kafka API and Admin API listeners must not collide. The validators
+// registered with RegisterValidator run last.
+func Validate(c *Config) []error {
+	var errs []error
+	rp := c.Redpanda
+	if rp.Directory == "" {
+		errs = append(errs, fmt.Errorf("redpanda.data_directory can't be empty"))
+	}
+	if rp.ID < 0 {
+		errs = append(errs, fmt.Errorf("redpanda.node_id can't be a negative integer"))
+	}
+
+	errs = append(errs, validateSocketAddress(rp.RPCServer, "redpanda.rpc_server")...)
+	if rp.AdvertisedRPCAPI != nil {
+		errs = append(errs, validateSocketAddress(*rp.AdvertisedRPCAPI, "redpanda.advertised_rpc_api")...)
+	}
+	if len(rp.KafkaAPI) == 0 {
+		errs = append(errs, fmt.Errorf("redpanda.kafka_api missing"))
+	}
+	errs = append(errs, validateNamedSocketAddresses(rp.KafkaAPI, "redpanda.kafka_api")...)
+	errs = append(errs, validateNamedSocketAddresses(rp.AdvertisedKafkaAPI, "redpanda.advertised_kafka_api")...)
+	errs = append(errs, validateNamedSocketAddresses(rp.AdminAPI, "redpanda.admin")...)
+	errs = append(errs, validateListenerCollisions(rp)...)
+	if rp.CoprocSupervisorServer != (SocketAddress{}) {
+		errs = append(errs, validateSocketAddress(rp.CoprocSupervisorServer, "redpanda.coproc_supervisor_server")...)
+	}
+
+	seen := make(map[SocketAddress]int, len(rp.SeedServers))
+	for i, seed := range rp.SeedServers {
+		configPath := fmt.Sprintf("redpanda.seed_servers[%d].host", i)
+		errs = append(errs, validateSocketAddress(seed.Host, configPath)...)
+		if j, ok := seen[seed.Host]; ok {
+			errs = append(errs, fmt.Errorf("%s is a duplicate of redpanda.seed_servers[%d].host", configPath, j))
+			continue
+		}
+		seen[seed.Host] = i
+	}
+
+	if pp := c.Pandaproxy; pp != nil {
+		errs = append(errs, validateNamedSocketAddresses(pp.PandaproxyAPI, "pandaproxy.pandaproxy_api")...)
+		errs = append(errs, validateNamedSocketAddresses(pp.AdvertisedPandaproxyAPI, "pandaproxy.advertised_pandaproxy_api")...)
+	}
+	if sr := c.SchemaRegistry; sr != nil {
+		errs = append(errs, validateNamedSocketAddresses(sr.SchemaRegistryAPI, "schema_registry.schema_registry_api")...)
+	}
+	if kc := c.PandaproxyClient; kc != nil {
+		errs = append(errs, validateSocketAddresses(kc.Brokers, "pandaproxy_client.brokers")...)
+	}
+	if kc := c.SchemaRegistryClient; kc != nil {
+		errs = append(errs, validateSocketAddresses(kc.Brokers, "schema_registry_client.brokers")...)
+	}
+
+	errs = append(errs, c.invalidRpk...)
+	errs = append(errs, checkRpkConfig(c)...)
+	return append(errs, runValidators(c)...)
+}
+
+// checkRpkSection returns the unknown and mistyped properties of the rpk
+// section of the YAML config file b, see checkNodeTypes.
+func checkRpkSection(b []byte) []error {
+	var file struct {
+		Rpk yaml.Node `yaml:"rpk"`
+	}
+	if err := yaml.Unmarshal(b, &file); err != nil {
+		return nil
+	}
+	return checkNodeTypes(&file.Rpk, reflect.TypeOf(RpkConfig{}), "rpk")
+}
+
+// checkNodeTypes returns an error for each property of the mapping n that is
+// not a field of the struct type t, and for each boolean or integer field
+// whose value is not a YAML boolean or integer, e.g. the string "true". The
+// weak decoding accepts these, but Validate is strict.
+func checkNodeTypes(n *yaml.Node, t reflect.Type, configPath string) []error {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	var errs []error
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i].Value, n.Content[i+1]
+		p := configPath + "." + k
+		f, ok := fieldByTag(t, k)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s is not a known property", p))
+			continue
+		}
+		if v.Tag == "!!null" {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		switch ft.Kind() {
+		case reflect.Bool:
+			if v.Tag != "!!bool" {
+				errs = append(errs, fmt.Errorf("%s must be a boolean, got %q", p, v.Value))
+			}
+		case reflect.Int:
+			if v.Tag != "!!int" {
+				errs = append(errs, fmt.Errorf("%s must be an integer, got %q", p, v.Value))
+			}
+		case reflect.Struct:
+			if _, hasOther := ft.FieldByName("Other"); !hasOther {
+				errs = append(errs, checkNodeTypes(v, ft, p)...)
+			}
+		}
+	}
+	return errs
+}
+
+// fieldByTag returns the exported field of the struct type t with the given
+// yaml tag.
+func fieldByTag(t reflect.Type, tag string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.IsExported() && strings.Split(f.Tag.Get("yaml"), ",")[0] == tag {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// validateListenerCollisions returns an error for each pair of the RPC,
+// Kafka API and Admin API listeners that listen on the same address and port,
+// which redpanda would fail to bind at startup. Unset ports are reported by
+// the address validation instead.
+func validateListenerCollisions(rp RedpandaConfig) []error {
+	type listener struct {
+		path string
+		addr SocketAddress
+	}
+	listeners := []listener{{"redpanda.rpc_server", rp.RPCServer}}
+	for i, a := range rp.KafkaAPI {
+		listeners = append(listeners, listener{fmt.Sprintf("redpanda.kafka_api[%d]", i), SocketAddress{a.Address, a.Port}})
+	}
+	for i, a := range rp.AdminAPI {
+		listeners = append(listeners, listener{fmt.Sprintf("redpanda.admin[%d]", i), SocketAddress{a.Address, a.Port}})
+	}
+
+	var errs []error
+	seen := make(map[SocketAddress]string, len(listeners))
+	for _, l := range listeners {
+		if l.addr.Port == 0 {
+			continue
+		}
+		if prev, ok := seen[l.addr]; ok {
+			errs = append(errs, fmt.Errorf("%s and %s both listen on %s", prev, l.path, net.JoinHostPort(l.addr.Address, strconv.Itoa(l.addr.Port))))
+			continue
+		}
+		seen[l.addr] = l.path
+	}
+	return errs
+}
+
+func validateNamedSocketAddresses(addrs []NamedSocketAddress, configPath string) []error {
+	var errs []error
+	for i, a := range addrs {
+		p := fmt.Sprintf("%s[%d]", configPath, i)
+		errs = append(errs, validateSocketAddress(SocketAddress{a.Address, a.Port}, p)...)
+	}
+	return errs
+}
+
+func validateSocketAddresses(addrs []SocketAddress, configPath string) []error {
+	var errs []error
+	for i, a := range addrs {
+		errs = append(errs, validateSocketAddress(a, fmt.Sprintf("%s[%d]", configPath, i))...)
+	}
+	return errs
+}
+
+func validateSocketAddress(s SocketAddress, configPath string) []error {
+	return s.validate(configPath + ".")
+}
+
+// Validate returns an error if the address is not a valid IP or hostname, or
+// if the port is not within 1-65535. The error names the invalid fields.
+func (s SocketAddress) Validate() error {
+	errs := s.validate("")
+	if len(errs) == 0 {
+		return nil
+	}
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// validate returns an error for each invalid field, the field names being
+// prefixed with prefix.
+func (s SocketAddress) validate(prefix string) []error {
+	var errs []error
+	if s.Address == "" {
+		errs = append(errs, fmt.Errorf("%saddress can't be empty", prefix))
+	} else if !isValidHost(s.Address) {
+		errs = append(errs, fmt.Errorf("%saddress %q is not a valid IP or hostname", prefix, s.Address))
+	}
+	if s.Port < 1 || s.Port > 65535 {
+		errs = append(errs, fmt.Errorf("%sport %d is out of the range [1, 65535]", prefix, s.Port))
+	}
+	return errs
+}
+
+// isValidHost returns whether h is an IP or a hostname, with no scheme nor
+// port.
+func isValidHost(h string) bool {
+	if net.ParseIP(h) != nil {
+		return true
+	}
+	_, host, err := vnet.ParseHostMaybeScheme(h)
+	return err == nil && host == h && !strings.Contains(h, ":")
+}
@@ -0,0 +1,326 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		conf func() *Config
+		exp  []string
+	}{
+		{
+			name: "valid config",
+			conf: getValidConfig,
+		},
+		{
+			name: "valid hostnames and IPv6 addresses",
+			conf: func() *Config {
+				c := getValidConfig()
+				c.Redpanda.RPCServer.Address = "redpanda-0.redpanda.svc"
+				c.Redpanda.KafkaAPI[0].Address = "::"
+				return c
+			},
+		},
+		{
+			name: "negative node id and empty data directory",
+			conf: func() *Config {
+				c := getValidConfig()
+				c.Redpanda.ID = -1
+				c.Redpanda.Directory = ""
+				return c
+			},
+			exp: []string{
+				"redpanda.data_directory can't be empty",
+				"redpanda.node_id can't be a negative integer",
+			},
+		},
+		{
+			name: "invalid rpc server address",
+			conf: func() *Config {
+				c := getValidConfig()
+				c.Redpanda.RPCServer.Address = "10.0.0.256:33145"
+				return c
+			},
+			exp: []string{`redpanda.rpc_server.address "10.0.0.256:33145" is not a valid IP or hostname`},
+		},
+		{
+			name: "ports out of range",
+			conf: func() *Config {
+				c := getValidConfig()
+				c.Redpanda.KafkaAPI = append(c.Redpanda.KafkaAPI, NamedSocketAddress{Address: "0.0.0.0", Port: 65536})
+				c.Redpanda.AdminAPI[0].Port = -1
+				c.Redpanda.RPCServer.Port = 0
+				return c
+			},
+			exp: []string{
+				"redpanda.rpc_server.port 0 is out of the range [1, 65535]",
+				"redpanda.kafka_api[1].port 65536 is out of the range [1, 65535]",
+				"redpanda.admin[0].port -1 is out of the range [1, 65535]",
+			},
+		},
+		{
+			name: "duplicate and empty seed servers",
+			conf: func() *Config {
+				c := getValidConfig()
+				c.Redpanda.SeedServers = []SeedServer{
+					{SocketAddress{"10.0.0.1", 33145}},
+					{SocketAddress{"10.0.0.2", 33145}},
+					{SocketAddress{"10.0.0.1", 33145}},
+					{SocketAddress{"", 33145}},
+				}
+				return c
+			},
+			exp: []string{
+				"redpanda.seed_servers[2].host is a duplicate of redpanda.seed_servers[0].host",
+				"redpanda.seed_servers[3].host.address can't be empty",
+			},
+		},
+		{
+			name: "distinct listeners on the same port",
+			conf: func() *Config {
+				c := getValidConfig()
+				c.Redpanda.RPCServer = SocketAddress{"10.0.0.1", 9092}
+				c.Redpanda.KafkaAPI = []NamedSocketAddress{{Address: "10.0.0.2", Port: 9092}, {Address: "10.0.0.2", Port: 9093}}
+				c.Redpanda.AdminAPI = []NamedSocketAddress{{Address: "10.0.0.3", Port: 9092}}
+				return c
+			},
+		},
+		{
+			name: "kafka api collides with rpc server",
+			conf: func() *Config {
+				c := getValidConfig()
+				c.Redpanda.RPCServer = SocketAddress{"0.0.0.0", 9092}
+				c.Redpanda.KafkaAPI = []NamedSocketAddress{{Address: "0.0.0.0", Port: 9092}}
+				return c
+			},
+			exp: []string{"redpanda.rpc_server and redpanda.kafka_api[0] both listen on 0.0.0.0:9092"},
+		},
+		{
+			name: "admin api collides with rpc server",
+			conf: func() *Config {
+				c := getValidConfig()
+				c.Redpanda.RPCServer = SocketAddress{"::1", 9644}
+				c.Redpanda.AdminAPI = []NamedSocketAddress{{Address: "::1", Port: 9644}}
+				return c
+			},
+			exp: []string{"redpanda.rpc_server and redpanda.admin[0] both listen on [::1]:9644"},
+		},
+		{
+			name: "admin api collides with kafka api",
+			conf: func() *Config {
+				c := getValidConfig()
+				c.Redpanda.KafkaAPI = []NamedSocketAddress{{Address: "0.0.0.0", Port: 9092}, {Address: "10.0.0.1", Port: 9644}}
+				c.Redpanda.AdminAPI = []NamedSocketAddress{{Address: "10.0.0.1", Port: 9644}}
+				return c
+			},
+			exp: []string{"redpanda.kafka_api[1] and redpanda.admin[0] both listen on 10.0.0.1:9644"},
+		},
+		{
+			name: "kafka api listeners collide",
+			conf: func() *Config {
+				c := getValidConfig()
+				c.Redpanda.KafkaAPI = []NamedSocketAddress{{Address: "0.0.0.0", Port: 9092, Name: "internal"}, {Address: "0.0.0.0", Port: 9092, Name: "external"}}
+				return c
+			},
+			exp: []string{"redpanda.kafka_api[0] and redpanda.kafka_api[1] both listen on 0.0.0.0:9092"},
+		},
+		{
+			name: "missing kafka api",
+			conf: func() *Config {
+				c := getValidConfig()
+				c.Redpanda.KafkaAPI = nil
+				return c
+			},
+			exp: []string{"redpanda.kafka_api missing"},
+		},
+		{
+			name: "invalid pandaproxy address",
+			conf: func() *Config {
+				c := getValidConfig()
+				c.Pandaproxy.PandaproxyAPI = []NamedSocketAddress{{Address: "-bad-", Port: 8082}}
+				return c
+			},
+			exp: []string{`pandaproxy.pandaproxy_api[0].address "-bad-" is not a valid IP or hostname`},
+		},
+		{
+			name: "invalid client brokers and coproc supervisor server",
+			conf: func() *Config {
+				c := getValidConfig()
+				c.Redpanda.CoprocSupervisorServer = SocketAddress{"0.0.0.0", 70000}
+				c.PandaproxyClient = &KafkaClient{Brokers: []SocketAddress{{"10.0.0.1", 9092}, {"", 9092}}}
+				return c
+			},
+			exp: []string{
+				"redpanda.coproc_supervisor_server.port 70000 is out of the range [1, 65535]",
+				"pandaproxy_client.brokers[1].address can't be empty",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var errs []string
+			for _, err := range Validate(test.conf()) {
+				errs = append(errs, err.Error())
+			}
+			require.Equal(t, test.exp, errs)
+		})
+	}
+}
+
+func TestRegisterValidator(t *testing.T) {
+	errSubnet := errors.New("seed servers must not be in 192.168.0.0/16")
+	RegisterValidator("node-ids", func(c *Config) []error {
+		if c.Redpanda.ID >= 1000 {
+			return []error{fmt.Errorf("redpanda.node_id %d must be below 1000", c.Redpanda.ID)}
+		}
+		return nil
+	})
+	RegisterValidator("approved-subnets", func(c *Config) []error {
+		var errs []error
+		for _, s := range c.Redpanda.SeedServers {
+			if strings.HasPrefix(s.Host.Address, "192.168.") {
+				errs = append(errs, errSubnet)
+			}
+		}
+		return errs
+	})
+	defer func() {
+		validatorsMu.Lock()
+		defer validatorsMu.Unlock()
+		delete(validators, "node-ids")
+		delete(validators, "approved-subnets")
+	}()
+
+	c := getValidConfig()
+	require.Empty(t, Validate(c))
+
+	c.Redpanda.ID = 1000
+	c.Redpanda.SeedServers = []SeedServer{
+		{Host: SocketAddress{"192.168.0.1", 33145}},
+	}
+	c.Redpanda.RPCServer.Port = 0
+	errs := Validate(c)
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	require.Equal(t, []string{
+		"redpanda.rpc_server.port 0 is out of the range [1, 65535]",
+		"approved-subnets: seed servers must not be in 192.168.0.0/16",
+		"node-ids: redpanda.node_id 1000 must be below 1000",
+	}, msgs)
+	require.ErrorIs(t, errs[1], errSubnet)
+
+	require.Panics(t, func() { RegisterValidator("node-ids", func(*Config) []error { return nil }) })
+	require.Panics(t, func() { RegisterValidator("nil", nil) })
+}
+
+func TestSocketAddressValidate(t *testing.T) {
+	for _, test := range []struct {
+		addr   SocketAddress
+		expErr string
+	}{
+		{SocketAddress{"0.0.0.0", 9092}, ""},
+		{SocketAddress{"::1", 1}, ""},
+		{SocketAddress{"redpanda-0.redpanda.svc", 65535}, ""},
+		{SocketAddress{"", 9092}, "address can't be empty"},
+		{SocketAddress{"10.0.0.1:9092", 9092}, `address "10.0.0.1:9092" is not a valid IP or hostname`},
+		{SocketAddress{"0.0.0.0", 0}, "port 0 is out of the range [1, 65535]"},
+		{SocketAddress{"0.0.0.0", 65536}, "port 65536 is out of the range [1, 65535]"},
+		{SocketAddress{"", -1}, "address can't be empty; port -1 is out of the range [1, 65535]"},
+	} {
+		err := test.addr.Validate()
+		if test.expErr == "" {
+			require.NoError(t, err, "%v", test.addr)
+			continue
+		}
+		require.EqualError(t, err, test.expErr, "%v", test.addr)
+	}
+}
+
+func TestValidateRpk(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		rpk  string
+		exp  []string
+	}{
+		{
+			name: "valid toggles",
+			rpk: `rpk:
+  tune_network: true
+  tune_cpu: false
+  smp: 2
+  coredump_dir: /var/lib/redpanda/coredump
+  kafka_api:
+    brokers: [127.0.0.1:9092]
+`,
+		},
+		{
+			name: "unknown properties",
+			rpk: `rpk:
+  tune_netwrok: true
+  kafka_api:
+    brokerz: [127.0.0.1:9092]
+`,
+			exp: []string{
+				"rpk.tune_netwrok is not a known property",
+				"rpk.kafka_api.brokerz is not a known property",
+			},
+		},
+		{
+			name: "mistyped properties",
+			rpk: `rpk:
+  tune_network: "true"
+  tune_cpu: 1
+  smp: "2"
+`,
+			exp: []string{
+				`rpk.tune_network must be a boolean, got "true"`,
+				`rpk.tune_cpu must be a boolean, got "1"`,
+				`rpk.smp must be an integer, got "2"`,
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			file := `redpanda:
+  data_directory: /var/lib/redpanda/data
+  rpc_server:
+    address: 0.0.0.0
+    port: 33145
+  kafka_api:
+    - address: 0.0.0.0
+      port: 9092
+  admin:
+    - address: 0.0.0.0
+      port: 9644
+` + test.rpk
+			require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(file), 0o644))
+			cfg, err := new(Params).Load(fs)
+			require.NoError(t, err)
+
+			var errs []string
+			for _, err := range Validate(cfg) {
+				errs = append(errs, err.Error())
+			}
+			require.Equal(t, test.exp, errs)
+		})
+	}
+}
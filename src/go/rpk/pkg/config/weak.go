@@ -270,6 +270,8 @@ func (ss *seedServers) UnmarshalYAML(n *yaml.Node) error {
 
 func (c *Config) UnmarshalYAML(n *yaml.Node) error {
 	var internal struct {
+		Version              weakInt         `yaml:"config_version"`
+		Includes             weakStringArray `yaml:"includes"`
 		NodeUUID             weakString      `yaml:"node_uuid"`
 		Organization         weakString      `yaml:"organization"`
 		LicenseKey           weakString      `yaml:"license_key"`
@@ -287,6 +289,8 @@ func (c *Config) UnmarshalYAML(n *yaml.Node) error {
 	if err := n.Decode(&internal); err != nil {
 		return err
 	}
+	c.Version = int(internal.Version)
+	c.Includes = internal.Includes
 	c.NodeUUID = string(internal.NodeUUID)
 	c.Organization = string(internal.Organization)
 	c.LicenseKey = string(internal.LicenseKey)
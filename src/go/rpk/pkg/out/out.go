@@ -51,8 +51,13 @@ func Pick(options []string, msg string, args ...interface{}) (string, error) {
 // Die formats the message with a suffixed newline to stderr and exits the
 // process with 1.
 func Die(msg string, args ...interface{}) {
+	DieCode(1, msg, args...)
+}
+
+// DieCode is Die, but it exits the process with the given code.
+func DieCode(code int, msg string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, msg+"\n", args...)
-	os.Exit(1)
+	os.Exit(code)
 }
 
 // MaybeDie calls Die if err is non-nil.